@@ -48,13 +48,70 @@ func Backtrack(vertex *VertexWrapper) []Edge {
 	}
 	var path []Edge
 	var currentWrapper = vertex
-	for currentWrapper.Previous != nil && currentWrapper.Hash() != currentWrapper.Previous.Hash() {
-		path = append(path, currentWrapper.Previous.Inner.GetEdge(currentWrapper.Inner))
+	for currentWrapper.Previous != nil && VertexHashOrId(currentWrapper) != VertexHashOrId(currentWrapper.Previous) {
+		edge := currentWrapper.Via
+		if edge == nil {
+			edge = currentWrapper.Previous.Inner.GetEdge(currentWrapper.Inner)
+		}
+		path = append(path, edge)
 		currentWrapper = currentWrapper.Previous
 	}
 	return path
 }
 
+// mayHaveParallelEdges reports whether the edges of vertex may include parallel edges, which only
+// multigraphs keep apart. Vertices of other graph types are assumed to have some.
+func mayHaveParallelEdges(vertex Vertex) bool {
+	switch v := vertex.(type) {
+	case *SimpleVertex:
+		return v.multi
+	case *FrozenVertex:
+		return v.graph.multi
+	case *VertexWrapper:
+		return mayHaveParallelEdges(v.Inner)
+	}
+	return true
+}
+
+// cheapestEdges keeps only the cheapest of each group of parallel edges under costFunctions, so
+// that routing on a multigraph takes the cheapest edge to every neighbour.
+func cheapestEdges(curr *VertexWrapper, edges []Edge, costFunctions map[string]CostFunction, costCombiner CostCombiner) []Edge {
+	if !mayHaveParallelEdges(curr.Inner) {
+		return edges
+	}
+	seen := make(map[int64]bool, len(edges))
+	parallel := false
+	for _, edge := range edges {
+		key := VertexHashOrId(ToVertex(edge.To()))
+		if seen[key] {
+			parallel = true
+			break
+		}
+		seen[key] = true
+	}
+	if !parallel {
+		return edges
+	}
+	retArray := make([]Edge, 0, len(edges))
+	costs := make([]float64, 0, len(edges))
+	indices := make(map[int64]int, len(edges))
+	for _, edge := range edges {
+		key := VertexHashOrId(ToVertex(edge.To()))
+		cost := costCombiner(GenerateNextCosts(curr, ViaEdge(edge), costFunctions)).Current
+		if index, ok := indices[key]; ok {
+			if cost < costs[index] {
+				retArray[index] = edge
+				costs[index] = cost
+			}
+			continue
+		}
+		indices[key] = len(retArray)
+		retArray = append(retArray, edge)
+		costs = append(costs, cost)
+	}
+	return retArray
+}
+
 var BFS RoutingAlgorithm = func(parameters RoutingAlgorithmRequest) RoutingAlgorithmResponse {
 	start := parameters.Start
 	destination := parameters.Destination
@@ -90,6 +147,7 @@ var BFS RoutingAlgorithm = func(parameters RoutingAlgorithmRequest) RoutingAlgor
 		if currCombined < bestCombined {
 			best = NewVertexWrapper(curr.Inner, curr.Costs, costCombiner)
 			best.Previous = curr.Previous
+			best.Via = curr.Via
 			bestCombined = currCombined
 			if len(updateListeners) > 0 {
 				VisitRoutingAlgorithmUpdateListeners(updateListeners, RoutingAlgorithmResponse{
@@ -99,14 +157,15 @@ var BFS RoutingAlgorithm = func(parameters RoutingAlgorithmRequest) RoutingAlgor
 					false})
 			}
 		}
-		if curr.Hash() == destination.Hash() {
+		if VertexHashOrId(curr) == VertexHashOrId(destination) {
 			break
 		}
-		for _, edge := range curr.Inner.GetEdges() {
+		for _, edge := range cheapestEdges(curr, curr.Inner.GetEdges(), costFunctions, costCombiner) {
 			toVertex := ToVertex(edge.To())
 			hashOrId := VertexHashOrId(toVertex)
-			nextCosts := GenerateNextCosts(curr, toVertex, costFunctions)
+			nextCosts := GenerateNextCosts(curr, ViaEdge(edge), costFunctions)
 			successor := NewVertexWrapper(toVertex, nextCosts, costCombiner)
+			successor.Via = edge
 			successor.Combined.Total = successor.Combined.Accumulated
 			if !goutils.SetContains(visited, hashOrId) {
 				pass := true
@@ -167,6 +226,7 @@ type PathState struct {
 	current       float64
 	total         float64
 	previous      *PathState
+	via           Edge
 }
 
 func PathStateToVertexWrapper(state *PathState) *VertexWrapper {
@@ -211,6 +271,7 @@ var DFS RoutingAlgorithm = func(parameters RoutingAlgorithmRequest) RoutingAlgor
 		startCombined.Current,
 		nil,
 	)
+	startState.edges = cheapestEdges(PathStateToVertexWrapper(startState), startState.edges, costFunctions, costCombiner)
 
 	stack := []*PathState{startState}
 	visited := make(map[int64]bool)
@@ -245,6 +306,7 @@ var DFS RoutingAlgorithm = func(parameters RoutingAlgorithmRequest) RoutingAlgor
 					curr.total,
 					curr.previous,
 				)
+				best.via = curr.via
 				bestCombined = currCombined
 				if len(updateListeners) > 0 {
 					VisitRoutingAlgorithmUpdateListeners(updateListeners, RoutingAlgorithmResponse{
@@ -258,7 +320,7 @@ var DFS RoutingAlgorithm = func(parameters RoutingAlgorithmRequest) RoutingAlgor
 
 		}
 
-		if curr.vertex.Hash() == destination.Hash() {
+		if VertexHashOrId(curr.vertex) == VertexHashOrId(destination) {
 			break
 		}
 
@@ -269,7 +331,7 @@ var DFS RoutingAlgorithm = func(parameters RoutingAlgorithmRequest) RoutingAlgor
 		hashOrId := VertexHashOrId(toVertex)
 
 		if !visited[hashOrId] {
-			nextCosts := GenerateNextCosts(PathStateToVertexWrapper(curr), toVertex, costFunctions)
+			nextCosts := GenerateNextCosts(PathStateToVertexWrapper(curr), ViaEdge(edge), costFunctions)
 			combined := costCombiner(nextCosts)
 
 			pass := true
@@ -299,6 +361,8 @@ var DFS RoutingAlgorithm = func(parameters RoutingAlgorithmRequest) RoutingAlgor
 					f,
 					curr,
 				)
+				successor.via = edge
+				successor.edges = cheapestEdges(PathStateToVertexWrapper(successor), successor.edges, costFunctions, costCombiner)
 
 				stack = append(stack, successor)
 			}
@@ -336,8 +400,13 @@ func BacktrackPathState(state *PathState) []Edge {
 	path := make([]Edge, 0)
 	curr := state
 	for curr.previous != nil {
+		if curr.via != nil {
+			path = append([]Edge{curr.via}, path...)
+			curr = curr.previous
+			continue
+		}
 		for _, edge := range curr.previous.vertex.GetEdges() {
-			if ToVertex(edge.To()).Hash() == curr.vertex.Hash() {
+			if VertexHashOrId(ToVertex(edge.To())) == VertexHashOrId(curr.vertex) {
 				path = append([]Edge{edge}, path...)
 				break
 			}
@@ -388,6 +457,7 @@ var AStar RoutingAlgorithm = func(parameters RoutingAlgorithmRequest) RoutingAlg
 			if currCombined < bestCombined {
 				best = NewVertexWrapper(curr.Inner, curr.Costs, costCombiner)
 				best.Previous = curr.Previous
+				best.Via = curr.Via
 				bestCombined = currCombined
 				if len(updateListeners) > 0 {
 					VisitRoutingAlgorithmUpdateListeners(updateListeners, RoutingAlgorithmResponse{
@@ -397,14 +467,15 @@ var AStar RoutingAlgorithm = func(parameters RoutingAlgorithmRequest) RoutingAlg
 						false})
 				}
 			}
-			if curr.Hash() == destination.Hash() {
+			if VertexHashOrId(curr) == VertexHashOrId(destination) {
 				break
 			}
-			for _, edge := range curr.Inner.GetEdges() {
+			for _, edge := range cheapestEdges(curr, curr.Inner.GetEdges(), costFunctions, costCombiner) {
 				toVertex := ToVertex(edge.To())
 				hashOrId := VertexHashOrId(toVertex)
-				nextCosts := GenerateNextCosts(curr, toVertex, costFunctions)
+				nextCosts := GenerateNextCosts(curr, ViaEdge(edge), costFunctions)
 				successor := NewVertexWrapper(toVertex, nextCosts, costCombiner)
+				successor.Via = edge
 				successor.Combined.Total = successor.Combined.Accumulated + explorationFactor*costCombiner(GenerateNextCosts(successor, destination, costFunctions)).Current
 				if !goutils.SetContains(visited, hashOrId) {
 					pass := true
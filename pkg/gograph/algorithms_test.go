@@ -1,6 +1,7 @@
 package gograph
 
 import (
+	"github.com/mtresnik/gomath/pkg/gomath"
 	"image/png"
 	"math/rand"
 	"os"
@@ -31,3 +32,49 @@ func TestBFS_Evaluate(t *testing.T) {
 	defer file.Close()
 	png.Encode(file, img)
 }
+
+func TestRouting_ParallelEdges(t *testing.T) {
+	graph := NewMultiGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{2.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, 1, &map[string]float64{COST_TYPE_DISTANCE: 5.0}))
+	graph.AddEdge(NewSimpleEdge(&a, &b, 2, &map[string]float64{COST_TYPE_DISTANCE: 2.0}))
+	graph.AddEdge(NewSimpleEdge(&a, &b, 3, &map[string]float64{COST_TYPE_DISTANCE: 4.0}))
+	graph.AddEdge(NewSimpleEdge(&b, &c, 4, &map[string]float64{COST_TYPE_DISTANCE: 1.0}))
+	for name, algorithm := range map[string]RoutingAlgorithm{"BFS": BFS, "DFS": DFS, "AStar": AStar} {
+		response := algorithm(RoutingAlgorithmRequest{
+			Start:       &a,
+			Destination: &c,
+		})
+		ids := make(map[int64]bool)
+		for _, edge := range response.Path.GetEdges() {
+			ids[edge.Id()] = true
+		}
+		if len(ids) != 2 || !ids[2] || !ids[4] {
+			t.Errorf("%s: expected the path to use edges 2 and 4, got %v", name, ids)
+		}
+		if cost := GetPathCost(response.Path, nil)[COST_TYPE_DISTANCE].Total; cost != 3.0 {
+			t.Errorf("%s: expected a path cost of 3, got %f", name, cost)
+		}
+	}
+	frozen := Freeze(graph)
+	copied := NewConcurrentGraphFrom(graph).Snapshot()
+	for name, endpoints := range map[string][2]Vertex{
+		"frozen": {frozen.Vertex(&a), frozen.Vertex(&c)},
+		"copied": {copied.GetVertex(VertexHashOrId(&a)), copied.GetVertex(VertexHashOrId(&c))},
+	} {
+		response := BFS(RoutingAlgorithmRequest{Start: endpoints[0], Destination: endpoints[1]})
+		if cost := response.Costs[COST_TYPE_DISTANCE].Total; cost != 3.0 {
+			t.Errorf("%s: expected a path cost of 3, got %f", name, cost)
+		}
+	}
+
+	plain := NewSimpleGraph()
+	d := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 1.0}})
+	e := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 1.0}})
+	plain.AddEdge(NewSimpleEdge(&d, &e, -1))
+	if mayHaveParallelEdges(&d) || mayHaveParallelEdges(Freeze(plain).Vertex(&d)) || !mayHaveParallelEdges(&a) {
+		t.Error("Expected only vertices of multigraphs to be checked for parallel edges")
+	}
+}
@@ -0,0 +1,114 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"time"
+)
+
+// VertexMapping maps the VertexHashOrId of each vertex of a source graph to its counterpart in a
+// graph built from it by Clone or MapVertices.
+type VertexMapping map[int64]Vertex
+
+// Get returns the counterpart of vertex, or nil.
+func (m VertexMapping) Get(vertex Vertex) Vertex {
+	return m[VertexHashOrId(vertex)]
+}
+
+// Clone copies graph into a new SimpleGraph that shares no vertices, edges, adjacency, cost maps,
+// polyline points or properties with it. Vertex and edge ids are kept, and so are the external
+// keys of a SimpleGraph.
+func Clone(graph Graph) (*SimpleGraph, VertexMapping) {
+	var cloned *SimpleGraph
+	if simpleGraph, ok := graph.(*SimpleGraph); ok {
+		cloned = copySimpleGraph(simpleGraph)
+	} else {
+		builder := newSetOperationBuilder(graph.IsDirected())
+		for _, vertex := range graph.GetVertices() {
+			builder.addVertex(vertex)
+		}
+		for _, vertex := range graph.GetVertices() {
+			for _, edge := range vertex.GetEdges() {
+				builder.addEdge(edge)
+			}
+		}
+		cloned = builder.graph
+	}
+	cloned.id = time.Now().UnixNano()
+	mapping := make(VertexMapping, graph.Size())
+	for _, vertex := range graph.GetVertices() {
+		key := VertexHashOrId(vertex)
+		mapping[key] = cloned.GetVertex(key)
+	}
+	return cloned, mapping
+}
+
+// MapVertices builds a new graph by applying transform to the coordinates of every vertex and
+// every interior polyline point, e.g. to project latitude and longitude to meters. Ids, cost maps
+// and properties are copied unchanged, so costs that depend on coordinates must be recomputed.
+func MapVertices(graph Graph, transform func(values []float64) []float64) (*SimpleGraph, VertexMapping) {
+	mapped := newSimpleGraph(graph.IsDirected())
+	if simpleGraph, ok := graph.(*SimpleGraph); ok {
+		mapped.multi = simpleGraph.multi
+	}
+	mapping := make(VertexMapping, graph.Size())
+	mapVertex := func(vertex Vertex) Vertex {
+		key := VertexHashOrId(vertex)
+		if existing, ok := mapping[key]; ok {
+			return existing
+		}
+		copied := copyVertex(vertex)
+		copied.Spatial = gomath.Point{Values: transform(append([]float64{}, vertex.GetValues()...))}
+		copied.hash = -1
+		copied.Hash()
+		mapping[key] = copied
+		mapped.AddVertex(copied)
+		return copied
+	}
+	edgeKeys := make(map[int64]int64)
+	for _, vertex := range graph.GetVertices() {
+		mapVertex(vertex)
+	}
+	for _, vertex := range graph.GetVertices() {
+		for _, edge := range vertex.GetEdges() {
+			from := mapVertex(ToVertex(edge.From()))
+			to := mapVertex(ToVertex(edge.To()))
+			mappedEdge := mapEdge(cloneEdge(edge, from, to), transform)
+			edgeKeys[EdgeHashOrId(edge)] = EdgeHashOrId(mappedEdge)
+			if !mapped.ContainsEdge(mappedEdge) {
+				mapped.AddEdge(mappedEdge)
+			}
+		}
+	}
+	if simpleGraph, ok := graph.(*SimpleGraph); ok {
+		for key, id := range simpleGraph.vertexKeys {
+			if vertex, exists := mapping[id]; exists {
+				mapped.vertexKeys[key] = VertexHashOrId(vertex)
+			}
+		}
+		for key, id := range simpleGraph.edgeKeys {
+			if mappedId, exists := edgeKeys[id]; exists {
+				mapped.edgeKeys[key] = mappedId
+			}
+		}
+	}
+	return mapped, mapping
+}
+
+// mapEdge transforms the interior points of a polyline and drops the cached distance and hash,
+// which depend on the coordinates.
+func mapEdge(e Edge, transform func(values []float64) []float64) Edge {
+	switch edge := e.(type) {
+	case SimpleEdge:
+		edge.distance = -1
+		edge.hash = -1
+		return edge
+	case PolyEdge:
+		for i := 1; i < len(edge.Points)-1; i++ {
+			edge.Points[i] = gomath.Point{Values: transform(append([]float64{}, edge.Points[i].GetValues()...))}
+		}
+		edge.distance = -1
+		edge.hash = -1
+		return edge
+	}
+	return e
+}
@@ -0,0 +1,101 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertexWithId(gomath.Point{Values: []float64{0.0, 0.0}}, 1)
+	b := NewSimpleVertexWithId(gomath.Point{Values: []float64{1.0, 0.0}}, 2)
+	c := NewSimpleVertexWithId(gomath.Point{Values: []float64{2.0, 0.0}}, 3)
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_DISTANCE: 5.0}))
+	graph.AddEdge(NewPolyEdge([]gomath.Spatial{&b, gomath.Point{Values: []float64{1.5, 1.0}}, &c}, 10))
+	graph.SetVertexKey("a", &a)
+
+	cloned, mapping := Clone(graph)
+	if cloned.Size() != 3 || len(cloned.GetEdges()) != len(graph.GetEdges()) || !cloned.Validate().IsValid() {
+		t.Errorf("Expected an equal copy, got %d vertices and %d edges", cloned.Size(), len(cloned.GetEdges()))
+	}
+	if mapping.Get(&a) == nil || mapping.Get(&a) == Vertex(&a) || mapping.Get(&a).Id() != 1 {
+		t.Error("Expected a new vertex with the same id for a")
+	}
+	if cloned.GetVertexByKey("a") != mapping.Get(&a) {
+		t.Error("Expected external keys to be kept")
+	}
+	edge := cloned.GetEdge(EdgeHashOrId(NewSimpleEdge(&a, &b, -1)))
+	if edge == nil || (*edge.Cost())[COST_TYPE_DISTANCE] != 5.0 {
+		t.Error("Expected the cost map to be kept")
+	}
+	(*edge.Cost())[COST_TYPE_DISTANCE] = 1.0
+	if (*graph.GetEdge(EdgeHashOrId(edge)).Cost())[COST_TYPE_DISTANCE] != 5.0 {
+		t.Error("Changing the clone's cost map should not change the original")
+	}
+	polyline, ok := cloned.GetEdge(10).(PolyEdge)
+	if !ok || len(polyline.Points) != 3 || polyline.Points[1].GetValues()[1] != 1.0 {
+		t.Error("Expected the polyline to be kept")
+	}
+
+	cloned.SetEdgeCost(NewSimpleEdge(&a, &b, -1), COST_TYPE_TIME, 5.0)
+	forward, reverse := GetEdge(mapping.Get(&a), mapping.Get(&b)), GetEdge(mapping.Get(&b), mapping.Get(&a))
+	if (*forward.Cost())[COST_TYPE_TIME] != 5.0 || (*reverse.Cost())[COST_TYPE_TIME] != 5.0 {
+		t.Error("Expected both directions of the cloned edge to see the new cost")
+	}
+	GetProperties(reverse).SetString("name", "Main Street")
+	if name, _ := GetProperties(forward).GetString("name"); name != "Main Street" {
+		t.Error("Expected both directions of the cloned edge to share their properties")
+	}
+	if GetProperties(GetEdge(&a, &b)).Len() != 0 {
+		t.Error("Changing the clone's properties should not change the original")
+	}
+
+	cloned.RemoveVertex(mapping.Get(&b))
+	if len(a.GetEdges()) != 1 || len(graph.GetEdges()) != 3 {
+		t.Error("Changing the clone should not change the original")
+	}
+}
+
+func TestClone_View(t *testing.T) {
+	graph, vertices, shortcut := buildViewTestGraph()
+	cloned, mapping := Clone(NewGraphView(graph, nil, ExcludeEdges(graph, []Edge{shortcut})))
+	if cloned.Size() != 4 || len(cloned.GetEdges()) != 6 || !cloned.Validate().IsValid() {
+		t.Errorf("Expected 4 vertices and 6 edges, got %d and %d", cloned.Size(), len(cloned.GetEdges()))
+	}
+	if len(mapping.Get(vertices[0]).GetEdges()) != 1 {
+		t.Error("Expected the clone to leave out the shortcut")
+	}
+}
+
+func TestMapVertices(t *testing.T) {
+	graph := NewDirectedSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 1.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	graph.AddEdge(NewPolyEdge([]gomath.Spatial{&b, gomath.Point{Values: []float64{2.0, 0.5}}, &c}, -1))
+	graph.SetVertexKey("b", &b)
+
+	scaled, mapping := MapVertices(graph, func(values []float64) []float64 {
+		return []float64{values[0] * 10, values[1] * 10}
+	})
+	if scaled.Size() != 3 || len(scaled.GetEdges()) != 2 || !scaled.Validate().IsValid() {
+		t.Errorf("Expected 3 vertices and 2 edges, got %d and %d", scaled.Size(), len(scaled.GetEdges()))
+	}
+	mappedB := mapping.Get(&b)
+	if mappedB.X() != 10.0 || scaled.GetVertexByKey("b") != mappedB {
+		t.Errorf("Expected b at x=10, got %f", mappedB.X())
+	}
+	if edge := mapping.Get(&a).GetEdge(mappedB); ToVertex(edge.To()) != mappedB || edge.Id() != -1 {
+		t.Error("Expected a->b to end in the mapped b")
+	}
+	for _, edge := range mappedB.GetEdges() {
+		polyline, ok := edge.(PolyEdge)
+		if !ok || polyline.Points[1].GetValues()[0] != 20.0 {
+			t.Error("Expected interior points to be transformed")
+		}
+	}
+	if b.X() != 1.0 {
+		t.Error("MapVertices should not change the original")
+	}
+}
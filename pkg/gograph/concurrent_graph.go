@@ -0,0 +1,343 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"maps"
+	"sync"
+)
+
+// ConcurrentGraph guards a SimpleGraph with a read/write lock and hands out copy-on-write
+// snapshots. A snapshot is never mutated after it is handed out, so a routing request that runs
+// on it sees one consistent view for its whole run. The first write after a snapshot copies the
+// graph, so batch writes together with Update.
+//
+// The other read methods run under the read lock on the current version and never copy it. The
+// vertices and edges they return are those of the current version, which later writes change in
+// place, so use a snapshot to work with them while the graph is being written to.
+//
+// Listeners are called under the write lock, so they must not call back into the graph.
+type ConcurrentGraph struct {
+	lock      sync.RWMutex
+	current   *SimpleGraph
+	shared    bool
+	listeners []GraphUpdateListener
+}
+
+func NewConcurrentGraph() *ConcurrentGraph {
+	return newConcurrentGraph(NewSimpleGraph())
+}
+
+func NewDirectedConcurrentGraph() *ConcurrentGraph {
+	return newConcurrentGraph(NewDirectedSimpleGraph())
+}
+
+// NewConcurrentGraphFrom copies graph, which may keep being used independently afterward.
+func NewConcurrentGraphFrom(graph *SimpleGraph) *ConcurrentGraph {
+	return newConcurrentGraph(copySimpleGraph(graph))
+}
+
+// newConcurrentGraph warms the cached hash of graph, so that Hash never writes under the read lock.
+func newConcurrentGraph(graph *SimpleGraph) *ConcurrentGraph {
+	graph.Hash()
+	return &ConcurrentGraph{current: graph}
+}
+
+// Snapshot returns the current version of the graph. It must be treated as read-only.
+func (g *ConcurrentGraph) Snapshot() Graph {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.shared = true
+	return g.current
+}
+
+// Update applies fn to the graph under the write lock, as a single copy-on-write step.
+func (g *ConcurrentGraph) Update(fn func(graph *SimpleGraph)) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	graph := g.writable()
+	graph.listeners = g.listeners
+	// Finish even when fn panics, so that readers never write the cached hashes.
+	defer func() {
+		graph.listeners = nil
+		for _, vertex := range graph.vertices {
+			vertex.Hash()
+		}
+		graph.Hash()
+	}()
+	fn(graph)
+}
+
+// writable must be called with the write lock held. The graph is copied only when a snapshot
+// of it has been handed out.
+func (g *ConcurrentGraph) writable() *SimpleGraph {
+	if g.shared {
+		g.current = copySimpleGraph(g.current)
+		g.shared = false
+	}
+	return g.current
+}
+
+func (g *ConcurrentGraph) write(fn func(graph *SimpleGraph), touched ...gomath.Spatial) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	graph := g.writable()
+	graph.listeners = g.listeners
+	fn(graph)
+	graph.listeners = nil
+	// Warm the cached hashes so that readers of a later snapshot never write to the vertices.
+	for _, spatial := range touched {
+		if vertex := g.current.GetVertex(VertexHashOrId(ToVertex(spatial))); vertex != nil {
+			vertex.Hash()
+		}
+	}
+	graph.Hash()
+}
+
+func (g *ConcurrentGraph) read() (*SimpleGraph, func()) {
+	g.lock.RLock()
+	return g.current, g.lock.RUnlock
+}
+
+func (g *ConcurrentGraph) Id() int64 {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.Id()
+}
+
+func (g *ConcurrentGraph) GetEdge(id int64) Edge {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.GetEdge(id)
+}
+
+func (g *ConcurrentGraph) GetVertex(id int64) Vertex {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.GetVertex(id)
+}
+
+func (g *ConcurrentGraph) AddEdge(e Edge) {
+	g.write(func(graph *SimpleGraph) {
+		graph.AddEdge(e)
+	}, e.From(), e.To())
+}
+
+func (g *ConcurrentGraph) ContainsEdge(e Edge) bool {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.ContainsEdge(e)
+}
+
+func (g *ConcurrentGraph) ContainsVertex(v Vertex) bool {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.ContainsVertex(v)
+}
+
+func (g *ConcurrentGraph) AddVertex(v Vertex) {
+	g.write(func(graph *SimpleGraph) {
+		graph.AddVertex(v)
+	}, v)
+}
+
+func (g *ConcurrentGraph) GetVertices() []Vertex {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.GetVertices()
+}
+
+func (g *ConcurrentGraph) GetEdges() []Edge {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.GetEdges()
+}
+
+func (g *ConcurrentGraph) RemoveEdge(e Edge) {
+	g.write(func(graph *SimpleGraph) {
+		graph.RemoveEdge(e)
+	})
+}
+
+func (g *ConcurrentGraph) RemoveVertex(v Vertex) {
+	g.write(func(graph *SimpleGraph) {
+		graph.RemoveVertex(v)
+	})
+}
+
+func (g *ConcurrentGraph) Size() int {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.Size()
+}
+
+func (g *ConcurrentGraph) Clear() {
+	g.write(func(graph *SimpleGraph) {
+		graph.Clear()
+	})
+}
+
+func (g *ConcurrentGraph) Hash() int64 {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.Hash()
+}
+
+func (g *ConcurrentGraph) IsDirected() bool {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.IsDirected()
+}
+
+func (g *ConcurrentGraph) IsMultiGraph() bool {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.IsMultiGraph()
+}
+
+func (g *ConcurrentGraph) InDegree(v Vertex) int {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.InDegree(v)
+}
+
+func (g *ConcurrentGraph) OutDegree(v Vertex) int {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.OutDegree(v)
+}
+
+func (g *ConcurrentGraph) GetIncomingEdges(v Vertex) []Edge {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.GetIncomingEdges(v)
+}
+
+func (g *ConcurrentGraph) SetEdgeCost(e Edge, key string, value float64) {
+	g.write(func(graph *SimpleGraph) {
+		graph.SetEdgeCost(e, key, value)
+	})
+}
+
+func (g *ConcurrentGraph) RemoveEdgeCost(e Edge, key string) {
+	g.write(func(graph *SimpleGraph) {
+		graph.RemoveEdgeCost(e, key)
+	})
+}
+
+// adjacency returns copies of the outgoing and incoming edges of the vertex with key, without
+// sharing the current version of the graph.
+func (g *ConcurrentGraph) adjacency(key int64) ([]Edge, []Edge, bool) {
+	graph, unlock := g.read()
+	defer unlock()
+	return graph.adjacency(key)
+}
+
+func (g *ConcurrentGraph) AddUpdateListener(listener GraphUpdateListener) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.listeners = append(g.listeners, listener)
+}
+
+func (g *ConcurrentGraph) RemoveUpdateListener(listener GraphUpdateListener) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for i, existing := range g.listeners {
+		if existing == listener {
+			g.listeners = append(g.listeners[:i:i], g.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+// copyVertex copies the coordinates, id and properties of vertex, but none of its edges.
+func copyVertex(vertex Vertex) *SimpleVertex {
+	copied := &SimpleVertex{
+		Spatial: gomath.Point{Values: append([]float64{}, vertex.GetValues()...)},
+		Edges:   make([]Edge, 0, len(vertex.GetEdges())),
+		id:      vertex.Id(),
+		hash:    -1,
+	}
+	if properties := GetProperties(vertex); properties.Len() > 0 {
+		copied.properties = properties.Clone()
+	}
+	copied.Hash()
+	return copied
+}
+
+// copySimpleGraph copies every vertex, edge and cost map of graph so the copy shares no mutable state.
+func copySimpleGraph(graph *SimpleGraph) *SimpleGraph {
+	copied := newSimpleGraph(graph.directed)
+	copied.id = graph.id
+	copied.multi = graph.multi
+	copied.nextEdgeId = graph.nextEdgeId
+	maps.Copy(copied.vertexKeys, graph.vertexKeys)
+	maps.Copy(copied.edgeKeys, graph.edgeKeys)
+	for key, vertex := range graph.vertices {
+		copiedVertex := copyVertex(vertex)
+		copiedVertex.multi = graph.multi
+		copied.vertices[key] = copiedVertex
+	}
+	resolve := func(spatial gomath.Spatial) Vertex {
+		key := VertexHashOrId(ToVertex(spatial))
+		if vertex, ok := copied.vertices[key]; ok {
+			return vertex
+		}
+		return VertexFromSpatial(gomath.Point{Values: append([]float64{}, spatial.GetValues()...)})
+	}
+	cloner := newEdgeCloner()
+	for key, edge := range graph.edges {
+		copiedEdge := cloner.clone(edge, resolve(edge.From()), resolve(edge.To()))
+		copied.edges[key] = copiedEdge
+	}
+	for key, vertex := range graph.vertices {
+		copiedVertex := copied.vertices[key]
+		for _, edge := range vertex.GetEdges() {
+			edgeKey := EdgeHashOrId(edge)
+			copiedEdge, ok := copied.edges[edgeKey]
+			if !ok || VertexHashOrId(ToVertex(copiedEdge.From())) != key {
+				copiedEdge = cloner.clone(edge, copiedVertex, resolve(edge.To()))
+			}
+			copiedVertex.AddEdge(copiedEdge)
+			toKey := VertexHashOrId(ToVertex(copiedEdge.To()))
+			if copied.incoming[toKey] == nil {
+				copied.incoming[toKey] = make(map[int64]Edge)
+			}
+			copied.incoming[toKey][edgeKey] = copiedEdge
+		}
+	}
+	if graph.index != nil {
+		copied.index = NewSpatialIndex(copied)
+	}
+	copied.Hash()
+	return copied
+}
+
+// edgeCloner clones edges like cloneEdge, except that edges sharing a cost map or property bag,
+// like the two directions of an undirected edge, share the copies of them as well.
+type edgeCloner struct {
+	costs      map[*map[string]float64]*map[string]float64
+	properties map[*Properties]*Properties
+}
+
+func newEdgeCloner() *edgeCloner {
+	return &edgeCloner{costs: make(map[*map[string]float64]*map[string]float64), properties: make(map[*Properties]*Properties)}
+}
+
+func (c *edgeCloner) clone(e Edge, from, to Vertex) Edge {
+	cloned := cloneEdge(e, from, to)
+	if cost := e.Cost(); cost != nil {
+		if shared, ok := c.costs[cost]; ok {
+			cloned = withCost(cloned, shared)
+		} else {
+			c.costs[cost] = cloned.Cost()
+		}
+	}
+	if properties := GetProperties(e); properties != nil {
+		if shared, ok := c.properties[properties]; ok {
+			cloned = withProperties(cloned, shared)
+		} else {
+			c.properties[properties] = GetProperties(cloned)
+		}
+	}
+	return cloned
+}
@@ -0,0 +1,116 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentGraph_Snapshot(t *testing.T) {
+	graph := NewConcurrentGraph()
+	a := VertexFromSpatial(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := VertexFromSpatial(gomath.Point{Values: []float64{1.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(a, b, -1))
+	snapshot := graph.Snapshot()
+	graph.RemoveVertex(b)
+	if len(snapshot.GetEdges()) != 2 || snapshot.Size() != 2 {
+		t.Errorf("Snapshot changed after a write, got %d edges", len(snapshot.GetEdges()))
+	}
+	if len(snapshot.GetVertex(VertexHashOrId(a)).GetEdges()) != 1 {
+		t.Error("Snapshot vertex adjacency changed after a write")
+	}
+	if graph.Size() != 1 || len(graph.GetEdges()) != 0 {
+		t.Errorf("Expected 1 vertex and no edges, got %d and %d", graph.Size(), len(graph.GetEdges()))
+	}
+}
+
+func TestConcurrentGraph_ReadsDoNotCopy(t *testing.T) {
+	graph := NewConcurrentGraph()
+	a := VertexFromSpatial(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := VertexFromSpatial(gomath.Point{Values: []float64{1.0, 0.0}})
+	c := VertexFromSpatial(gomath.Point{Values: []float64{2.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(a, b, -1))
+	current := graph.current
+	hash := graph.Hash()
+	if graph.GetVertex(VertexHashOrId(a)) == nil || len(graph.GetVertices()) != 2 || len(graph.GetEdges()) != 2 || len(graph.GetIncomingEdges(b)) != 1 {
+		t.Fatal("Expected to read the current version")
+	}
+	graph.AddEdge(NewSimpleEdge(b, c, -1))
+	if graph.current != current || graph.Hash() == hash {
+		t.Error("Expected a write after reads to change the graph in place")
+	}
+	graph.Snapshot()
+	graph.RemoveVertex(c)
+	if graph.current == current {
+		t.Error("Expected a write after a snapshot to copy the graph")
+	}
+}
+
+func TestConcurrentGraph_CostAfterSnapshot(t *testing.T) {
+	graph := NewConcurrentGraph()
+	a := VertexFromSpatial(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := VertexFromSpatial(gomath.Point{Values: []float64{1.0, 0.0}})
+	edge := NewSimpleEdge(a, b, -1, &map[string]float64{COST_TYPE_TIME: 1.0})
+	graph.AddEdge(edge)
+	snapshot := graph.Snapshot()
+	graph.SetEdgeCost(edge, COST_TYPE_TIME, 5.0)
+
+	from, to := graph.GetVertex(VertexHashOrId(a)), graph.GetVertex(VertexHashOrId(b))
+	if (*GetEdge(from, to).Cost())[COST_TYPE_TIME] != 5.0 || (*GetEdge(to, from).Cost())[COST_TYPE_TIME] != 5.0 {
+		t.Error("Expected both directions of the undirected edge to see the new cost")
+	}
+	GetProperties(GetEdge(from, to)).SetString("name", "Main Street")
+	if name, _ := GetProperties(GetEdge(to, from)).GetString("name"); name != "Main Street" {
+		t.Error("Expected both directions of the undirected edge to share their properties")
+	}
+	if (*GetEdge(snapshot.GetVertex(VertexHashOrId(b)), a).Cost())[COST_TYPE_TIME] != 1.0 {
+		t.Error("Expected the snapshot to keep the old cost")
+	}
+}
+
+func TestConcurrentGraph_MixedWorkload(t *testing.T) {
+	size := 8
+	grid := BoundedGridGraphProvider{
+		BoundingBox: gomath.BoundingBox{MinX: 0, MinY: 0, MaxX: float64(size), MaxY: float64(size)},
+		Width:       size,
+		Height:      size,
+	}.Build()
+	graph := NewConcurrentGraphFrom(grid.(*SimpleGraph))
+	startKey := HashVertex(VertexFromSpatial(gomath.Point{Values: []float64{0.0, 0.0}}))
+	destinationKey := HashVertex(VertexFromSpatial(gomath.Point{Values: []float64{float64(size - 1), float64(size - 1)}}))
+	detour := NewEdge(gomath.Point{Values: []float64{0.0, 0.0}}, gomath.Point{Values: []float64{float64(size - 1), float64(size - 1)}})
+
+	var group sync.WaitGroup
+	for writer := 0; writer < 2; writer++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for i := 0; i < 50; i++ {
+				graph.AddEdge(detour)
+				graph.RemoveEdge(detour)
+			}
+		}()
+	}
+	for reader := 0; reader < 4; reader++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for i := 0; i < 10; i++ {
+				snapshot := graph.Snapshot()
+				response := AStar(RoutingAlgorithmRequest{
+					Start:       snapshot.GetVertex(startKey),
+					Destination: snapshot.GetVertex(destinationKey),
+				})
+				for _, edge := range response.Path.GetEdges() {
+					if !snapshot.ContainsEdge(edge) {
+						t.Error("Routed over an edge that is not part of the snapshot")
+					}
+				}
+			}
+		}()
+	}
+	group.Wait()
+	if !ValidateGraph(graph.Snapshot()).IsValid() {
+		t.Error("Expected a valid graph after the workload")
+	}
+}
@@ -85,10 +85,16 @@ func GenerateCostDifference(one, two map[string]CostEntry) map[string]CostEntry
 }
 
 func GetCostOrEvaluate(currWrapper *VertexWrapper, toVertex Vertex, key string, costFunctions map[string]CostFunction) float64 {
-	edge := GetEdge(currWrapper.Inner, toVertex)
+	edge := TraversedEdge(currWrapper.Inner, toVertex)
 	if edge == nil {
 		return costFunctions[key].Eval(currWrapper, toVertex)
 	}
+	if frozenEdge, ok := edge.(FrozenEdge); ok {
+		if weight, ok := frozenEdge.Weight(key); ok {
+			return weight
+		}
+		return costFunctions[key].Eval(currWrapper, toVertex)
+	}
 	costMap := edge.Cost()
 	if costMap != nil {
 		cost, ok := (*costMap)[key]
@@ -244,7 +250,7 @@ type InitialCostFunction struct {
 }
 
 func (f InitialCostFunction) Eval(vertexWrapper *VertexWrapper, to gomath.Spatial) float64 {
-	edgeCost := GetEdge(vertexWrapper.Inner, ToVertex(to)).Cost()
+	edgeCost := TraversedEdge(vertexWrapper.Inner, to).Cost()
 	if edgeCost == nil {
 		return f.Default
 	}
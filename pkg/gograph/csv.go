@@ -0,0 +1,564 @@
+package gograph
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Column names with a meaning to the CSV readers and writers. The coordinates of a node are in
+// columns x, y and z, or lon and lat for x and y.
+const (
+	CSV_COLUMN_NODE      = "node"
+	CSV_COLUMN_FROM      = "from"
+	CSV_COLUMN_TO        = "to"
+	CSV_COLUMN_EDGE_ID   = "edge_id"
+	CSV_COLUMN_VERTEX_ID = "vertex_id"
+)
+
+var csvCoordinateColumns = map[string]int{"x": 0, "y": 1, "z": 2, "lon": 0, "lat": 1}
+
+// CSVOptions configures the CSV readers and writers. Directed makes the readers build a directed
+// graph, CostType is the cost an adjacency matrix holds and defaults to COST_TYPE_DISTANCE, and
+// Comma separates fields and defaults to ','.
+type CSVOptions struct {
+	Directed bool
+	CostType string
+	Comma    rune
+}
+
+func csvOptions(options []CSVOptions) CSVOptions {
+	resolved := CSVOptions{}
+	if len(options) > 0 {
+		resolved = options[0]
+	}
+	if resolved.CostType == "" {
+		resolved.CostType = COST_TYPE_DISTANCE
+	}
+	if resolved.Comma == 0 {
+		resolved.Comma = ','
+	}
+	return resolved
+}
+
+// csvReader reads the records of a CSV file, which must all have as many fields as its header,
+// and reports errors with the line of the record being read.
+type csvReader struct {
+	reader *csv.Reader
+	line   int
+}
+
+func newCSVReader(reader io.Reader, comma rune) *csvReader {
+	records := csv.NewReader(reader)
+	records.Comma = comma
+	records.TrimLeadingSpace = true
+	return &csvReader{reader: records}
+}
+
+// next returns the next record, or nil at the end of the file.
+func (r *csvReader) next() ([]string, error) {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.line, _ = r.reader.FieldPos(0)
+	for i, field := range record {
+		record[i] = strings.TrimSpace(field)
+	}
+	return record, nil
+}
+
+func (r *csvReader) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", r.line, fmt.Sprintf(format, args...))
+}
+
+// header reads the header, which must name every column once.
+func (r *csvReader) header() ([]string, error) {
+	header, err := r.next()
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errors.New("CSV file without a header")
+	}
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		if i > 0 && (column == "" || seen[column]) {
+			return nil, r.errorf("empty or repeated column %q", column)
+		}
+		seen[column] = true
+	}
+	return header, nil
+}
+
+// parseNumber reads a finite number, or reports it missing when field is empty.
+func (r *csvReader) parseNumber(field string, column string) (float64, bool, error) {
+	if field == "" {
+		return 0, false, nil
+	}
+	number, err := strconv.ParseFloat(field, 64)
+	if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+		return 0, false, r.errorf("invalid %s %q", column, field)
+	}
+	return number, true, nil
+}
+
+// csvNode is a row of a node CSV, along with its line for errors found after the file is read.
+type csvNode struct {
+	name   string
+	line   int
+	record VertexRecord
+}
+
+// readCSVNodes reads a node CSV, whose first column names each node, into the vertex record of
+// each row. The coordinate columns give the values of the record and vertex_id its id, and any
+// other column becomes a string property.
+func readCSVNodes(reader io.Reader, comma rune) ([]csvNode, error) {
+	nodes := newCSVReader(reader, comma)
+	header, err := nodes.header()
+	if err != nil {
+		return nil, fmt.Errorf("nodes: %w", err)
+	}
+	dimensions := 0
+	for _, column := range header[1:] {
+		if index, ok := csvCoordinateColumns[strings.ToLower(column)]; ok {
+			dimensions = max(dimensions, index+1)
+		}
+	}
+	rows := make([]csvNode, 0)
+	names := make(map[string]bool)
+	for {
+		fields, err := nodes.next()
+		if err != nil {
+			return nil, fmt.Errorf("nodes: %w", err)
+		}
+		if fields == nil {
+			return rows, nil
+		}
+		name := fields[0]
+		if name == "" || names[name] {
+			return nil, fmt.Errorf("nodes: %w", nodes.errorf("empty or repeated node %q", name))
+		}
+		names[name] = true
+		record := VertexRecord{Id: -1, Values: make([]float64, dimensions), Properties: NewProperties()}
+		found := make([]bool, dimensions)
+		for i, column := range header[1:] {
+			field := fields[i+1]
+			if index, ok := csvCoordinateColumns[strings.ToLower(column)]; ok {
+				if record.Values[index], found[index], err = nodes.parseNumber(field, column); err != nil {
+					return nil, fmt.Errorf("nodes: %w", err)
+				}
+			} else if column == CSV_COLUMN_VERTEX_ID {
+				if field != "" {
+					if record.Id, err = strconv.ParseInt(field, 10, 64); err != nil {
+						return nil, fmt.Errorf("nodes: %w", nodes.errorf("invalid %s %q", column, field))
+					}
+				}
+			} else if field != "" {
+				record.Properties.SetString(column, field)
+			}
+		}
+		if slices.Contains(found, false) {
+			return nil, fmt.Errorf("nodes: %w", nodes.errorf("node %s is missing a coordinate", name))
+		}
+		if record.Properties.Len() == 0 {
+			record.Properties = nil
+		}
+		rows = append(rows, csvNode{name, nodes.line, record})
+	}
+}
+
+// csvGraph starts a graph with the vertices of an optional node CSV. The returned function finds
+// the vertex of a node name, adding a vertex without coordinates for an unknown name unless the
+// node CSV was given, in which case the name is an error.
+func csvGraph(nodes io.Reader, resolved CSVOptions) (*SimpleGraph, func(name string) (*SimpleVertex, error), error) {
+	graph := newSimpleGraph(resolved.Directed)
+	vertices := make(map[string]*SimpleVertex)
+	if nodes != nil {
+		rows, err := readCSVNodes(nodes, resolved.Comma)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, row := range rows {
+			if vertices[row.name], err = addKeyedVertex(graph, row.name, row.record); err != nil {
+				return nil, nil, fmt.Errorf("nodes: line %d: %w", row.line, err)
+			}
+		}
+	}
+	vertexNamed := func(name string) (*SimpleVertex, error) {
+		if vertex, ok := vertices[name]; ok {
+			return vertex, nil
+		}
+		if nodes != nil || name == "" {
+			return nil, fmt.Errorf("unknown node %q", name)
+		}
+		vertex, err := addKeyedVertex(graph, name, VertexRecord{Id: -1})
+		if err != nil {
+			return nil, err
+		}
+		vertices[name] = vertex
+		return vertex, nil
+	}
+	return graph, vertexNamed, nil
+}
+
+// ReadEdgeListCSV reads a CSV edge list such as
+//
+//	from,to,distance,time
+//	a,b,120,9.5
+//
+// whose first two columns name the endpoints of each edge. An edge_id column gives edges unique
+// ids, and every other column is a cost, left out of the cost map of an edge whose field is
+// empty. Nodes are vertex keys. When nodes is not nil, it is read as a node CSV such as
+//
+//	node,x,y,vertex_id,label
+//	a,0,0,1,depot
+//
+// which must list every node, and whose first column names the nodes, the x, y and z, or lon and
+// lat, columns give their coordinates and vertex_id their ids, and other columns become string
+// properties. Without it, vertices have no coordinates and get ids from IdFromKey. Rows between
+// the same nodes make the result a multigraph. Errors report the line of the offending row.
+func ReadEdgeListCSV(edges io.Reader, nodes io.Reader, options ...CSVOptions) (*SimpleGraph, error) {
+	resolved := csvOptions(options)
+	graph, vertexNamed, err := csvGraph(nodes, resolved)
+	if err != nil {
+		return nil, err
+	}
+	reader := newCSVReader(edges, resolved.Comma)
+	header, err := reader.header()
+	if err != nil {
+		return nil, err
+	}
+	if len(header) < 2 {
+		return nil, reader.errorf("expected from and to columns")
+	}
+	type csvEdge struct {
+		from, to *SimpleVertex
+		record   EdgeRecord
+	}
+	rows := make([]csvEdge, 0)
+	pairs := make(map[[2]int64]bool)
+	ids := make(map[int64]bool)
+	for {
+		fields, err := reader.next()
+		if err != nil {
+			return nil, err
+		}
+		if fields == nil {
+			break
+		}
+		from, err := vertexNamed(fields[0])
+		if err != nil {
+			return nil, reader.errorf("%s", err)
+		}
+		to, err := vertexNamed(fields[1])
+		if err != nil {
+			return nil, reader.errorf("%s", err)
+		}
+		record := EdgeRecord{Id: -1, Cost: make(map[string]float64)}
+		for i, column := range header[2:] {
+			field := fields[i+2]
+			if column == CSV_COLUMN_EDGE_ID {
+				if field != "" {
+					if record.Id, err = strconv.ParseInt(field, 10, 64); err != nil {
+						return nil, reader.errorf("invalid %s %q", column, field)
+					}
+					if ids[record.Id] {
+						return nil, reader.errorf("repeated %s %d", column, record.Id)
+					}
+					ids[record.Id] = true
+				}
+				continue
+			}
+			cost, ok, err := reader.parseNumber(field, column)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				record.Cost[column] = cost
+			}
+		}
+		if len(record.Cost) == 0 {
+			record.Cost = nil
+		}
+		pair := [2]int64{VertexHashOrId(from), VertexHashOrId(to)}
+		if !resolved.Directed && pair[1] < pair[0] {
+			pair = [2]int64{pair[1], pair[0]}
+		}
+		graph.multi = graph.multi || pairs[pair]
+		pairs[pair] = true
+		rows = append(rows, csvEdge{from, to, record})
+	}
+	records := make([]*EdgeRecord, len(rows))
+	for i := range rows {
+		records[i] = &rows[i].record
+	}
+	assignEdgeIds(graph, records)
+	for _, row := range rows {
+		graph.AddEdge(row.record.Edge(row.from, row.to))
+	}
+	return graph, nil
+}
+
+// WriteEdgeListCSV writes graph as a CSV edge list with a column for every cost type of its edges,
+// and an edge_id column when any edge has an id, listing an undirected edge once. When nodes is
+// not nil, the vertices are written to it as a node CSV with their coordinates, ids and
+// properties. Nodes are named by their vertex keys, or n<key> for vertices without one. The
+// polylines of PolyEdges are not kept.
+func WriteEdgeListCSV(edges io.Writer, nodes io.Writer, graph Graph, options ...CSVOptions) error {
+	resolved := csvOptions(options)
+	record := NewGraphRecord(graph)
+	names := vertexNames(record)
+
+	costTypes := make(map[string]bool)
+	withIds := false
+	for _, edge := range record.Edges {
+		for costType := range edge.Cost {
+			costTypes[costType] = true
+		}
+		withIds = withIds || edge.Id != -1
+	}
+	columns := slices.Sorted(maps.Keys(costTypes))
+	header := []string{CSV_COLUMN_FROM, CSV_COLUMN_TO}
+	if withIds {
+		header = append(header, CSV_COLUMN_EDGE_ID)
+	}
+	output := csv.NewWriter(edges)
+	output.Comma = resolved.Comma
+	_ = output.Write(append(header, columns...))
+	for _, edge := range record.Edges {
+		row := []string{names[edge.From], names[edge.To]}
+		if withIds && edge.Id != -1 {
+			row = append(row, strconv.FormatInt(edge.Id, 10))
+		} else if withIds {
+			row = append(row, "")
+		}
+		for _, column := range columns {
+			if cost, ok := edge.Cost[column]; ok {
+				row = append(row, formatFloat(cost))
+			} else {
+				row = append(row, "")
+			}
+		}
+		_ = output.Write(row)
+	}
+	output.Flush()
+	if err := output.Error(); err != nil || nodes == nil {
+		return err
+	}
+	return writeCSVNodes(nodes, record, names, resolved.Comma)
+}
+
+func writeCSVNodes(writer io.Writer, record GraphRecord, names map[int64]string, comma rune) error {
+	dimensions := 0
+	withIds := false
+	properties := make(map[string]bool)
+	for _, vertex := range record.Vertices {
+		dimensions = max(dimensions, len(vertex.Values))
+		withIds = withIds || vertex.Id > 0
+		for _, key := range vertex.Properties.Keys() {
+			properties[key] = true
+		}
+	}
+	if dimensions > 3 {
+		return fmt.Errorf("node CSV holds at most 3 coordinates, got %d", dimensions)
+	}
+	header := append([]string{CSV_COLUMN_NODE}, []string{"x", "y", "z"}[:dimensions]...)
+	if withIds {
+		header = append(header, CSV_COLUMN_VERTEX_ID)
+	}
+	columns := slices.Sorted(maps.Keys(properties))
+	output := csv.NewWriter(writer)
+	output.Comma = comma
+	_ = output.Write(append(header, columns...))
+	for _, vertex := range record.Vertices {
+		row := []string{names[vertex.Key]}
+		for i := 0; i < dimensions; i++ {
+			if i < len(vertex.Values) {
+				row = append(row, formatFloat(vertex.Values[i]))
+			} else {
+				row = append(row, "0")
+			}
+		}
+		if withIds && vertex.Id > 0 {
+			row = append(row, strconv.FormatInt(vertex.Id, 10))
+		} else if withIds {
+			row = append(row, "")
+		}
+		for _, column := range columns {
+			value, ok := vertex.Properties.Get(column)
+			formatted := fmt.Sprint(value)
+			if floatValue, isFloat := value.(float64); isFloat {
+				formatted = formatFloat(floatValue)
+			} else if !ok {
+				formatted = ""
+			}
+			row = append(row, formatted)
+		}
+		_ = output.Write(row)
+	}
+	output.Flush()
+	return output.Error()
+}
+
+// ReadAdjacencyMatrixCSV reads a dense adjacency matrix such as
+//
+//	,a,b,c
+//	a,0,4,
+//	b,4,0,2
+//	c,,2,0
+//
+// whose header and first column name the nodes in the same order. A number in row i and column j
+// is the CostType cost of an edge from node i to node j, and an empty field means no edge. The
+// diagonal is ignored. The matrix of an undirected graph must be symmetric. The optional node CSV
+// is the one read by ReadEdgeListCSV. The edges of a dense matrix are added without the duplicate
+// search of AddEdge, so reading takes time proportional to the size of the matrix.
+func ReadAdjacencyMatrixCSV(matrix io.Reader, nodes io.Reader, options ...CSVOptions) (*SimpleGraph, error) {
+	resolved := csvOptions(options)
+	graph, vertexNamed, err := csvGraph(nodes, resolved)
+	if err != nil {
+		return nil, err
+	}
+	reader := newCSVReader(matrix, resolved.Comma)
+	header, err := reader.header()
+	if err != nil {
+		return nil, err
+	}
+	names := header[1:]
+	vertices := make([]*SimpleVertex, len(names))
+	for i, name := range names {
+		if vertices[i], err = vertexNamed(name); err != nil {
+			return nil, reader.errorf("%s", err)
+		}
+	}
+	weights := make([][]float64, len(names))
+	present := make([][]bool, len(names))
+	lines := make([]int, len(names))
+	for i := 0; ; i++ {
+		fields, err := reader.next()
+		if err != nil {
+			return nil, err
+		}
+		if fields == nil {
+			if i < len(names) {
+				return nil, reader.errorf("expected %d rows, found %d", len(names), i)
+			}
+			break
+		}
+		if i >= len(names) {
+			return nil, reader.errorf("more rows than columns")
+		}
+		if fields[0] != names[i] {
+			return nil, reader.errorf("row %q where %q was expected", fields[0], names[i])
+		}
+		lines[i] = reader.line
+		weights[i], present[i] = make([]float64, len(names)), make([]bool, len(names))
+		for j, field := range fields[1:] {
+			if weights[i][j], present[i][j], err = reader.parseNumber(field, names[j]); err != nil {
+				return nil, err
+			}
+			if !resolved.Directed && j < i && (present[i][j] != present[j][i] || weights[i][j] != weights[j][i]) {
+				return nil, reader.errorf("%s to %s differs from line %d in an undirected matrix", names[i], names[j], lines[j])
+			}
+		}
+	}
+	for i, from := range vertices {
+		for j, to := range vertices {
+			if i != j && present[i][j] && (resolved.Directed || i < j) {
+				graph.appendEdge(NewSimpleEdge(from, to, -1, &map[string]float64{resolved.CostType: weights[i][j]}))
+			}
+		}
+	}
+	return graph, nil
+}
+
+// WriteAdjacencyMatrixCSV writes graph as the dense adjacency matrix read by
+// ReadAdjacencyMatrixCSV. A field holds the CostType cost of the edge between its nodes, or its
+// distance when it has none, and the least of them between parallel edges. Nodes are named like
+// WriteEdgeListCSV names them.
+func WriteAdjacencyMatrixCSV(writer io.Writer, graph Graph, options ...CSVOptions) error {
+	resolved := csvOptions(options)
+	record := NewGraphRecord(graph)
+	names := vertexNames(record)
+	indexes := make(map[int64]int, len(record.Vertices))
+	for i, vertex := range record.Vertices {
+		indexes[vertex.Key] = i
+	}
+	weights := make([][]float64, len(record.Vertices))
+	for i := range weights {
+		weights[i] = make([]float64, len(record.Vertices))
+		for j := range weights[i] {
+			weights[i][j] = math.NaN()
+		}
+	}
+	for _, vertex := range graph.GetVertices() {
+		i := indexes[VertexHashOrId(vertex)]
+		for _, edge := range vertex.GetEdges() {
+			j := indexes[VertexHashOrId(ToVertex(edge.To()))]
+			weight := edge.Distance()
+			if cost := edge.Cost(); cost != nil {
+				if value, ok := (*cost)[resolved.CostType]; ok {
+					weight = value
+				}
+			}
+			if i != j && !(weight >= weights[i][j]) {
+				weights[i][j] = weight
+			}
+		}
+	}
+	return writeCSVMatrix(writer, record, names, resolved.Comma, func(i, j int) string {
+		if math.IsNaN(weights[i][j]) {
+			return ""
+		}
+		return formatFloat(weights[i][j])
+	})
+}
+
+// WriteTSPMatrixCSV writes the distance matrix of a TSPRequest in the form read by
+// ReadAdjacencyMatrixCSV, measuring every pair of vertices of its graph with its distance
+// function, or Euclidean distance, whether or not an edge joins them.
+func WriteTSPMatrixCSV(writer io.Writer, request TSPRequest, options ...CSVOptions) error {
+	resolved := csvOptions(options)
+	distanceFunction := gomath.EuclideanDistance
+	if request.DistanceFunction != nil {
+		distanceFunction = *request.DistanceFunction
+	}
+	record := NewGraphRecord(request.Graph)
+	vertices := request.Graph.GetVertices()
+	return writeCSVMatrix(writer, record, vertexNames(record), resolved.Comma, func(i, j int) string {
+		if i == j {
+			return "0"
+		}
+		return formatFloat(distanceFunction(vertices[i], vertices[j]))
+	})
+}
+
+func writeCSVMatrix(writer io.Writer, record GraphRecord, names map[int64]string, comma rune, field func(i, j int) string) error {
+	output := csv.NewWriter(writer)
+	output.Comma = comma
+	header := make([]string, len(record.Vertices)+1)
+	for i, vertex := range record.Vertices {
+		header[i+1] = names[vertex.Key]
+	}
+	_ = output.Write(header)
+	row := make([]string, len(header))
+	for i := range record.Vertices {
+		row[0] = header[i+1]
+		for j := range record.Vertices {
+			row[j+1] = field(i, j)
+		}
+		_ = output.Write(row)
+	}
+	output.Flush()
+	return output.Error()
+}
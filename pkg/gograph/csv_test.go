@@ -0,0 +1,199 @@
+package gograph
+
+import (
+	"bytes"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"io"
+	"strings"
+	"testing"
+)
+
+const csvEdges = `from,to,distance,time,edge_id
+depot,a,120,9.5,
+a,b,80,,7
+b,depot, 200 ,14,
+`
+
+const csvNodes = `node,lon,lat,vertex_id,label
+depot,-0.1276,51.5072,1,Main depot
+a,-0.12,51.51,,
+b,-0.11,51.505,,
+`
+
+func TestReadEdgeListCSV(t *testing.T) {
+	graph, err := ReadEdgeListCSV(strings.NewReader(csvEdges), strings.NewReader(csvNodes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graph.IsDirected() || graph.IsMultiGraph() || graph.Size() != 3 || !graph.Validate().IsValid() {
+		t.Fatal("Expected an undirected graph of 3 nodes")
+	}
+	depot, a, b := graph.GetVertexByKey("depot"), graph.GetVertexByKey("a"), graph.GetVertexByKey("b")
+	if depot.Id() != 1 || depot != graph.GetVertex(1) || a.X() != -0.12 || a.Y() != 51.51 {
+		t.Error("Expected the node CSV to give ids and [lon, lat] coordinates")
+	}
+	if label, _ := GetProperties(depot).GetString("label"); label != "Main depot" || GetProperties(a).Len() != 0 {
+		t.Error("Expected other node columns to become properties")
+	}
+	if cost := *GetEdge(depot, a).Cost(); cost[COST_TYPE_DISTANCE] != 120 || cost[COST_TYPE_TIME] != 9.5 {
+		t.Errorf("Expected the extra columns as costs, got %v", cost)
+	}
+	if cost := *GetEdge(b, a).Cost(); len(cost) != 1 || GetEdge(a, b).Id() != 7 {
+		t.Error("Expected empty fields to be left out and edge_id to give the id")
+	}
+	if (*GetEdge(depot, b).Cost())[COST_TYPE_DISTANCE] != 200 {
+		t.Error("Expected fields to be trimmed")
+	}
+
+	parallel, err := ReadEdgeListCSV(strings.NewReader("from;to;distance\n1;2;5\n2;1;3\n2;3;1\n"), nil, CSVOptions{Comma: ';'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	one, two := parallel.GetVertexByKey("1"), parallel.GetVertexByKey("2")
+	if !parallel.IsMultiGraph() || len(GetEdges(one, two)) != 2 || one.Id() != IdFromKey("1") {
+		t.Error("Expected parallel rows to make a multigraph of nodes without coordinates")
+	}
+	directed, err := ReadEdgeListCSV(strings.NewReader("from,to,distance\n1,2,5\n2,1,3\n"), nil, CSVOptions{Directed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !directed.IsDirected() || directed.IsMultiGraph() || len(directed.GetEdges()) != 2 {
+		t.Error("Expected opposite rows to be two directed edges")
+	}
+	ids, err := ReadEdgeListCSV(strings.NewReader("from,to,edge_id,distance\na,b,,5\na,b,1,7\n"), nil, CSVOptions{Directed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids.GetEdges()) != 2 || (*ids.GetEdge(1).Cost())[COST_TYPE_DISTANCE] != 7.0 {
+		t.Errorf("Expected a generated id not to take the explicit id 1, got %d edges", len(ids.GetEdges()))
+	}
+}
+
+func TestReadEdgeListCSV_Errors(t *testing.T) {
+	for name, test := range map[string]struct {
+		edges, nodes, line string
+	}{
+		"cost":          {"from,to,distance\na,b,1\nb,c,far\n", "", "line 3:"},
+		"edge id":       {"from,to,edge_id\na,b,x\n", "", "line 2:"},
+		"field count":   {"from,to,distance\na,b,1\na,c\n", "", "line 3"},
+		"unknown node":  {"from,to\na,b\na,c\n", "node,x,y\na,0,0\nb,1,1\n", "line 3:"},
+		"coordinate":    {"from,to\na,b\n", "node,x,y\na,0,0\nb,1,\n", "nodes: line 3:"},
+		"repeated node": {"from,to\na,b\n", "node,x,y\na,0,0\na,1,1\n", "nodes: line 3:"},
+		"header":        {"from,to,time,time\n", "", "line 1:"},
+		"repeated id":   {"from,to,edge_id\na,b,1\nb,c,2\nc,a,1\n", "", "line 4:"},
+		"vertex id":     {"from,to\na,b\n", "node,x,y,vertex_id\na,0,0,5\nb,1,1,5\n", "nodes: line 3:"},
+	} {
+		var nodes io.Reader
+		if test.nodes != "" {
+			nodes = strings.NewReader(test.nodes)
+		}
+		if _, err := ReadEdgeListCSV(strings.NewReader(test.edges), nodes); err == nil || !strings.Contains(err.Error(), test.line) {
+			t.Errorf("%s: expected an error on %q, got %v", name, test.line, err)
+		}
+	}
+}
+
+func TestWriteEdgeListCSV(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertexWithId(gomath.Point{Values: []float64{0, 0}}, 1)
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1, 2}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{3, 1}})
+	a.Properties().SetString("label", "start")
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_DISTANCE: 2.5, COST_TYPE_TIME: 1}))
+	graph.AddEdge(NewSimpleEdge(&b, &c, -1, &map[string]float64{COST_TYPE_DISTANCE: 4}))
+	graph.AddEdge(NewSimpleEdge(&c, &a, -1))
+	graph.SetVertexKey("start", &a)
+
+	var edges, nodes bytes.Buffer
+	if err := WriteEdgeListCSV(&edges, &nodes, graph); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(edges.String(), "from,to,distance,time\n") || strings.Count(edges.String(), "\n") != 4 {
+		t.Errorf("Expected each undirected edge once, got %q", edges.String())
+	}
+	if !strings.HasPrefix(nodes.String(), "node,x,y,vertex_id,label\n") || !strings.Contains(nodes.String(), "start,0,0,1,start\n") {
+		t.Errorf("Unexpected node CSV %q", nodes.String())
+	}
+	decoded, err := ReadEdgeListCSV(&edges, &nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := DiffGraphs(graph, decoded); !diff.IsEmpty() {
+		t.Errorf("Expected no difference, got %+v", diff)
+	}
+	equalAdjacency(t, graph, decoded)
+}
+
+const csvMatrix = `,a,b,c
+a,0,4,
+b,4,0,2
+c,,2,0
+`
+
+func TestReadAdjacencyMatrixCSV(t *testing.T) {
+	graph, err := ReadAdjacencyMatrixCSV(strings.NewReader(csvMatrix), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b, c := graph.GetVertexByKey("a"), graph.GetVertexByKey("b"), graph.GetVertexByKey("c")
+	if graph.Size() != 3 || len(graph.GetEdges()) != 4 || !graph.Validate().IsValid() {
+		t.Fatalf("Expected 2 undirected edges, got %d", len(graph.GetEdges()))
+	}
+	if (*GetEdge(c, b).Cost())[COST_TYPE_DISTANCE] != 2 || len(GetEdges(a, c)) != 0 || len(GetEdges(a, a)) != 0 {
+		t.Error("Expected edges for the numbers off the diagonal only")
+	}
+
+	_, err = ReadAdjacencyMatrixCSV(strings.NewReader(",a,b\na,0,4\nb,5,0\n"), nil)
+	if err == nil || !strings.Contains(err.Error(), "line 3:") {
+		t.Errorf("Expected an asymmetry error on line 3, got %v", err)
+	}
+	directed, err := ReadAdjacencyMatrixCSV(strings.NewReader(",a,b\na,0,4\nb,5,0\n"), strings.NewReader("node,x,y\na,0,0\nb,3,4\n"), CSVOptions{Directed: true, CostType: COST_TYPE_TIME})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b = directed.GetVertexByKey("a"), directed.GetVertexByKey("b")
+	if (*GetEdge(b, a).Cost())[COST_TYPE_TIME] != 5 || b.X() != 3 {
+		t.Error("Expected a directed matrix of times at the node coordinates")
+	}
+	for name, matrix := range map[string]string{
+		"row name":  ",a,b\nb,0,1\na,1,0\n",
+		"row count": ",a,b\na,0,1\n",
+		"weight":    ",a,b\na,0,x\nb,1,0\n",
+	} {
+		if _, err := ReadAdjacencyMatrixCSV(strings.NewReader(matrix), nil); err == nil || !strings.Contains(err.Error(), "line ") {
+			t.Errorf("%s: expected an error with its line, got %v", name, err)
+		}
+	}
+}
+
+func TestWriteAdjacencyMatrixCSV(t *testing.T) {
+	graph, err := ReadAdjacencyMatrixCSV(strings.NewReader(csvMatrix), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	if err := WriteAdjacencyMatrixCSV(&buffer, graph); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadAdjacencyMatrixCSV(&buffer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := DiffGraphs(graph, decoded); !diff.IsEmpty() {
+		t.Errorf("Expected no difference, got %+v", diff)
+	}
+
+	instance, err := ReadTSPLIB(strings.NewReader(tsplibSquare))
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance.Graph.RemoveEdge(GetEdge(instance.Graph.GetVertex(1), instance.Graph.GetVertex(3)))
+	buffer.Reset()
+	if err := WriteTSPMatrixCSV(&buffer, instance.Request()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 5 || lines[1] != "n1,0,3,5,4" {
+		t.Errorf("Expected the full distance matrix, got %q", buffer.String())
+	}
+}
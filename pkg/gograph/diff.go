@@ -0,0 +1,298 @@
+package gograph
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// VertexModification is a vertex present in both graphs of a diff whose coordinates or properties
+// differ. Only a vertex with an id can move, since other vertices are identified by coordinates.
+type VertexModification struct {
+	Before     Vertex
+	After      Vertex
+	Values     bool
+	Properties bool
+}
+
+// EdgeModification is an edge present in both graphs of a diff that changed. Endpoints and Points
+// can only change for an edge with an id, since the hash of other edges covers them.
+type EdgeModification struct {
+	Before     Edge
+	After      Edge
+	Endpoints  bool
+	Points     bool
+	Cost       bool
+	Properties bool
+}
+
+// GraphDiff lists the differences between two graphs, with vertices keyed by VertexHashOrId and
+// edges by EdgeHashOrId. Each list is ordered by key. An undirected edge is listed once.
+type GraphDiff struct {
+	AddedVertices    []Vertex
+	RemovedVertices  []Vertex
+	ModifiedVertices []VertexModification
+	AddedEdges       []Edge
+	RemovedEdges     []Edge
+	ModifiedEdges    []EdgeModification
+}
+
+// DiffGraphs returns the changes that turn before into after.
+func DiffGraphs(before Graph, after Graph) GraphDiff {
+	diff := GraphDiff{
+		AddedVertices:    make([]Vertex, 0),
+		RemovedVertices:  make([]Vertex, 0),
+		ModifiedVertices: make([]VertexModification, 0),
+		AddedEdges:       make([]Edge, 0),
+		RemovedEdges:     make([]Edge, 0),
+		ModifiedEdges:    make([]EdgeModification, 0),
+	}
+	beforeVertices, afterVertices := verticesByKey(before), verticesByKey(after)
+	for _, key := range slices.Sorted(maps.Keys(beforeVertices)) {
+		vertex := beforeVertices[key]
+		other, ok := afterVertices[key]
+		if !ok {
+			diff.RemovedVertices = append(diff.RemovedVertices, vertex)
+			continue
+		}
+		modification := VertexModification{
+			Before:     vertex,
+			After:      other,
+			Values:     !equalValues(vertex.GetValues(), other.GetValues()),
+			Properties: !GetProperties(vertex).Equals(GetProperties(other)),
+		}
+		if modification.Values || modification.Properties {
+			diff.ModifiedVertices = append(diff.ModifiedVertices, modification)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(afterVertices)) {
+		if _, ok := beforeVertices[key]; !ok {
+			diff.AddedVertices = append(diff.AddedVertices, afterVertices[key])
+		}
+	}
+	beforeEdges, afterEdges := diffEdges(before), diffEdges(after)
+	for _, key := range slices.Sorted(maps.Keys(beforeEdges)) {
+		edge := beforeEdges[key]
+		other, ok := afterEdges[key]
+		if !ok {
+			diff.RemovedEdges = append(diff.RemovedEdges, edge)
+			continue
+		}
+		record, otherRecord := NewEdgeRecord(edge), NewEdgeRecord(other)
+		modification := EdgeModification{
+			Before:     edge,
+			After:      other,
+			Endpoints:  record.From != otherRecord.From || record.To != otherRecord.To,
+			Points:     !equalPolylines(record.Points, otherRecord.Points),
+			Cost:       !equalCosts(record.Cost, otherRecord.Cost),
+			Properties: !record.Properties.Equals(otherRecord.Properties),
+		}
+		if modification.Endpoints || modification.Points || modification.Cost || modification.Properties {
+			diff.ModifiedEdges = append(diff.ModifiedEdges, modification)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(afterEdges)) {
+		if _, ok := beforeEdges[key]; !ok {
+			diff.AddedEdges = append(diff.AddedEdges, afterEdges[key])
+		}
+	}
+	return diff
+}
+
+func verticesByKey(graph Graph) map[int64]Vertex {
+	vertices := make(map[int64]Vertex)
+	for _, vertex := range graph.GetVertices() {
+		vertices[VertexHashOrId(vertex)] = vertex
+	}
+	return vertices
+}
+
+// diffEdges keys the edges of graph, keeping only the first direction of an undirected edge.
+func diffEdges(graph Graph) map[int64]Edge {
+	edges := make(map[int64]Edge)
+	for _, edge := range graph.GetEdges() {
+		if !graph.IsDirected() {
+			if _, ok := edges[EdgeHashOrId(edge.Reverse())]; ok {
+				continue
+			}
+		}
+		edges[EdgeHashOrId(edge)] = edge
+	}
+	return edges
+}
+
+func (d GraphDiff) IsEmpty() bool {
+	return len(d.AddedVertices) == 0 && len(d.RemovedVertices) == 0 && len(d.ModifiedVertices) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0 && len(d.ModifiedEdges) == 0
+}
+
+const (
+	PATCH_ADD_VERTEX    = "add_vertex"
+	PATCH_REMOVE_VERTEX = "remove_vertex"
+	PATCH_UPDATE_VERTEX = "update_vertex"
+	PATCH_ADD_EDGE      = "add_edge"
+	PATCH_REMOVE_EDGE   = "remove_edge"
+	PATCH_UPDATE_EDGE   = "update_edge"
+)
+
+// PatchOperation is one step of a GraphPatch. Vertex is set for vertex operations and Edge for
+// edge operations, holding the new state for additions and updates and the old one for removals.
+type PatchOperation struct {
+	Op     string        `json:"op"`
+	Vertex *VertexRecord `json:"vertex,omitempty"`
+	Edge   *EdgeRecord   `json:"edge,omitempty"`
+}
+
+// GraphPatch is a serializable GraphDiff. Its JSON form is
+//
+//	{"operations": [{"op": "add_edge", "edge": {"key": 1, "id": 1, "from": 2, "to": 3, ...}}, ...]}
+type GraphPatch struct {
+	Operations []PatchOperation `json:"operations"`
+}
+
+var ErrPatchConflict = errors.New("patch does not apply to the graph")
+
+// Patch returns the patch that turns the before graph of the diff into the after graph.
+func (d GraphDiff) Patch() *GraphPatch {
+	patch := &GraphPatch{Operations: make([]PatchOperation, 0)}
+	vertexOperation := func(op string, vertex Vertex) {
+		record := NewVertexRecord(vertex)
+		patch.Operations = append(patch.Operations, PatchOperation{Op: op, Vertex: &record})
+	}
+	edgeOperation := func(op string, edge Edge) {
+		record := NewEdgeRecord(edge)
+		patch.Operations = append(patch.Operations, PatchOperation{Op: op, Edge: &record})
+	}
+	for _, edge := range d.RemovedEdges {
+		edgeOperation(PATCH_REMOVE_EDGE, edge)
+	}
+	for _, vertex := range d.RemovedVertices {
+		vertexOperation(PATCH_REMOVE_VERTEX, vertex)
+	}
+	for _, vertex := range d.AddedVertices {
+		vertexOperation(PATCH_ADD_VERTEX, vertex)
+	}
+	for _, modification := range d.ModifiedVertices {
+		vertexOperation(PATCH_UPDATE_VERTEX, modification.After)
+	}
+	for _, modification := range d.ModifiedEdges {
+		edgeOperation(PATCH_UPDATE_EDGE, modification.After)
+	}
+	for _, edge := range d.AddedEdges {
+		edgeOperation(PATCH_ADD_EDGE, edge)
+	}
+	return patch
+}
+
+// Apply checks that every operation of the patch fits graph and then applies them in order. The
+// graph is left untouched when the check fails with an error wrapping ErrPatchConflict.
+func (p *GraphPatch) Apply(graph Graph) error {
+	if err := p.check(graph); err != nil {
+		return err
+	}
+	for _, operation := range p.Operations {
+		switch operation.Op {
+		case PATCH_ADD_VERTEX:
+			graph.AddVertex(operation.Vertex.Vertex())
+		case PATCH_REMOVE_VERTEX:
+			graph.RemoveVertex(graph.GetVertex(operation.Vertex.Key))
+		case PATCH_UPDATE_VERTEX:
+			updateVertex(graph, *operation.Vertex)
+		case PATCH_ADD_EDGE:
+			addEdgeRecord(graph, *operation.Edge)
+		case PATCH_REMOVE_EDGE:
+			graph.RemoveEdge(graph.GetEdge(operation.Edge.Key))
+		case PATCH_UPDATE_EDGE:
+			graph.RemoveEdge(graph.GetEdge(operation.Edge.Key))
+			addEdgeRecord(graph, *operation.Edge)
+		}
+	}
+	return nil
+}
+
+// check simulates the patch on the keys of graph.
+func (p *GraphPatch) check(graph Graph) error {
+	vertices := make(map[int64]bool)
+	edges := make(map[int64]bool)
+	hasVertex := func(key int64) bool {
+		if present, ok := vertices[key]; ok {
+			return present
+		}
+		return graph.GetVertex(key) != nil
+	}
+	hasEdge := func(key int64) bool {
+		if present, ok := edges[key]; ok {
+			return present
+		}
+		return graph.GetEdge(key) != nil
+	}
+	for i, operation := range p.Operations {
+		var err error
+		switch operation.Op {
+		case PATCH_ADD_VERTEX, PATCH_REMOVE_VERTEX, PATCH_UPDATE_VERTEX:
+			if operation.Vertex == nil {
+				err = fmt.Errorf("%w: operation %d has no vertex", ErrPatchConflict, i)
+			} else if present := hasVertex(operation.Vertex.Key); present == (operation.Op == PATCH_ADD_VERTEX) {
+				err = fmt.Errorf("%w: %s of vertex %d", ErrPatchConflict, operation.Op, operation.Vertex.Key)
+			} else {
+				vertices[operation.Vertex.Key] = operation.Op != PATCH_REMOVE_VERTEX
+			}
+		case PATCH_ADD_EDGE, PATCH_REMOVE_EDGE, PATCH_UPDATE_EDGE:
+			if operation.Edge == nil {
+				err = fmt.Errorf("%w: operation %d has no edge", ErrPatchConflict, i)
+			} else if present := hasEdge(operation.Edge.Key); present == (operation.Op == PATCH_ADD_EDGE) {
+				err = fmt.Errorf("%w: %s of edge %d", ErrPatchConflict, operation.Op, operation.Edge.Key)
+			} else if operation.Op != PATCH_REMOVE_EDGE && (!hasVertex(operation.Edge.From) || !hasVertex(operation.Edge.To)) {
+				err = fmt.Errorf("%w: edge %d has a missing endpoint", ErrPatchConflict, operation.Edge.Key)
+			} else {
+				edges[operation.Edge.Key] = operation.Op != PATCH_REMOVE_EDGE
+			}
+		default:
+			err = fmt.Errorf("%w: unknown operation %q", ErrPatchConflict, operation.Op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addEdgeRecord(graph Graph, record EdgeRecord) {
+	graph.AddEdge(record.Edge(graph.GetVertex(record.From), graph.GetVertex(record.To)))
+}
+
+// updateVertex replaces the vertex of record with a new one and rebinds its edges to it.
+func updateVertex(graph Graph, record VertexRecord) {
+	vertex := graph.GetVertex(record.Key)
+	edges := append([]Edge{}, vertex.GetEdges()...)
+	if graph.IsDirected() {
+		edges = append(edges, graph.GetIncomingEdges(vertex)...)
+	}
+	graph.RemoveVertex(vertex)
+	graph.AddVertex(record.Vertex())
+	for _, edge := range edges {
+		from := graph.GetVertex(VertexHashOrId(ToVertex(edge.From())))
+		to := graph.GetVertex(VertexHashOrId(ToVertex(edge.To())))
+		if !graph.ContainsEdge(edge) {
+			graph.AddEdge(cloneEdge(edge, from, to))
+		}
+	}
+}
+
+// Write encodes the patch as JSON.
+func (p *GraphPatch) Write(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
+
+func ReadGraphPatch(reader io.Reader) (*GraphPatch, error) {
+	patch := &GraphPatch{}
+	if err := json.NewDecoder(reader).Decode(patch); err != nil {
+		return nil, err
+	}
+	return patch, nil
+}
@@ -0,0 +1,110 @@
+package gograph
+
+import (
+	"bytes"
+	"errors"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"testing"
+)
+
+func buildDiffTestGraph() (*SimpleGraph, []*SimpleVertex) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertexWithId(gomath.Point{Values: []float64{0.0, 0.0}}, 1)
+	b := NewSimpleVertexWithId(gomath.Point{Values: []float64{2.0, 0.0}}, 2)
+	c := NewSimpleVertexWithId(gomath.Point{Values: []float64{2.0, 2.0}}, 3)
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_TIME: 2.0}))
+	graph.AddEdge(NewPolyEdge([]gomath.Spatial{&b, gomath.Point{Values: []float64{3.0, 1.0}}, &c}, 7))
+	graph.AddEdge(NewSimpleEdge(&a, &c, -1))
+	return graph, []*SimpleVertex{&a, &b, &c}
+}
+
+func TestDiffGraphs(t *testing.T) {
+	before, _ := buildDiffTestGraph()
+	after, _ := Clone(before)
+	a, b, c := after.GetVertex(1), after.GetVertex(2), after.GetVertex(3)
+
+	after.SetEdgeCost(GetEdge(a, b), COST_TYPE_TIME, 3.0)
+	after.RemoveEdge(GetEdge(a, c))
+	d := NewSimpleVertexWithId(gomath.Point{Values: []float64{0.0, 2.0}}, 4)
+	after.AddEdge(NewSimpleEdge(c, &d, -1))
+	after.RemoveEdge(GetEdge(b, c))
+	after.AddEdge(NewPolyEdge([]gomath.Spatial{b, gomath.Point{Values: []float64{3.0, 1.5}}, c}, 7))
+	moved := copyVertex(a)
+	moved.Spatial = gomath.Point{Values: []float64{-1.0, 0.0}}
+	moved.hash = -1
+	edges := append([]Edge{}, a.GetEdges()...)
+	after.RemoveVertex(a)
+	for _, edge := range edges {
+		after.AddEdge(cloneEdge(edge, moved, ToVertex(edge.To())))
+	}
+
+	diff := DiffGraphs(before, after)
+	if len(diff.AddedVertices) != 1 || len(diff.RemovedVertices) != 0 || len(diff.ModifiedVertices) != 1 {
+		t.Errorf("Expected 1 added and 1 modified vertex, got %d and %d", len(diff.AddedVertices), len(diff.ModifiedVertices))
+	}
+	if !diff.ModifiedVertices[0].Values || diff.ModifiedVertices[0].Properties {
+		t.Error("Expected the moved vertex to have new values")
+	}
+	if len(diff.AddedEdges) != 1 || len(diff.RemovedEdges) != 1 || len(diff.ModifiedEdges) != 2 {
+		t.Errorf("Expected 1 added, 1 removed and 2 modified edges, got %d, %d and %d",
+			len(diff.AddedEdges), len(diff.RemovedEdges), len(diff.ModifiedEdges))
+	}
+	for _, modification := range diff.ModifiedEdges {
+		if modification.Before.Id() == 7 && (!modification.Points || modification.Cost) {
+			t.Error("Expected the polyline of edge 7 to change")
+		}
+		if modification.Before.Id() == -1 && (!modification.Cost || modification.Points) {
+			t.Error("Expected the cost of the edge from a to b to change")
+		}
+	}
+	if !DiffGraphs(after, after).IsEmpty() {
+		t.Error("Expected no difference between a graph and itself")
+	}
+
+	patched, _ := Clone(before)
+	if err := diff.Patch().Apply(patched); err != nil {
+		t.Fatal(err)
+	}
+	if !DiffGraphs(patched, after).IsEmpty() || !patched.Validate().IsValid() {
+		t.Error("Expected the patch to move the vertex and rebind its edges")
+	}
+}
+
+func TestGraphPatch_Apply(t *testing.T) {
+	before, vertices := buildDiffTestGraph()
+	after, _ := Clone(before)
+	after.SetEdgeCost(GetEdge(after.GetVertex(1), after.GetVertex(2)), COST_TYPE_DISTANCE, 1.0)
+	after.RemoveVertex(after.GetVertex(3))
+	e := NewSimpleVertex(gomath.Point{Values: []float64{5.0, 5.0}})
+	after.AddEdge(NewPolyEdge([]gomath.Spatial{after.GetVertex(2), gomath.Point{Values: []float64{4.0, 4.0}}, &e}, -1))
+	e.Properties().SetString("name", "depot")
+
+	var buffer bytes.Buffer
+	if err := DiffGraphs(before, after).Patch().Write(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	patch, err := ReadGraphPatch(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := patch.Apply(before); err != nil {
+		t.Fatal(err)
+	}
+	if diff := DiffGraphs(before, after); !diff.IsEmpty() {
+		t.Errorf("Expected the patched graph to equal the target, got %+v", diff)
+	}
+	if before.Hash() != after.Hash() || !before.Validate().IsValid() {
+		t.Error("Expected the patched graph to be valid")
+	}
+	if len(vertices[1].Edges) != 2 {
+		t.Errorf("Expected b to keep 2 edges, got %d", len(vertices[1].Edges))
+	}
+
+	hash := before.Hash()
+	if err := patch.Apply(before); !errors.Is(err, ErrPatchConflict) {
+		t.Errorf("Expected a conflict when applying the patch twice, got %v", err)
+	}
+	if before.Hash() != hash {
+		t.Error("Expected a conflicting patch to leave the graph untouched")
+	}
+}
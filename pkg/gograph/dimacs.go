@@ -0,0 +1,253 @@
+package gograph
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrDIMACSSyntax = errors.New("invalid DIMACS file")
+
+// DIMACSOptions configures ReadDIMACS and WriteDIMACS. CostType is the edge cost holding the arc
+// weights and defaults to COST_TYPE_DISTANCE. Coordinates are divided by CoordinateScale when read
+// and multiplied by it when written. It defaults to 1, and the 9th DIMACS Challenge files, which
+// give longitude and latitude in millionths of a degree, need 1e6 to work with
+// HaversineDistanceCostFunction.
+type DIMACSOptions struct {
+	CostType        string
+	CoordinateScale float64
+}
+
+func dimacsOptions(options []DIMACSOptions) DIMACSOptions {
+	resolved := DIMACSOptions{}
+	if len(options) > 0 {
+		resolved = options[0]
+	}
+	if resolved.CostType == "" {
+		resolved.CostType = COST_TYPE_DISTANCE
+	}
+	if resolved.CoordinateScale == 0 {
+		resolved.CoordinateScale = 1
+	}
+	return resolved
+}
+
+// dimacsLines reads the lines of a DIMACS file that are not comments, numbering them from 1.
+type dimacsLines struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newDIMACSLines(reader io.Reader) *dimacsLines {
+	return &dimacsLines{scanner: bufio.NewScanner(reader)}
+}
+
+func (l *dimacsLines) next() ([]string, bool) {
+	for l.scanner.Scan() {
+		l.line++
+		if fields := strings.Fields(l.scanner.Text()); len(fields) > 0 && fields[0] != "c" {
+			return fields, true
+		}
+	}
+	return nil, false
+}
+
+func (l *dimacsLines) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: line %d: %s", ErrDIMACSSyntax, l.line, fmt.Sprintf(format, args...))
+}
+
+// ReadDIMACS reads a DIMACS shortest path .gr file into a directed multigraph whose vertex ids are
+// the node numbers. Every arc becomes an edge whose id is its position in the file and whose
+// CostType cost is its weight, so parallel arcs are kept. When coordinates is not nil, it is read
+// as the matching .co file and gives the vertices their positions; otherwise vertex n is placed
+// at [n, 0]. Reading takes time linear in the size of the files.
+func ReadDIMACS(arcs io.Reader, coordinates io.Reader, options ...DIMACSOptions) (*SimpleGraph, error) {
+	resolved := dimacsOptions(options)
+	var positions [][]float64
+	if coordinates != nil {
+		var err error
+		if positions, err = readDIMACSCoordinates(coordinates, resolved.CoordinateScale); err != nil {
+			return nil, err
+		}
+	}
+
+	lines := newDIMACSLines(arcs)
+	graph := NewDirectedMultiGraph()
+	var vertices []*SimpleVertex
+	expected := 0
+	count := 0
+	for {
+		fields, ok := lines.next()
+		if !ok {
+			break
+		}
+		switch fields[0] {
+		case "p":
+			if vertices != nil || len(fields) != 4 || fields[1] != "sp" {
+				return nil, lines.errorf("invalid problem line %q", strings.Join(fields, " "))
+			}
+			n, nErr := strconv.Atoi(fields[2])
+			m, mErr := strconv.Atoi(fields[3])
+			if nErr != nil || mErr != nil || n < 0 || m < 0 {
+				return nil, lines.errorf("invalid problem line %q", strings.Join(fields, " "))
+			}
+			if positions != nil && len(positions) != n {
+				return nil, lines.errorf("%d nodes, but the coordinates have %d", n, len(positions))
+			}
+			vertices = make([]*SimpleVertex, n)
+			for i := range vertices {
+				record := VertexRecord{Id: int64(i + 1), Values: []float64{float64(i + 1), 0}}
+				if positions != nil {
+					record.Values = positions[i]
+				}
+				vertices[i] = record.Vertex()
+				graph.AddVertex(vertices[i])
+			}
+			expected = m
+		case "a":
+			if vertices == nil {
+				return nil, lines.errorf("arc before the problem line")
+			}
+			if len(fields) != 4 {
+				return nil, lines.errorf("invalid arc %q", strings.Join(fields, " "))
+			}
+			from, fromErr := strconv.Atoi(fields[1])
+			to, toErr := strconv.Atoi(fields[2])
+			weight, weightErr := strconv.ParseFloat(fields[3], 64)
+			if fromErr != nil || toErr != nil || weightErr != nil {
+				return nil, lines.errorf("invalid arc %q", strings.Join(fields, " "))
+			}
+			if from < 1 || from > len(vertices) || to < 1 || to > len(vertices) {
+				return nil, lines.errorf("arc %d %d refers to a node outside 1..%d", from, to, len(vertices))
+			}
+			count++
+			graph.appendEdge(NewSimpleEdge(vertices[from-1], vertices[to-1], int64(count), &map[string]float64{resolved.CostType: weight}))
+		default:
+			return nil, lines.errorf("unknown line type %q", fields[0])
+		}
+	}
+	if err := lines.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if vertices == nil {
+		return nil, fmt.Errorf("%w: no problem line", ErrDIMACSSyntax)
+	}
+	if count != expected {
+		return nil, fmt.Errorf("%w: expected %d arcs, found %d", ErrDIMACSSyntax, expected, count)
+	}
+	return graph, nil
+}
+
+// readDIMACSCoordinates reads a .co file into the positions of its nodes in order.
+func readDIMACSCoordinates(reader io.Reader, scale float64) ([][]float64, error) {
+	lines := newDIMACSLines(reader)
+	var positions [][]float64
+	for {
+		fields, ok := lines.next()
+		if !ok {
+			break
+		}
+		switch fields[0] {
+		case "p":
+			if positions != nil || len(fields) != 5 || fields[1] != "aux" || fields[2] != "sp" || fields[3] != "co" {
+				return nil, lines.errorf("invalid problem line %q", strings.Join(fields, " "))
+			}
+			n, err := strconv.Atoi(fields[4])
+			if err != nil || n < 0 {
+				return nil, lines.errorf("invalid problem line %q", strings.Join(fields, " "))
+			}
+			positions = make([][]float64, n)
+		case "v":
+			if positions == nil {
+				return nil, lines.errorf("node before the problem line")
+			}
+			if len(fields) != 4 {
+				return nil, lines.errorf("invalid node %q", strings.Join(fields, " "))
+			}
+			node, nodeErr := strconv.Atoi(fields[1])
+			x, xErr := strconv.ParseFloat(fields[2], 64)
+			y, yErr := strconv.ParseFloat(fields[3], 64)
+			if nodeErr != nil || xErr != nil || yErr != nil {
+				return nil, lines.errorf("invalid node %q", strings.Join(fields, " "))
+			}
+			if node < 1 || node > len(positions) || positions[node-1] != nil {
+				return nil, lines.errorf("node %d is outside 1..%d or listed twice", node, len(positions))
+			}
+			positions[node-1] = []float64{x / scale, y / scale}
+		default:
+			return nil, lines.errorf("unknown line type %q", fields[0])
+		}
+	}
+	if err := lines.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if positions == nil {
+		return nil, fmt.Errorf("%w: no problem line in the coordinates", ErrDIMACSSyntax)
+	}
+	for i, position := range positions {
+		if position == nil {
+			return nil, fmt.Errorf("%w: node %d has no coordinates", ErrDIMACSSyntax, i+1)
+		}
+	}
+	return positions, nil
+}
+
+// WriteDIMACS writes graph as a DIMACS shortest path .gr file, and its vertex positions as a .co
+// file when coordinates is not nil. Vertices are numbered from 1 in the order of GetVertices and
+// arcs follow the order of GetEdges, so a graph read with ReadDIMACS keeps its node numbers and
+// arc ids. Every edge becomes an arc, in both directions for an undirected graph, weighted by its
+// CostType cost, or its distance when it has none. DIMACS weights and coordinates are integers,
+// so both are rounded.
+func WriteDIMACS(arcs io.Writer, coordinates io.Writer, graph Graph, options ...DIMACSOptions) error {
+	resolved := dimacsOptions(options)
+	vertices := graph.GetVertices()
+	numbers := make(map[int64]int, len(vertices))
+	for i, vertex := range vertices {
+		numbers[VertexHashOrId(vertex)] = i + 1
+	}
+	edges := graph.GetEdges()
+	if !graph.IsDirected() {
+		// An undirected edge with an id is stored in one direction only, unlike its reverse.
+		for _, edge := range edges {
+			from, to := ToVertex(edge.From()), ToVertex(edge.To())
+			if reverse := edge.Reverse(); EdgeHashOrId(reverse) == EdgeHashOrId(edge) && VertexHashOrId(from) != VertexHashOrId(to) {
+				edges = append(edges, reverse)
+			}
+		}
+	}
+
+	output := bufio.NewWriter(arcs)
+	_, _ = fmt.Fprintf(output, "p sp %d %d\n", len(vertices), len(edges))
+	for _, edge := range edges {
+		weight := edge.Distance()
+		if cost := edge.Cost(); cost != nil {
+			if value, ok := (*cost)[resolved.CostType]; ok {
+				weight = value
+			}
+		}
+		from, to := numbers[VertexHashOrId(ToVertex(edge.From()))], numbers[VertexHashOrId(ToVertex(edge.To()))]
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("arc %d %d has weight %v, which DIMACS cannot represent", from, to, weight)
+		}
+		_, _ = fmt.Fprintf(output, "a %d %d %d\n", from, to, int64(math.Round(weight)))
+	}
+	if err := output.Flush(); err != nil {
+		return err
+	}
+	if coordinates == nil {
+		return nil
+	}
+
+	output = bufio.NewWriter(coordinates)
+	_, _ = fmt.Fprintf(output, "p aux sp co %d\n", len(vertices))
+	for i, vertex := range vertices {
+		x := int64(math.Round(vertex.X() * resolved.CoordinateScale))
+		y := int64(math.Round(vertex.Y() * resolved.CoordinateScale))
+		_, _ = fmt.Fprintf(output, "v %d %d %d\n", i+1, x, y)
+	}
+	return output.Flush()
+}
@@ -0,0 +1,148 @@
+package gograph
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"strings"
+	"testing"
+)
+
+const dimacsArcs = `c 9th DIMACS Implementation Challenge: Shortest Paths
+c sample graph
+p sp 4 6
+c arcs
+a 1 2 10
+a 2 1 10
+a 2 3 5
+a 3 4 7
+a 1 4 30
+a 1 4 25
+`
+
+const dimacsCoordinates = `c coordinates in millionths of a degree
+p aux sp co 4
+v 1 -73530767 41085396
+v 2 -73530538 41086098
+v 3 -73519366 41048796
+v 4 -73519377 41048654
+`
+
+func TestReadDIMACS(t *testing.T) {
+	graph, err := ReadDIMACS(strings.NewReader(dimacsArcs), strings.NewReader(dimacsCoordinates), DIMACSOptions{CoordinateScale: 1e6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !graph.IsDirected() || !graph.IsMultiGraph() || graph.Size() != 4 || len(graph.GetEdges()) != 6 || !graph.Validate().IsValid() {
+		t.Fatalf("Expected 4 nodes and 6 arcs, got %d and %d", graph.Size(), len(graph.GetEdges()))
+	}
+	one, four := graph.GetVertex(1), graph.GetVertex(4)
+	if one.X() != -73.530767 || one.Y() != 41.085396 {
+		t.Errorf("Expected scaled coordinates, got %v", one.GetValues())
+	}
+	if parallel := GetEdges(one, four); len(parallel) != 2 {
+		t.Error("Expected both parallel arcs to be kept")
+	}
+	if arc := graph.GetEdge(3); (*arc.Cost())[COST_TYPE_DISTANCE] != 5 || ToVertex(arc.From()).Id() != 2 {
+		t.Error("Expected arc ids to follow the file")
+	}
+	if len(GetEdges(four, one)) != 0 {
+		t.Error("Expected arcs to be directed")
+	}
+
+	positioned, err := ReadDIMACS(strings.NewReader(dimacsArcs), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	three := positioned.GetVertex(3)
+	if three.X() != 3 || three.Y() != 0 {
+		t.Error("Expected vertices at their node numbers without coordinates")
+	}
+	response := BFS(RoutingAlgorithmRequest{Start: positioned.GetVertex(1), Destination: positioned.GetVertex(4)})
+	if !response.Completed || response.Path == nil || response.Path.Length() != 1 {
+		t.Error("Expected a route along one of the parallel arcs")
+	}
+
+	timed, err := ReadDIMACS(strings.NewReader(dimacsArcs), nil, DIMACSOptions{CostType: COST_TYPE_TIME})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*timed.GetEdge(1).Cost())[COST_TYPE_TIME] != 10 {
+		t.Error("Expected the weights under the cost type")
+	}
+}
+
+func TestReadDIMACS_Errors(t *testing.T) {
+	for name, text := range map[string]string{
+		"arc count":    "p sp 2 2\na 1 2 1\n",
+		"node range":   "p sp 2 1\na 1 3 1\n",
+		"weight":       "p sp 2 1\na 1 2 far\n",
+		"no problem":   "a 1 2 1\n",
+		"problem type": "p max 2 1\na 1 2 1\n",
+		"line type":    "p sp 2 1\ne 1 2\n",
+	} {
+		if _, err := ReadDIMACS(strings.NewReader(text), nil); !errors.Is(err, ErrDIMACSSyntax) {
+			t.Errorf("%s: expected a syntax error, got %v", name, err)
+		}
+	}
+	_, err := ReadDIMACS(strings.NewReader("c header\np sp 2 1\n\na 1 2 x\n"), nil)
+	if err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("Expected an error on line 4, got %v", err)
+	}
+	if _, err := ReadDIMACS(strings.NewReader(dimacsArcs), strings.NewReader("p aux sp co 4\nv 1 0 0\n")); !errors.Is(err, ErrDIMACSSyntax) {
+		t.Errorf("Expected an error for missing coordinates, got %v", err)
+	}
+}
+
+func TestWriteDIMACS(t *testing.T) {
+	graph, err := ReadDIMACS(strings.NewReader(dimacsArcs), strings.NewReader(dimacsCoordinates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var arcs, coordinates bytes.Buffer
+	if err := WriteDIMACS(&arcs, &coordinates, graph); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadDIMACS(&arcs, &coordinates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := DiffGraphs(graph, decoded); !diff.IsEmpty() {
+		t.Errorf("Expected no difference, got %+v", diff)
+	}
+
+	undirected := NewSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0, 0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{0, 1}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{5, 5}})
+	undirected.AddEdge(NewSimpleEdge(&a, &b, -1))
+	undirected.AddEdge(NewSimpleEdge(&b, &c, -1, &map[string]float64{COST_TYPE_DISTANCE: 2.6}))
+	arcs.Reset()
+	if err := WriteDIMACS(&arcs, nil, undirected); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(arcs.String(), "p sp 3 4\n") || strings.Count(arcs.String(), " 1\n") != 2 || strings.Count(arcs.String(), " 3\n") != 2 {
+		t.Errorf("Expected both directions with rounded weights, got %q", arcs.String())
+	}
+	undirected.AddEdge(NewSimpleEdge(&a, &c, -1, &map[string]float64{COST_TYPE_DISTANCE: -1}))
+	if err := WriteDIMACS(&arcs, nil, undirected); err == nil {
+		t.Error("Expected an error for a negative weight")
+	}
+}
+
+func TestReadDIMACS_Large(t *testing.T) {
+	const n = 100000
+	var builder strings.Builder
+	_, _ = fmt.Fprintf(&builder, "p sp %d %d\n", n, 2*(n-1))
+	for i := 2; i <= n; i++ {
+		_, _ = fmt.Fprintf(&builder, "a 1 %d 1\na %d 1 1\n", i, i)
+	}
+	graph, err := ReadDIMACS(strings.NewReader(builder.String()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hub := graph.GetVertex(1); graph.OutDegree(hub) != n-1 || graph.InDegree(hub) != n-1 {
+		t.Error("Expected a star around node 1")
+	}
+}
@@ -0,0 +1,724 @@
+package gograph
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DOT attributes written by WriteDOT besides costs and properties. Positions are written as
+// pos="x,y!", pinning vertices for neato and fdp.
+const (
+	DOT_ATTRIBUTE_POSITION  = "pos"
+	DOT_ATTRIBUTE_VERTEX_ID = "vertex_id"
+	DOT_ATTRIBUTE_EDGE_ID   = "edge_id"
+	DOT_ATTRIBUTE_POINTS    = "points"
+	DOT_DEFAULT_PATH_COLOR  = "red"
+)
+
+var ErrDOTSyntax = errors.New("invalid DOT")
+
+// DOTOptions configures WriteDOT and WritePathDOT.
+//
+// Name is the name of the written graph, G by default. Positions writes the coordinates of each
+// vertex multiplied by Scale, which defaults to 1, as its pos attribute. CostLabel names the cost
+// shown as the label of each edge. The edges and vertices of Path are drawn in PathColor, red by
+// default.
+type DOTOptions struct {
+	Name      string
+	Positions bool
+	Scale     float64
+	CostLabel string
+	Path      Path
+	PathColor string
+}
+
+// dotPresentationAttributes are read as properties even when their value is a number.
+var dotPresentationAttributes = map[string]bool{
+	"arrowhead": true, "arrowsize": true, "arrowtail": true, "class": true, "color": true,
+	"constraint": true, "dir": true, "fillcolor": true, "fontcolor": true, "fontname": true,
+	"fontsize": true, "headlabel": true, "href": true, "id": true, "key": true, "label": true,
+	"len": true, "lp": true, "minlen": true, "penwidth": true, "pos": true, "style": true,
+	"taillabel": true, "tooltip": true, "URL": true, "xlabel": true,
+}
+
+var dotKeywords = map[string]bool{"node": true, "edge": true, "graph": true, "digraph": true, "subgraph": true, "strict": true}
+
+var (
+	dotIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z_0-9]*$`)
+	dotNumeral    = regexp.MustCompile(`^-?(\.[0-9]+|[0-9]+(\.[0-9]*)?)$`)
+)
+
+type dotAttribute struct {
+	Name  string
+	Value string
+}
+
+// WriteDOT writes graph in the Graphviz DOT language, as a digraph when it is directed and as a
+// graph otherwise. An undirected edge is written once. Vertex ids, edge ids, polylines and costs
+// are written as attributes, so ReadDOT restores them, and properties are written as attributes
+// that ReadDOT reads back as strings. Pass the Graph of an MSTResponse to draw a spanning tree.
+func WriteDOT(writer io.Writer, graph Graph, options ...DOTOptions) error {
+	return writeDOT(writer, NewGraphRecord(graph), dotOptions(options))
+}
+
+// WritePathDOT writes the edges of path in order as a digraph.
+func WritePathDOT(writer io.Writer, path Path, options ...DOTOptions) error {
+	record := NewPathRecord(path)
+	return writeDOT(writer, GraphRecord{Directed: true, Vertices: record.Vertices, Edges: record.Edges}, dotOptions(options))
+}
+
+func dotOptions(options []DOTOptions) DOTOptions {
+	resolved := DOTOptions{}
+	if len(options) > 0 {
+		resolved = options[0]
+	}
+	if resolved.Name == "" {
+		resolved.Name = "G"
+	}
+	if resolved.Scale == 0 {
+		resolved.Scale = 1
+	}
+	if resolved.PathColor == "" {
+		resolved.PathColor = DOT_DEFAULT_PATH_COLOR
+	}
+	return resolved
+}
+
+func writeDOT(writer io.Writer, record GraphRecord, options DOTOptions) error {
+	highlightedEdges := make(map[int64]bool)
+	highlightedVertices := make(map[int64]bool)
+	if options.Path != nil {
+		for _, edge := range options.Path.GetEdges() {
+			highlightedEdges[EdgeHashOrId(edge)] = true
+			highlightedEdges[EdgeHashOrId(edge.Reverse())] = true
+			highlightedVertices[VertexHashOrId(ToVertex(edge.From()))] = true
+			highlightedVertices[VertexHashOrId(ToVertex(edge.To()))] = true
+		}
+	}
+	kind, operator := "graph", "--"
+	if record.Directed {
+		kind, operator = "digraph", "->"
+	}
+	names := vertexNames(record)
+
+	output := bufio.NewWriter(writer)
+	_, _ = fmt.Fprintf(output, "%s %s {\n", kind, dotId(options.Name))
+	for _, vertex := range record.Vertices {
+		attributes := make([]dotAttribute, 0)
+		if options.Positions && len(vertex.Values) > 0 {
+			values := make([]string, len(vertex.Values))
+			for i, value := range vertex.Values {
+				values[i] = formatFloat(value * options.Scale)
+			}
+			attributes = append(attributes, dotAttribute{DOT_ATTRIBUTE_POSITION, strings.Join(values, ",") + "!"})
+		}
+		if vertex.Id > 0 {
+			attributes = append(attributes, dotAttribute{DOT_ATTRIBUTE_VERTEX_ID, strconv.FormatInt(vertex.Id, 10)})
+		}
+		reserved := []string{DOT_ATTRIBUTE_POSITION, DOT_ATTRIBUTE_VERTEX_ID}
+		if highlightedVertices[vertex.Key] {
+			reserved = append(reserved, "color")
+		}
+		attributes = append(attributes, dotPropertyAttributes(vertex.Properties, reserved...)...)
+		if highlightedVertices[vertex.Key] {
+			attributes = append(attributes, dotAttribute{"color", options.PathColor})
+		}
+		_, _ = fmt.Fprintf(output, "\t%s%s;\n", dotId(names[vertex.Key]), formatDOTAttributes(attributes))
+	}
+	for _, edge := range record.Edges {
+		attributes := make([]dotAttribute, 0)
+		if edge.Id != -1 {
+			attributes = append(attributes, dotAttribute{DOT_ATTRIBUTE_EDGE_ID, strconv.FormatInt(edge.Id, 10)})
+		}
+		if len(edge.Points) > 0 {
+			attributes = append(attributes, dotAttribute{DOT_ATTRIBUTE_POINTS, formatPolyline(edge.Points)})
+		}
+		for _, key := range slices.Sorted(maps.Keys(edge.Cost)) {
+			attributes = append(attributes, dotAttribute{key, formatFloat(edge.Cost[key])})
+		}
+		// The cost label and the highlighting take the place of the edge's own attributes.
+		reserved := []string{DOT_ATTRIBUTE_EDGE_ID, DOT_ATTRIBUTE_POINTS}
+		if _, ok := edge.Cost[options.CostLabel]; ok {
+			reserved = append(reserved, "label")
+		}
+		if highlightedEdges[edge.Key] {
+			reserved = append(reserved, "color", "penwidth")
+		}
+		attributes = append(attributes, dotPropertyAttributes(edge.Properties, reserved...)...)
+		if value, ok := edge.Cost[options.CostLabel]; ok {
+			attributes = append(attributes, dotAttribute{"label", formatFloat(value)})
+		}
+		if highlightedEdges[edge.Key] {
+			attributes = append(attributes, dotAttribute{"color", options.PathColor}, dotAttribute{"penwidth", "3"})
+		}
+		_, _ = fmt.Fprintf(output, "\t%s %s %s%s;\n", dotId(names[edge.From]), operator, dotId(names[edge.To]), formatDOTAttributes(attributes))
+	}
+	_, _ = fmt.Fprintln(output, "}")
+	return output.Flush()
+}
+
+func dotPropertyAttributes(properties *Properties, reserved ...string) []dotAttribute {
+	attributes := make([]dotAttribute, 0, properties.Len())
+	for _, key := range properties.Keys() {
+		if slices.Contains(reserved, key) {
+			continue
+		}
+		value, _ := properties.Get(key)
+		formatted := fmt.Sprint(value)
+		if floatValue, ok := value.(float64); ok {
+			formatted = formatFloat(floatValue)
+		}
+		attributes = append(attributes, dotAttribute{key, formatted})
+	}
+	return attributes
+}
+
+func formatDOTAttributes(attributes []dotAttribute) string {
+	if len(attributes) == 0 {
+		return ""
+	}
+	formatted := make([]string, len(attributes))
+	for i, attribute := range attributes {
+		formatted[i] = dotId(attribute.Name) + "=" + dotId(attribute.Value)
+	}
+	return " [" + strings.Join(formatted, ", ") + "]"
+}
+
+// dotId quotes value unless it is an identifier or a numeral, escaping quotes and backslashes.
+func dotId(value string) string {
+	if (dotIdentifier.MatchString(value) && !dotKeywords[strings.ToLower(value)]) || dotNumeral.MatchString(value) {
+		return value
+	}
+	return `"` + dotEscaper.Replace(value) + `"`
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// ReadDOT reads a graph or digraph in the Graphviz DOT language into a SimpleGraph. Attribute
+// statements, attribute lists, edge chains like a -> b -> c and subgraphs, also as the ends of an
+// edge, are supported, while ports are ignored. Each node name is registered with SetVertexKey.
+//
+// A pos attribute gives the coordinates of a vertex and vertex_id its id. A vertex without
+// vertex_id is identified by its coordinates, or by IdFromKey of its name when it has none or
+// shares them with another vertex. Numeric edge attributes become costs, except for Graphviz
+// presentation attributes like penwidth, and edge_id and points give the id and polyline of an
+// edge. Any other attribute becomes a string property. Parallel edges make the result a
+// multigraph, unless the graph is strict, in which case their attributes are merged.
+func ReadDOT(reader io.Reader) (*SimpleGraph, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := lexDOT(string(data))
+	if err != nil {
+		return nil, err
+	}
+	parser := &dotParser{tokens: tokens, nodes: make(map[string]map[string]string)}
+	if err := parser.parse(); err != nil {
+		return nil, err
+	}
+
+	edges := make([]dotEdge, 0, len(parser.edges))
+	pairs := make(map[[2]string]int)
+	multi := false
+	for _, edge := range parser.edges {
+		pair := [2]string{edge.from, edge.to}
+		if !parser.directed && edge.to < edge.from {
+			pair = [2]string{edge.to, edge.from}
+		}
+		if index, ok := pairs[pair]; ok {
+			if parser.strict {
+				maps.Copy(edges[index].attributes, edge.attributes)
+				continue
+			}
+			multi = true
+		} else {
+			pairs[pair] = len(edges)
+		}
+		edges = append(edges, edge)
+	}
+
+	graph := newSimpleGraph(parser.directed)
+	graph.multi = multi
+	vertices := make(map[string]*SimpleVertex, len(parser.order))
+	for _, name := range parser.order {
+		record, err := dotVertexRecord(parser.nodes[name])
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", name, err)
+		}
+		vertex, err := addKeyedVertex(graph, name, record)
+		if err != nil {
+			return nil, err
+		}
+		vertices[name] = vertex
+	}
+	records := make([]*EdgeRecord, len(edges))
+	for i, edge := range edges {
+		record, err := dotEdgeRecord(edge.attributes)
+		if err != nil {
+			return nil, fmt.Errorf("edge %s-%s: %w", edge.from, edge.to, err)
+		}
+		records[i] = &record
+	}
+	assignEdgeIds(graph, records)
+	for i, edge := range edges {
+		graph.AddEdge(records[i].Edge(vertices[edge.from], vertices[edge.to]))
+	}
+	return graph, nil
+}
+
+func dotVertexRecord(attributes map[string]string) (VertexRecord, error) {
+	record := VertexRecord{Id: -1, Values: make([]float64, 0), Properties: NewProperties()}
+	for _, name := range slices.Sorted(maps.Keys(attributes)) {
+		value := attributes[name]
+		switch name {
+		case DOT_ATTRIBUTE_POSITION:
+			for _, part := range strings.Split(strings.TrimSuffix(strings.TrimSpace(value), "!"), ",") {
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+				if err != nil {
+					return record, fmt.Errorf("invalid pos %q", value)
+				}
+				record.Values = append(record.Values, parsed)
+			}
+		case DOT_ATTRIBUTE_VERTEX_ID:
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return record, fmt.Errorf("invalid vertex_id %q", value)
+			}
+			record.Id = id
+		default:
+			record.Properties.SetString(name, value)
+		}
+	}
+	if record.Properties.Len() == 0 {
+		record.Properties = nil
+	}
+	return record, nil
+}
+
+func dotEdgeRecord(attributes map[string]string) (EdgeRecord, error) {
+	record := EdgeRecord{Id: -1, Cost: make(map[string]float64), Properties: NewProperties()}
+	for _, name := range slices.Sorted(maps.Keys(attributes)) {
+		value := attributes[name]
+		switch name {
+		case DOT_ATTRIBUTE_EDGE_ID:
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return record, fmt.Errorf("invalid edge_id %q", value)
+			}
+			record.Id = id
+		case DOT_ATTRIBUTE_POINTS:
+			points, err := parsePolyline(value)
+			if err != nil {
+				return record, fmt.Errorf("invalid points %q", value)
+			}
+			record.Points = points
+		default:
+			if cost, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(cost, 0) && !math.IsNaN(cost) && !dotPresentationAttributes[name] {
+				record.Cost[name] = cost
+			} else {
+				record.Properties.SetString(name, value)
+			}
+		}
+	}
+	if len(record.Cost) == 0 {
+		record.Cost = nil
+	}
+	if record.Properties.Len() == 0 {
+		record.Properties = nil
+	}
+	return record, nil
+}
+
+const (
+	dotTokenEnd = iota
+	dotTokenId
+	dotTokenQuoted
+	dotTokenSymbol
+)
+
+type dotToken struct {
+	kind int
+	text string
+	line int
+}
+
+// lexDOT splits a DOT document into identifiers, numerals, quoted and HTML strings and symbols,
+// skipping comments and preprocessor lines.
+func lexDOT(input string) ([]dotToken, error) {
+	tokens := make([]dotToken, 0)
+	line, lineStart := 1, true
+	for i := 0; i < len(input); {
+		character := input[i]
+		switch {
+		case character == '\n':
+			line++
+			lineStart = true
+			i++
+			continue
+		case character == ' ' || character == '\t' || character == '\r':
+			i++
+			continue
+		case character == '#' && lineStart:
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(input[i:], "//"):
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(input[i:], "/*"):
+			end := strings.Index(input[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: line %d: unterminated comment", ErrDOTSyntax, line)
+			}
+			line += strings.Count(input[i:i+2+end], "\n")
+			i += end + 4
+			continue
+		}
+		lineStart = false
+		start, startLine := i, line
+		switch {
+		case character == '"':
+			var text strings.Builder
+			for i++; i < len(input) && input[i] != '"'; i++ {
+				switch {
+				case input[i] == '\\' && i+1 < len(input) && (input[i+1] == '"' || input[i+1] == '\\'):
+					text.WriteByte(input[i+1])
+					i++
+				case input[i] == '\\' && i+1 < len(input) && input[i+1] == '\n':
+					line++
+					i++
+				default:
+					if input[i] == '\n' {
+						line++
+					}
+					text.WriteByte(input[i])
+				}
+			}
+			if i >= len(input) {
+				return nil, fmt.Errorf("%w: line %d: unterminated string", ErrDOTSyntax, startLine)
+			}
+			i++
+			tokens = append(tokens, dotToken{dotTokenQuoted, text.String(), startLine})
+		case character == '<':
+			depth := 0
+			for ; i < len(input); i++ {
+				if input[i] == '<' {
+					depth++
+				} else if input[i] == '>' {
+					depth--
+				} else if input[i] == '\n' {
+					line++
+				}
+				if depth == 0 {
+					break
+				}
+			}
+			if i >= len(input) {
+				return nil, fmt.Errorf("%w: line %d: unterminated HTML string", ErrDOTSyntax, startLine)
+			}
+			i++
+			tokens = append(tokens, dotToken{dotTokenQuoted, input[start+1 : i-1], startLine})
+		case strings.HasPrefix(input[i:], "--") || strings.HasPrefix(input[i:], "->"):
+			i += 2
+			tokens = append(tokens, dotToken{dotTokenSymbol, input[start:i], startLine})
+		case strings.ContainsRune("{}[]=;,:+", rune(character)):
+			i++
+			tokens = append(tokens, dotToken{dotTokenSymbol, input[start:i], startLine})
+		case character == '-' || character == '.' || (character >= '0' && character <= '9'):
+			i++
+			for i < len(input) && (input[i] == '.' || (input[i] >= '0' && input[i] <= '9')) {
+				i++
+			}
+			if !dotNumeral.MatchString(input[start:i]) {
+				return nil, fmt.Errorf("%w: line %d: invalid numeral %s", ErrDOTSyntax, line, input[start:i])
+			}
+			tokens = append(tokens, dotToken{dotTokenId, input[start:i], startLine})
+		case character == '_' || character >= 0x80 || (character|0x20 >= 'a' && character|0x20 <= 'z'):
+			for i < len(input) && dotIdentifierByte(input[i]) {
+				i++
+			}
+			tokens = append(tokens, dotToken{dotTokenId, input[start:i], startLine})
+		default:
+			return nil, fmt.Errorf("%w: line %d: unexpected character %q", ErrDOTSyntax, line, character)
+		}
+	}
+	return append(tokens, dotToken{dotTokenEnd, "", line}), nil
+}
+
+func dotIdentifierByte(character byte) bool {
+	return character == '_' || character >= 0x80 || (character >= '0' && character <= '9') ||
+		(character|0x20 >= 'a' && character|0x20 <= 'z')
+}
+
+type dotEdge struct {
+	from       string
+	to         string
+	attributes map[string]string
+}
+
+// dotScope holds the node and edge defaults set by attribute statements. A subgraph starts with a
+// copy of the defaults of its parent.
+type dotScope struct {
+	node map[string]string
+	edge map[string]string
+}
+
+// dotParser is a recursive descent parser for the statements of a DOT graph. It collects nodes, in
+// the order they are first mentioned, and edges with their resolved attributes.
+type dotParser struct {
+	tokens   []dotToken
+	position int
+	directed bool
+	strict   bool
+	nodes    map[string]map[string]string
+	order    []string
+	edges    []dotEdge
+}
+
+func (p *dotParser) peek() dotToken {
+	return p.tokens[p.position]
+}
+
+func (p *dotParser) next() dotToken {
+	token := p.tokens[p.position]
+	if token.kind != dotTokenEnd {
+		p.position++
+	}
+	return token
+}
+
+func (p *dotParser) errorf(token dotToken, format string, args ...any) error {
+	return fmt.Errorf("%w: line %d: %s", ErrDOTSyntax, token.line, fmt.Sprintf(format, args...))
+}
+
+func isDOTKeyword(token dotToken, keyword string) bool {
+	return token.kind == dotTokenId && strings.EqualFold(token.text, keyword)
+}
+
+func isDOTSymbol(token dotToken, symbol string) bool {
+	return token.kind == dotTokenSymbol && token.text == symbol
+}
+
+func (p *dotParser) expect(symbol string) error {
+	if token := p.next(); !isDOTSymbol(token, symbol) {
+		return p.errorf(token, "expected %s, found %q", symbol, token.text)
+	}
+	return nil
+}
+
+func (p *dotParser) parse() error {
+	if isDOTKeyword(p.peek(), "strict") {
+		p.next()
+		p.strict = true
+	}
+	token := p.next()
+	switch {
+	case isDOTKeyword(token, "digraph"):
+		p.directed = true
+	case !isDOTKeyword(token, "graph"):
+		return p.errorf(token, "expected graph or digraph, found %q", token.text)
+	}
+	if !isDOTSymbol(p.peek(), "{") {
+		if _, err := p.parseId(); err != nil {
+			return err
+		}
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	if _, err := p.parseStatements(dotScope{node: make(map[string]string), edge: make(map[string]string)}); err != nil {
+		return err
+	}
+	if token := p.peek(); token.kind != dotTokenEnd {
+		return p.errorf(token, "expected one graph, found %q", token.text)
+	}
+	return nil
+}
+
+// parseId reads an identifier, numeral or quoted string, joining quoted strings concatenated
+// with +.
+func (p *dotParser) parseId() (string, error) {
+	token := p.next()
+	if token.kind != dotTokenId && token.kind != dotTokenQuoted {
+		return "", p.errorf(token, "expected an identifier, found %q", token.text)
+	}
+	text := token.text
+	for token.kind == dotTokenQuoted && isDOTSymbol(p.peek(), "+") {
+		p.next()
+		token = p.next()
+		if token.kind != dotTokenQuoted {
+			return "", p.errorf(token, "expected a quoted string after +")
+		}
+		text += token.text
+	}
+	return text, nil
+}
+
+// parseStatements parses statements up to and including the closing brace of a graph or
+// subgraph, returning the nodes mentioned in them.
+func (p *dotParser) parseStatements(scope dotScope) ([]string, error) {
+	members := make([]string, 0)
+	for {
+		token := p.peek()
+		switch {
+		case token.kind == dotTokenEnd:
+			return nil, p.errorf(token, "expected }")
+		case isDOTSymbol(token, "}"):
+			p.next()
+			return members, nil
+		case isDOTSymbol(token, ";"):
+			p.next()
+		case isDOTKeyword(token, "graph") || isDOTKeyword(token, "node") || isDOTKeyword(token, "edge"):
+			p.next()
+			attributes, err := p.parseAttributes()
+			if err != nil {
+				return nil, err
+			}
+			if isDOTKeyword(token, "node") {
+				maps.Copy(scope.node, attributes)
+			} else if isDOTKeyword(token, "edge") {
+				maps.Copy(scope.edge, attributes)
+			}
+		default:
+			mentioned, err := p.parseStatement(scope)
+			if err != nil {
+				return nil, err
+			}
+			for _, name := range mentioned {
+				if !slices.Contains(members, name) {
+					members = append(members, name)
+				}
+			}
+		}
+	}
+}
+
+// parseStatement parses a node statement, an edge chain, a subgraph or a graph attribute
+// assignment.
+func (p *dotParser) parseStatement(scope dotScope) ([]string, error) {
+	start := p.peek()
+	if start.kind == dotTokenId || start.kind == dotTokenQuoted {
+		if !isDOTKeyword(start, "subgraph") && isDOTSymbol(p.tokens[p.position+1], "=") {
+			p.next()
+			p.next()
+			_, err := p.parseId()
+			return nil, err
+		}
+	}
+	first, err := p.parseOperand(scope)
+	if err != nil {
+		return nil, err
+	}
+	operands := [][]string{first}
+	for isDOTSymbol(p.peek(), "--") || isDOTSymbol(p.peek(), "->") {
+		if operator := p.next(); (operator.text == "->") != p.directed {
+			return nil, p.errorf(operator, "unexpected edge operator %s", operator.text)
+		}
+		operand, err := p.parseOperand(scope)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	attributes, err := p.parseAttributes()
+	if err != nil {
+		return nil, err
+	}
+	mentioned := make([]string, 0)
+	for _, operand := range operands {
+		for _, name := range operand {
+			p.addNode(name, scope)
+			mentioned = append(mentioned, name)
+		}
+	}
+	if len(operands) == 1 {
+		if !isDOTKeyword(start, "subgraph") && !isDOTSymbol(start, "{") {
+			maps.Copy(p.nodes[first[0]], attributes)
+		}
+		return mentioned, nil
+	}
+	for i := 1; i < len(operands); i++ {
+		for _, from := range operands[i-1] {
+			for _, to := range operands[i] {
+				edgeAttributes := maps.Clone(scope.edge)
+				maps.Copy(edgeAttributes, attributes)
+				p.edges = append(p.edges, dotEdge{from, to, edgeAttributes})
+			}
+		}
+	}
+	return mentioned, nil
+}
+
+// parseOperand parses a node, skipping its port, or a subgraph, returning the nodes it stands for.
+func (p *dotParser) parseOperand(scope dotScope) ([]string, error) {
+	token := p.peek()
+	if isDOTKeyword(token, "subgraph") || isDOTSymbol(token, "{") {
+		p.next()
+		if isDOTKeyword(token, "subgraph") {
+			if !isDOTSymbol(p.peek(), "{") {
+				if _, err := p.parseId(); err != nil {
+					return nil, err
+				}
+			}
+			if err := p.expect("{"); err != nil {
+				return nil, err
+			}
+		}
+		return p.parseStatements(dotScope{node: maps.Clone(scope.node), edge: maps.Clone(scope.edge)})
+	}
+	name, err := p.parseId()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < 2 && isDOTSymbol(p.peek(), ":"); i++ {
+		p.next()
+		if _, err := p.parseId(); err != nil {
+			return nil, err
+		}
+	}
+	return []string{name}, nil
+}
+
+// parseAttributes parses any number of attribute lists into one map.
+func (p *dotParser) parseAttributes() (map[string]string, error) {
+	attributes := make(map[string]string)
+	for isDOTSymbol(p.peek(), "[") {
+		p.next()
+		for !isDOTSymbol(p.peek(), "]") {
+			name, err := p.parseId()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			if attributes[name], err = p.parseId(); err != nil {
+				return nil, err
+			}
+			if isDOTSymbol(p.peek(), ",") || isDOTSymbol(p.peek(), ";") {
+				p.next()
+			}
+		}
+		p.next()
+	}
+	return attributes, nil
+}
+
+func (p *dotParser) addNode(name string, scope dotScope) {
+	if _, ok := p.nodes[name]; !ok {
+		p.nodes[name] = maps.Clone(scope.node)
+		p.order = append(p.order, name)
+	}
+}
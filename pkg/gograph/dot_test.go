@@ -0,0 +1,202 @@
+package gograph
+
+import (
+	"bytes"
+	"errors"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"strings"
+	"testing"
+)
+
+func TestDOT_RoundTrip(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertexWithId(gomath.Point{Values: []float64{0.0, 0.0}}, 1)
+	b := NewSimpleVertex(gomath.Point{Values: []float64{2.5, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{2.5, 2.0}})
+	a.Properties().SetString("name", "main \"depot\"")
+	b.Properties().SetString("folder", `C:\roads\`)
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_TIME: 2.5, COST_TYPE_DISTANCE: 1.0 / 3.0}))
+	graph.AddEdge(NewPolyEdge([]gomath.Spatial{&b, gomath.Point{Values: []float64{3.0, 1.0}}, &c}, 7))
+	graph.AddEdge(NewSimpleEdge(&c, &a, -1))
+	graph.SetVertexKey("depot", &a)
+
+	var buffer bytes.Buffer
+	if err := WriteDOT(&buffer, graph, DOTOptions{Positions: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buffer.String(), "graph G {") || !strings.Contains(buffer.String(), `depot [pos="0,0!", vertex_id=1`) {
+		t.Errorf("Unexpected DOT output:\n%s", buffer.String())
+	}
+	decoded, err := ReadDOT(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.IsDirected() || decoded.Hash() != graph.Hash() || !decoded.Validate().IsValid() {
+		t.Error("Expected the decoded graph to equal the graph")
+	}
+	if diff := DiffGraphs(graph, decoded); !diff.IsEmpty() {
+		t.Errorf("Expected no difference, got %+v", diff)
+	}
+	equalAdjacency(t, graph, decoded)
+	if name, _ := GetProperties(decoded.GetVertexByKey("depot")).GetString("name"); name != "main \"depot\"" {
+		t.Errorf("Expected the quoted property to round trip, got %q", name)
+	}
+	if folder, _ := GetProperties(decoded.GetVertex(VertexHashOrId(&b))).GetString("folder"); folder != `C:\roads\` {
+		t.Errorf("Expected the backslashes to round trip, got %q", folder)
+	}
+}
+
+func TestWriteDOT_Highlighting(t *testing.T) {
+	graph := NewDirectedSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 1.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_DISTANCE: 1.0}))
+	graph.AddEdge(NewSimpleEdge(&b, &c, -1, &map[string]float64{COST_TYPE_DISTANCE: 1.0}))
+	graph.AddEdge(NewSimpleEdge(&a, &c, -1, &map[string]float64{COST_TYPE_DISTANCE: 5.0}))
+	a.Properties().SetString("color", "green")
+	GetProperties(GetEdge(&a, &b)).SetString("color", "red")
+	GetProperties(GetEdge(&a, &c)).SetString("color", "gray")
+	GetProperties(GetEdge(&a, &c)).SetString("label", "bypass")
+	path := NewSimplePath([]Edge{GetEdge(&a, &b), GetEdge(&b, &c)})
+
+	var buffer bytes.Buffer
+	if err := WriteDOT(&buffer, graph, DOTOptions{Name: "route map", CostLabel: COST_TYPE_DISTANCE, Path: path, PathColor: "blue"}); err != nil {
+		t.Fatal(err)
+	}
+	output := buffer.String()
+	if !strings.HasPrefix(output, `digraph "route map" {`) || strings.Contains(output, "pos=") {
+		t.Errorf("Unexpected DOT output:\n%s", output)
+	}
+	if strings.Count(output, "penwidth=3") != 2 || strings.Count(output, "color=blue") != 5 || !strings.Contains(output, "label=5") {
+		t.Errorf("Expected the path to be highlighted and costs labelled:\n%s", output)
+	}
+	if strings.Contains(output, "green") || strings.Contains(output, "red") || strings.Contains(output, "bypass") || !strings.Contains(output, "color=gray") {
+		t.Errorf("Expected the highlighting and labels to replace the own attributes:\n%s", output)
+	}
+
+	buffer.Reset()
+	if err := WritePathDOT(&buffer, path); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadDOT(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.IsDirected() || decoded.Size() != 3 || len(decoded.GetEdges()) != 2 {
+		t.Errorf("Expected the path as a digraph of 2 edges, got %d", len(decoded.GetEdges()))
+	}
+}
+
+func TestWriteDOT_MST(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 1.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	graph.AddEdge(NewSimpleEdge(&b, &c, -1))
+	graph.AddEdge(NewSimpleEdge(&a, &c, -1))
+	response := KruskalMST(MSTRequest{Graph: graph})
+	if response.Error != nil {
+		t.Fatal(response.Error)
+	}
+	var buffer bytes.Buffer
+	if err := WriteDOT(&buffer, response.Graph); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buffer.String(), " -- ") != 2 {
+		t.Errorf("Expected 2 tree edges:\n%s", buffer.String())
+	}
+}
+
+const graphvizDOT = `/* A hand written graph */
+# 1 "roads.gv"
+strict digraph roads {
+	graph [rankdir=LR];
+	node [shape=box, label="stop"];
+	edge [weight=1];
+	a [pos="0,0"; label="Depot"]
+	b [pos="3,4!"]
+	a -> b -> c [time=2.5, color=red, penwidth=2]
+	a -> b [weight=4]
+	subgraph cluster_1 {
+		edge [weight=2]
+		c; d:port:n
+	}
+	d -> {e "f" + "g"}
+	"quoted \"name\"" -> <<b>html</b>> // trailing comment
+}`
+
+func TestReadDOT(t *testing.T) {
+	graph, err := ReadDOT(strings.NewReader(graphvizDOT))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !graph.IsDirected() || graph.IsMultiGraph() || graph.Size() != 8 || len(graph.GetEdges()) != 5 {
+		t.Errorf("Expected 8 vertices and 5 edges, got %d and %d", graph.Size(), len(graph.GetEdges()))
+	}
+	a, b, c := graph.GetVertexByKey("a"), graph.GetVertexByKey("b"), graph.GetVertexByKey("c")
+	if b.X() != 3.0 || b.Y() != 4.0 || len(c.GetValues()) != 0 {
+		t.Error("Expected pos to give the coordinates")
+	}
+	if label, _ := GetProperties(a).GetString("label"); label != "Depot" {
+		t.Errorf("Expected the label Depot, got %q", label)
+	}
+	if shape, _ := GetProperties(c).GetString("shape"); shape != "box" {
+		t.Error("Expected the node defaults to apply")
+	}
+	cost := GetEdge(a, b).Cost()
+	if cost == nil || (*cost)[COST_TYPE_TIME] != 2.5 || (*cost)["weight"] != 4.0 {
+		t.Errorf("Expected the strict graph to merge the attributes of a -> b, got %v", cost)
+	}
+	if color, _ := GetProperties(GetEdge(b, c)).GetString("color"); color != "red" {
+		t.Error("Expected color to be a property")
+	}
+	if penwidth, _ := GetProperties(GetEdge(b, c)).GetString("penwidth"); penwidth != "2" {
+		t.Error("Expected penwidth to be a property")
+	}
+	d := graph.GetVertexByKey("d")
+	if len(GetEdges(d, graph.GetVertexByKey("fg"))) != 1 || (*GetEdge(d, graph.GetVertexByKey("e")).Cost())["weight"] != 1.0 {
+		t.Error("Expected an edge from d to each node of the subgraph")
+	}
+	if len(GetEdges(graph.GetVertexByKey(`quoted "name"`), graph.GetVertexByKey("<b>html</b>"))) != 1 {
+		t.Error("Expected quoted and HTML names")
+	}
+
+	multi, err := ReadDOT(strings.NewReader("graph { a -- b; b -- a }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !multi.IsMultiGraph() || len(multi.GetEdges()) != 2 {
+		t.Error("Expected parallel edges to make a multigraph")
+	}
+
+	ids, err := ReadDOT(strings.NewReader("digraph { a -> b [weight=5]; a -> b [edge_id=1, weight=7] }"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids.GetEdges()) != 2 || (*ids.GetEdge(1).Cost())["weight"] != 7.0 {
+		t.Errorf("Expected a generated id not to take the explicit id 1, got %d edges", len(ids.GetEdges()))
+	}
+}
+
+func TestReadDOT_Errors(t *testing.T) {
+	documents := []string{
+		"graph { a -> b }",
+		"digraph { a -- b }",
+		"digraph { a -> }",
+		"digraph { a [color] }",
+		`digraph { a [label="open] }`,
+		"digraph { a -> b",
+		"digraph { } digraph { }",
+		"tree { }",
+	}
+	for _, document := range documents {
+		if _, err := ReadDOT(strings.NewReader(document)); !errors.Is(err, ErrDOTSyntax) {
+			t.Errorf("Expected %q to be rejected, got %v", document, err)
+		}
+	}
+	if _, err := ReadDOT(strings.NewReader(`digraph { a [pos="x,y"] }`)); err == nil {
+		t.Error("Expected an error for an invalid pos")
+	}
+}
@@ -98,7 +98,7 @@ func Contract(firstEdge gomath.Segment, edges ...gomath.Segment) gomath.Segment
 	if ok {
 		id = asEdge.Id()
 	}
-	return PolyEdge{Points: allPoints, id: id}
+	return PolyEdge{Points: allPoints, id: id, properties: &Properties{}}
 }
 
 func Theta(e Edge) float64 {
@@ -118,16 +118,103 @@ func SortEdgesByTheta(edges []Edge) []Edge {
 	})
 }
 
+// rebindEdge returns a copy of e whose endpoints are the given vertices, so that traversing
+// the edge lands on the graph's own vertex instances. Unknown Edge implementations are returned as is.
+func rebindEdge(e Edge, from, to Vertex) Edge {
+	switch edge := e.(type) {
+	case SimpleEdge:
+		edge.from = from
+		edge.to = to
+		return edge
+	case PolyEdge:
+		points := make([]gomath.Spatial, len(edge.Points))
+		copy(points, edge.Points)
+		points[0] = from
+		points[len(points)-1] = to
+		edge.Points = points
+		return edge
+	}
+	return e
+}
+
+func withEdgeId(e Edge, id int64) Edge {
+	switch edge := e.(type) {
+	case SimpleEdge:
+		edge.SetId(id)
+		return edge
+	case PolyEdge:
+		edge.SetId(id)
+		return edge
+	}
+	panic("Cannot set the id of this edge type")
+}
+
+func withCost(e Edge, cost *map[string]float64) Edge {
+	switch edge := e.(type) {
+	case SimpleEdge:
+		edge.cost = cost
+		return edge
+	case PolyEdge:
+		edge.cost = cost
+		return edge
+	}
+	return e
+}
+
+func withProperties(e Edge, properties *Properties) Edge {
+	switch edge := e.(type) {
+	case SimpleEdge:
+		edge.properties = properties
+		return edge
+	case PolyEdge:
+		edge.properties = properties
+		return edge
+	}
+	return e
+}
+
+// cloneEdge is rebindEdge with independent copies of the cost map, interior points and properties.
+func cloneEdge(e Edge, from, to Vertex) Edge {
+	var cost *map[string]float64
+	if e.Cost() != nil {
+		copied := make(map[string]float64, len(*e.Cost()))
+		for key, value := range *e.Cost() {
+			copied[key] = value
+		}
+		cost = &copied
+	}
+	switch edge := rebindEdge(e, from, to).(type) {
+	case SimpleEdge:
+		edge.cost = cost
+		edge.properties = edge.properties.Clone()
+		return edge
+	case PolyEdge:
+		for i := 1; i < len(edge.Points)-1; i++ {
+			edge.Points[i] = gomath.Point{Values: append([]float64{}, edge.Points[i].GetValues()...)}
+		}
+		edge.cost = cost
+		edge.properties = edge.properties.Clone()
+		return edge
+	}
+	if wrapper, ok := e.(interface{ Inner() Edge }); ok {
+		return cloneEdge(wrapper.Inner(), from, to)
+	}
+	edge := NewSimpleEdge(from, to, e.Id(), cost)
+	edge.properties = GetProperties(e).Clone()
+	return edge
+}
+
 // </editor-fold>
 
 // SimpleEdge <editor-fold>
 type SimpleEdge struct {
-	from     gomath.Spatial
-	to       gomath.Spatial
-	id       int64
-	distance float64
-	hash     int64
-	cost     *map[string]float64
+	from       gomath.Spatial
+	to         gomath.Spatial
+	id         int64
+	distance   float64
+	hash       int64
+	cost       *map[string]float64
+	properties *Properties
 }
 
 func NewSimpleEdge(from gomath.Spatial, to gomath.Spatial, id int64, cost ...*map[string]float64) SimpleEdge {
@@ -135,7 +222,7 @@ func NewSimpleEdge(from gomath.Spatial, to gomath.Spatial, id int64, cost ...*ma
 	if len(cost) > 0 {
 		tempCost = cost[0]
 	}
-	return SimpleEdge{from, to, id, -1.0, -1, tempCost}
+	return SimpleEdge{from, to, id, -1.0, -1, tempCost, &Properties{}}
 }
 
 func (e SimpleEdge) From() gomath.Spatial {
@@ -147,13 +234,18 @@ func (e SimpleEdge) To() gomath.Spatial {
 }
 
 func (e SimpleEdge) Reverse() Edge {
-	return NewSimpleEdge(e.to, e.from, e.id)
+	return SimpleEdge{e.to, e.from, e.id, -1.0, -1, e.cost, e.properties}
 }
 
 func (e SimpleEdge) Id() int64 {
 	return e.id
 }
 
+func (e *SimpleEdge) SetId(id int64) {
+	e.id = id
+	e.hash = -1
+}
+
 func (e SimpleEdge) Distance(distanceFunction ...gomath.DistanceFunction) float64 {
 	return gomath.ToPoint(e.from).DistanceTo(gomath.ToPoint(e.to), distanceFunction...)
 }
@@ -208,16 +300,18 @@ func (e SimpleEdge) ToPolyEdge(size int) PolyEdge {
 		panic("At least one edge is required to create a poly edge")
 	}
 	if size == 1 {
-		return PolyEdge{[]gomath.Spatial{e.From(), e.To()}, e.Id(), e.distance, -1, e.cost}
+		return PolyEdge{[]gomath.Spatial{e.From(), e.To()}, e.Id(), e.distance, -1, e.cost, e.properties}
 	}
 	split := e.Split(size) // use Euclidean distance
 	contracted := Contract(split[0], split[1:]...)
 	polyEdge, ok := contracted.(PolyEdge)
 	if ok {
+		polyEdge.cost = e.cost
+		polyEdge.properties = e.properties
 		return polyEdge
 	}
 	// Shouldn't get here.
-	return PolyEdge{[]gomath.Spatial{e.From(), e.To()}, e.Id(), e.distance, e.Hash(), e.cost}
+	return PolyEdge{[]gomath.Spatial{e.From(), e.To()}, e.Id(), e.distance, e.Hash(), e.cost, e.properties}
 }
 
 func (e SimpleEdge) Hash() int64 {
@@ -227,6 +321,9 @@ func (e SimpleEdge) Hash() int64 {
 	fromHash := VertexHashOrId(ToVertex(e.From()))
 	toHash := VertexHashOrId(ToVertex(e.To()))
 	values := []float64{float64(fromHash), float64(toHash)}
+	if e.id != -1 {
+		values = append(values, float64(e.id))
+	}
 	hasher := fnv.New64a()
 
 	for _, value := range values {
@@ -246,19 +343,30 @@ func (e SimpleEdge) Cost() *map[string]float64 {
 	return e.cost
 }
 
+// Properties returns the properties of the edge. NewSimpleEdge starts every edge with an empty bag.
+func (e SimpleEdge) Properties() *Properties {
+	return e.properties
+}
+
+// SetProperties attaches properties to the edge. Like the cost map, they are shared with its Reverse.
+func (e *SimpleEdge) SetProperties(properties *Properties) {
+	e.properties = properties
+}
+
 // </editor-fold>
 
 // PolyEdge <editor-fold>
 type PolyEdge struct {
-	Points   []gomath.Spatial
-	id       int64
-	distance float64
-	hash     int64
-	cost     *map[string]float64
+	Points     []gomath.Spatial
+	id         int64
+	distance   float64
+	hash       int64
+	cost       *map[string]float64
+	properties *Properties
 }
 
 func NewPolyEdge(points []gomath.Spatial, id int64) PolyEdge {
-	return PolyEdge{points, id, -1, -1, nil}
+	return PolyEdge{points, id, -1, -1, nil, &Properties{}}
 }
 
 func (e PolyEdge) From() gomath.Spatial {
@@ -274,13 +382,21 @@ func (e PolyEdge) Reverse() Edge {
 	for i := 0; i < len(e.Points); i++ {
 		reversedVertices[i] = e.Points[len(e.Points)-1-i]
 	}
-	return NewPolyEdge(reversedVertices, e.id)
+	reversed := NewPolyEdge(reversedVertices, e.id)
+	reversed.cost = e.cost
+	reversed.properties = e.properties
+	return reversed
 }
 
 func (e PolyEdge) Id() int64 {
 	return e.id
 }
 
+func (e *PolyEdge) SetId(id int64) {
+	e.id = id
+	e.hash = -1
+}
+
 func (e PolyEdge) Distance(distanceFunction ...gomath.DistanceFunction) float64 {
 	retSum := 0.0
 	for i := 0; i < len(e.Points)-1; i++ {
@@ -391,11 +507,23 @@ func (e PolyEdge) Hash() int64 {
 	if e.hash != -1 {
 		return e.hash
 	}
-	hash := int64(31)
+	hasher := fnv.New64a()
 	for _, point := range e.Points {
-		hash ^= VertexHashOrId(ToVertex(point))
+		key := VertexHashOrId(ToVertex(point))
+		var buf [8]byte
+		for i := 0; i < 8; i++ {
+			buf[i] = byte(key >> (i * 8))
+		}
+		_, _ = hasher.Write(buf[:])
 	}
-	e.hash = hash
+	if e.id != -1 {
+		var buf [8]byte
+		for i := 0; i < 8; i++ {
+			buf[i] = byte(e.id >> (i * 8))
+		}
+		_, _ = hasher.Write(buf[:])
+	}
+	e.hash = int64(hasher.Sum64())
 	return e.hash
 }
 
@@ -403,4 +531,13 @@ func (e PolyEdge) Cost() *map[string]float64 {
 	return e.cost
 }
 
+// Properties returns the properties of the edge. NewPolyEdge starts every edge with an empty bag.
+func (e PolyEdge) Properties() *Properties {
+	return e.properties
+}
+
+func (e *PolyEdge) SetProperties(properties *Properties) {
+	e.properties = properties
+}
+
 // </editor-fold>
@@ -1,7 +1,7 @@
 package gograph
 
 type CostEntry struct {
-	Accumulated float64
-	Current     float64
-	Total       float64
+	Accumulated float64 `json:"accumulated"`
+	Current     float64 `json:"current"`
+	Total       float64 `json:"total"`
 }
@@ -0,0 +1,535 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"maps"
+	"math"
+	"time"
+)
+
+// FrozenGraph is an immutable, array backed (compressed sparse row) copy of a Graph. Vertices
+// are addressed by dense indices and every edge carries precomputed weights for each cost key,
+// so routing and MST algorithms avoid vertex hashing and cost function evaluation entirely.
+type FrozenGraph struct {
+	id            int64
+	hash          int64
+	directed      bool
+	multi         bool
+	vertices      []*FrozenVertex
+	originals     []Vertex
+	offsets       []int
+	sources       []int32
+	targets       []int32
+	adjacency     []Edge
+	originalEdges []Edge
+	edgeHashes    []int64
+	costs         []map[string]float64
+	weights       map[string][]float64
+	inOffsets     []int
+	inEdges       []int32
+	vertexIndex   map[int64]int32
+}
+
+// Freeze builds a FrozenGraph from the adjacency of every vertex in graph. Edge weights are taken
+// from each edge's Cost() map when present and otherwise evaluated once with pCostFunctions,
+// which defaults to Euclidean distance. Cost functions that depend on accumulated costs should
+// not be frozen.
+func Freeze(graph Graph, pCostFunctions ...map[string]CostFunction) *FrozenGraph {
+	var costFunctions map[string]CostFunction
+	if len(pCostFunctions) > 0 {
+		costFunctions, _ = GenerateInitialCosts(&pCostFunctions[0])
+	} else {
+		costFunctions, _ = GenerateInitialCosts(nil)
+	}
+	originals := graph.GetVertices()
+	frozen := &FrozenGraph{
+		id:          time.Now().UnixNano(),
+		hash:        graph.Hash(),
+		directed:    graph.IsDirected(),
+		multi:       true,
+		vertices:    make([]*FrozenVertex, len(originals)),
+		originals:   originals,
+		offsets:     make([]int, len(originals)+1),
+		weights:     make(map[string][]float64),
+		vertexIndex: make(map[int64]int32, len(originals)),
+	}
+	if multiGraph, ok := graph.(interface{ IsMultiGraph() bool }); ok {
+		frozen.multi = multiGraph.IsMultiGraph()
+	}
+	for i, vertex := range originals {
+		frozen.vertexIndex[VertexHashOrId(vertex)] = int32(i)
+	}
+	for i, vertex := range originals {
+		frozen.vertices[i] = &FrozenVertex{
+			graph:  frozen,
+			index:  int32(i),
+			values: vertex.GetValues(),
+			hash:   VertexHashOrId(vertex),
+		}
+		for _, edge := range vertex.GetEdges() {
+			target, ok := frozen.vertexIndex[VertexHashOrId(ToVertex(edge.To()))]
+			if !ok {
+				continue
+			}
+			edgeIndex := int32(len(frozen.originalEdges))
+			frozen.edgeHashes = append(frozen.edgeHashes, EdgeHashOrId(edge))
+			frozen.sources = append(frozen.sources, int32(i))
+			frozen.targets = append(frozen.targets, target)
+			frozen.originalEdges = append(frozen.originalEdges, edge)
+			frozen.adjacency = append(frozen.adjacency, FrozenEdge{graph: frozen, index: edgeIndex})
+		}
+		frozen.offsets[i+1] = len(frozen.originalEdges)
+	}
+	frozen.precomputeWeights(costFunctions)
+	frozen.buildIncoming()
+	return frozen
+}
+
+func (g *FrozenGraph) precomputeWeights(costFunctions map[string]CostFunction) {
+	numEdges := len(g.originalEdges)
+	keys := make(map[string]bool)
+	for key := range costFunctions {
+		keys[key] = true
+	}
+	for _, edge := range g.originalEdges {
+		if cost := edge.Cost(); cost != nil {
+			for key := range *cost {
+				keys[key] = true
+			}
+		}
+	}
+	for key := range keys {
+		g.weights[key] = make([]float64, numEdges)
+		for i := range g.weights[key] {
+			g.weights[key][i] = math.NaN()
+		}
+	}
+	_, initialCosts := GenerateInitialCosts(&costFunctions)
+	g.costs = make([]map[string]float64, numEdges)
+	for i, edge := range g.originalEdges {
+		from := g.originals[g.sources[i]]
+		to := g.originals[g.targets[i]]
+		var edgeCost map[string]float64
+		if cost := edge.Cost(); cost != nil {
+			edgeCost = *cost
+		}
+		current := make(map[string]float64, len(keys))
+		for key := range keys {
+			value, ok := edgeCost[key]
+			if !ok {
+				costFunction, exists := costFunctions[key]
+				if !exists {
+					continue
+				}
+				value = costFunction.Eval(NewVertexWrapper(from, initialCosts), to)
+			}
+			current[key] = value
+			g.weights[key][i] = value
+		}
+		g.costs[i] = current
+	}
+}
+
+func (g *FrozenGraph) buildIncoming() {
+	g.inOffsets = make([]int, len(g.vertices)+1)
+	for _, target := range g.targets {
+		g.inOffsets[target+1]++
+	}
+	for i := 1; i < len(g.inOffsets); i++ {
+		g.inOffsets[i] += g.inOffsets[i-1]
+	}
+	g.inEdges = make([]int32, len(g.targets))
+	next := make([]int, len(g.vertices))
+	copy(next, g.inOffsets[:len(g.vertices)])
+	for i, target := range g.targets {
+		g.inEdges[next[target]] = int32(i)
+		next[target]++
+	}
+}
+
+// Vertex returns the frozen counterpart of a vertex from the source graph, or nil.
+func (g *FrozenGraph) Vertex(original Vertex) *FrozenVertex {
+	index := g.indexOf(original)
+	if index < 0 {
+		return nil
+	}
+	return g.vertices[index]
+}
+
+// indexOf resolves frozen vertices, vertices wrapping them and source vertices to a dense index.
+func (g *FrozenGraph) indexOf(spatial gomath.Spatial) int32 {
+	switch vertex := spatial.(type) {
+	case *FrozenVertex:
+		if vertex.graph == g {
+			return vertex.index
+		}
+		return g.indexOf(g.originalOf(vertex))
+	case *VertexWrapper:
+		return g.indexOf(vertex.Inner)
+	case *SimpleVertex:
+		if _, ok := vertex.Spatial.(*FrozenVertex); ok {
+			return g.indexOf(vertex.Spatial)
+		}
+	}
+	index, ok := g.vertexIndex[VertexHashOrId(ToVertex(spatial))]
+	if !ok {
+		return -1
+	}
+	return index
+}
+
+func (g *FrozenGraph) originalOf(vertex *FrozenVertex) Vertex {
+	return vertex.graph.originals[vertex.index]
+}
+
+func (g *FrozenGraph) OriginalVertex(vertex Vertex) Vertex {
+	index := g.indexOf(vertex)
+	if index < 0 {
+		return nil
+	}
+	return g.originals[index]
+}
+
+// edgeIndexOf finds the frozen edge with the endpoints and EdgeHashOrId of e, or returns -1. Both
+// endpoints are matched, since the two directions of an undirected edge with an id share the id.
+func (g *FrozenGraph) edgeIndexOf(e Edge) int32 {
+	if frozenEdge, ok := e.(FrozenEdge); ok && frozenEdge.graph == g {
+		return frozenEdge.index
+	}
+	from, to := g.indexOf(e.From()), g.indexOf(e.To())
+	if from < 0 || to < 0 {
+		return -1
+	}
+	hash := EdgeHashOrId(e)
+	for i := g.offsets[from]; i < g.offsets[from+1]; i++ {
+		if g.targets[i] == to && g.edgeHashes[i] == hash {
+			return int32(i)
+		}
+	}
+	return -1
+}
+
+// OriginalEdge maps an edge between two frozen vertices back to the source graph's edge. An edge
+// that matches no frozen edge exactly maps to the first source edge between its endpoints.
+func (g *FrozenGraph) OriginalEdge(edge Edge) Edge {
+	if index := g.edgeIndexOf(edge); index >= 0 {
+		return g.originalEdges[index]
+	}
+	from, to := g.indexOf(edge.From()), g.indexOf(edge.To())
+	if from < 0 || to < 0 {
+		return nil
+	}
+	for i := g.offsets[from]; i < g.offsets[from+1]; i++ {
+		if g.targets[i] == to {
+			return g.originalEdges[i]
+		}
+	}
+	return nil
+}
+
+// ThawEdges maps edges found on the frozen graph, such as a routed path or MST, back to the
+// source graph's edges. Edges without a counterpart are dropped.
+func (g *FrozenGraph) ThawEdges(edges []Edge) []Edge {
+	retArray := make([]Edge, 0, len(edges))
+	for _, edge := range edges {
+		if original := g.OriginalEdge(edge); original != nil {
+			retArray = append(retArray, original)
+		}
+	}
+	return retArray
+}
+
+func (g *FrozenGraph) ThawPath(path Path) Path {
+	return NewSimplePath(g.ThawEdges(path.GetEdges()))
+}
+
+func (g *FrozenGraph) ThawResponse(response RoutingAlgorithmResponse) RoutingAlgorithmResponse {
+	visited := make(map[int64]bool, len(response.Visited))
+	for id, ok := range response.Visited {
+		if vertex := g.GetVertex(id); vertex != nil {
+			visited[vertex.Hash()] = ok
+		}
+	}
+	return RoutingAlgorithmResponse{
+		Costs:     response.Costs,
+		Path:      g.ThawPath(response.Path),
+		Visited:   visited,
+		Completed: response.Completed,
+	}
+}
+
+// Weights returns the precomputed weight of every edge for key, indexed like GetEdges. Edges
+// without a weight for key hold NaN.
+func (g *FrozenGraph) Weights(key string) []float64 {
+	return g.weights[key]
+}
+
+func (g *FrozenGraph) Id() int64 {
+	return g.id
+}
+
+func (g *FrozenGraph) GetEdge(id int64) Edge {
+	if id < 1 || id > int64(len(g.adjacency)) {
+		return nil
+	}
+	return g.adjacency[id-1]
+}
+
+func (g *FrozenGraph) GetVertex(id int64) Vertex {
+	if id < 1 || id > int64(len(g.vertices)) {
+		return nil
+	}
+	return g.vertices[id-1]
+}
+
+func (g *FrozenGraph) AddEdge(_ Edge) {
+	panic("FrozenGraph is read-only")
+}
+
+func (g *FrozenGraph) ContainsEdge(e Edge) bool {
+	return g.edgeIndexOf(e) >= 0
+}
+
+func (g *FrozenGraph) ContainsVertex(v Vertex) bool {
+	return g.indexOf(v) >= 0
+}
+
+func (g *FrozenGraph) AddVertex(_ Vertex) {
+	panic("FrozenGraph is read-only")
+}
+
+func (g *FrozenGraph) GetVertices() []Vertex {
+	vertices := make([]Vertex, len(g.vertices))
+	for i, vertex := range g.vertices {
+		vertices[i] = vertex
+	}
+	return vertices
+}
+
+func (g *FrozenGraph) GetEdges() []Edge {
+	edges := make([]Edge, len(g.adjacency))
+	copy(edges, g.adjacency)
+	return edges
+}
+
+func (g *FrozenGraph) RemoveEdge(_ Edge) {
+	panic("FrozenGraph is read-only")
+}
+
+func (g *FrozenGraph) RemoveVertex(_ Vertex) {
+	panic("FrozenGraph is read-only")
+}
+
+func (g *FrozenGraph) Size() int {
+	return len(g.vertices)
+}
+
+func (g *FrozenGraph) Clear() {
+	panic("FrozenGraph is read-only")
+}
+
+func (g *FrozenGraph) Hash() int64 {
+	return g.hash
+}
+
+func (g *FrozenGraph) IsDirected() bool {
+	return g.directed
+}
+
+func (g *FrozenGraph) InDegree(v Vertex) int {
+	index := g.indexOf(v)
+	if index < 0 {
+		return 0
+	}
+	return g.inOffsets[index+1] - g.inOffsets[index]
+}
+
+func (g *FrozenGraph) OutDegree(v Vertex) int {
+	index := g.indexOf(v)
+	if index < 0 {
+		return 0
+	}
+	return g.offsets[index+1] - g.offsets[index]
+}
+
+func (g *FrozenGraph) GetIncomingEdges(v Vertex) []Edge {
+	index := g.indexOf(v)
+	if index < 0 {
+		return []Edge{}
+	}
+	edges := make([]Edge, 0, g.inOffsets[index+1]-g.inOffsets[index])
+	for _, edgeIndex := range g.inEdges[g.inOffsets[index]:g.inOffsets[index+1]] {
+		edges = append(edges, g.adjacency[edgeIndex])
+	}
+	return edges
+}
+
+// FrozenVertex <editor-fold>
+type FrozenVertex struct {
+	graph  *FrozenGraph
+	index  int32
+	values []float64
+	hash   int64
+}
+
+// Index is the dense position of the vertex in its FrozenGraph.
+func (v *FrozenVertex) Index() int {
+	return int(v.index)
+}
+
+func (v *FrozenVertex) GetValues() []float64 {
+	return v.values
+}
+
+func (v *FrozenVertex) Size() int {
+	return len(v.values)
+}
+
+func (v *FrozenVertex) X() float64 {
+	return v.coordinate(0)
+}
+
+func (v *FrozenVertex) Y() float64 {
+	return v.coordinate(1)
+}
+
+func (v *FrozenVertex) Z() float64 {
+	return v.coordinate(2)
+}
+
+func (v *FrozenVertex) W() float64 {
+	return v.coordinate(3)
+}
+
+func (v *FrozenVertex) coordinate(i int) float64 {
+	if i < len(v.values) {
+		return v.values[i]
+	}
+	return 0
+}
+
+// Id is the dense index offset by one, so that VertexHashOrId never falls back to hashing.
+func (v *FrozenVertex) Id() int64 {
+	return int64(v.index) + 1
+}
+
+func (v *FrozenVertex) GetEdges() []Edge {
+	return v.graph.adjacency[v.graph.offsets[v.index]:v.graph.offsets[v.index+1]:v.graph.offsets[v.index+1]]
+}
+
+// Hash is the VertexHashOrId of the source vertex.
+func (v *FrozenVertex) Hash() int64 {
+	return v.hash
+}
+
+func (v *FrozenVertex) GetEdge(to Vertex) Edge {
+	target := v.graph.indexOf(to)
+	for i := v.graph.offsets[v.index]; i < v.graph.offsets[v.index+1]; i++ {
+		if v.graph.targets[i] == target {
+			return v.graph.adjacency[i]
+		}
+	}
+	return GetEdge(v, to)
+}
+
+func (v *FrozenVertex) Properties() *Properties {
+	return GetProperties(v.graph.originals[v.index])
+}
+
+func (v *FrozenVertex) AddEdge(_ Edge) {
+	panic("FrozenGraph is read-only")
+}
+
+func (v *FrozenVertex) RemoveEdge(_ Edge) {
+	panic("FrozenGraph is read-only")
+}
+
+// </editor-fold>
+
+// FrozenEdge <editor-fold>
+type FrozenEdge struct {
+	graph *FrozenGraph
+	index int32
+}
+
+func (e FrozenEdge) original() Edge {
+	return e.graph.originalEdges[e.index]
+}
+
+// Index is the position of the edge in GetEdges and Weights.
+func (e FrozenEdge) Index() int {
+	return int(e.index)
+}
+
+func (e FrozenEdge) From() gomath.Spatial {
+	return e.graph.vertices[e.graph.sources[e.index]]
+}
+
+func (e FrozenEdge) To() gomath.Spatial {
+	return e.graph.vertices[e.graph.targets[e.index]]
+}
+
+func (e FrozenEdge) Reverse() Edge {
+	from, to := e.graph.targets[e.index], e.graph.sources[e.index]
+	for i := e.graph.offsets[from]; i < e.graph.offsets[from+1]; i++ {
+		if e.graph.targets[i] == to {
+			return e.graph.adjacency[i]
+		}
+	}
+	return NewSimpleEdge(e.To(), e.From(), -1)
+}
+
+func (e FrozenEdge) Distance(distanceFunction ...gomath.DistanceFunction) float64 {
+	return e.original().Distance(distanceFunction...)
+}
+
+func (e FrozenEdge) DistanceCached(distanceFunction ...gomath.DistanceFunction) float64 {
+	if len(distanceFunction) == 0 {
+		if distance, ok := e.Weight(COST_TYPE_DISTANCE); ok {
+			return distance
+		}
+	}
+	return e.original().DistanceCached(distanceFunction...)
+}
+
+func (e FrozenEdge) Scale(t float64, distanceFunction ...gomath.DistanceFunction) gomath.Spatial {
+	return e.original().Scale(t, distanceFunction...)
+}
+
+func (e FrozenEdge) Split(size int, distanceFunction ...gomath.DistanceFunction) []gomath.Segment {
+	return e.original().Split(size, distanceFunction...)
+}
+
+func (e FrozenEdge) Id() int64 {
+	return int64(e.index) + 1
+}
+
+func (e FrozenEdge) String() string {
+	return e.original().String()
+}
+
+// Hash is the EdgeHashOrId of the source edge.
+func (e FrozenEdge) Hash() int64 {
+	return e.graph.edgeHashes[e.index]
+}
+
+// Cost returns a copy of the precomputed costs, so that the frozen graph cannot be changed through it.
+func (e FrozenEdge) Cost() *map[string]float64 {
+	cost := maps.Clone(e.graph.costs[e.index])
+	return &cost
+}
+
+// Weight returns the precomputed weight of the edge for key without copying its costs.
+func (e FrozenEdge) Weight(key string) (float64, bool) {
+	weights, ok := e.graph.weights[key]
+	if !ok || math.IsNaN(weights[e.index]) {
+		return 0, false
+	}
+	return weights[e.index], true
+}
+
+func (e FrozenEdge) Properties() *Properties {
+	return GetProperties(e.original())
+}
+
+// </editor-fold>
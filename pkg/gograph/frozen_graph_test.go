@@ -0,0 +1,135 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"math"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	graph := BoundedGridGraphProvider{
+		BoundingBox: gomath.BoundingBox{MinX: 0, MinY: 0, MaxX: 10, MaxY: 10},
+		Width:       10,
+		Height:      10,
+	}.Build()
+	frozen := Freeze(graph)
+	if frozen.Size() != graph.Size() || len(frozen.GetEdges()) != len(graph.GetEdges()) {
+		t.Fatalf("Expected %d vertices and %d edges, got %d and %d", graph.Size(), len(graph.GetEdges()), frozen.Size(), len(frozen.GetEdges()))
+	}
+	for _, vertex := range graph.GetVertices() {
+		frozenVertex := frozen.Vertex(vertex)
+		if frozenVertex == nil {
+			t.Fatal("Missing frozen vertex")
+		}
+		if frozen.OutDegree(frozenVertex) != len(vertex.GetEdges()) || frozen.InDegree(frozenVertex) != graph.InDegree(vertex) {
+			t.Error("Frozen degrees differ from the source graph")
+		}
+		if frozen.OriginalVertex(frozenVertex) != vertex {
+			t.Error("Frozen vertex does not map back to its source vertex")
+		}
+	}
+	for i, distance := range frozen.Weights(COST_TYPE_DISTANCE) {
+		if math.Abs(distance-frozen.GetEdges()[i].Distance(gomath.EuclideanDistance)) > 1e-9 {
+			t.Errorf("Unexpected precomputed distance %f", distance)
+		}
+	}
+}
+
+func TestFrozenGraph_Routing(t *testing.T) {
+	graph := BoundedGridGraphProvider{
+		BoundingBox: gomath.BoundingBox{MinX: 0, MinY: 0, MaxX: 10, MaxY: 10},
+		Width:       10,
+		Height:      10,
+	}.Build()
+	frozen := Freeze(graph)
+	start := graph.GetVertex(HashVertex(VertexFromSpatial(gomath.Point{Values: []float64{0.0, 0.0}})))
+	destination := graph.GetVertex(HashVertex(VertexFromSpatial(gomath.Point{Values: []float64{9.0, 9.0}})))
+	for _, algorithm := range []RoutingAlgorithm{BFS, DFS, AStar} {
+		response := algorithm(RoutingAlgorithmRequest{
+			Start:       frozen.Vertex(start),
+			Destination: frozen.Vertex(destination),
+		})
+		thawed := frozen.ThawResponse(response)
+		edges := thawed.Path.GetEdges()
+		if len(edges) == 0 || len(edges) != response.Path.Length() {
+			t.Fatalf("Expected a thawed path, got %d edges", len(edges))
+		}
+		endpoints := map[Vertex]bool{}
+		for _, edge := range edges {
+			endpoints[ToVertex(edge.From())] = true
+			endpoints[ToVertex(edge.To())] = true
+		}
+		if !endpoints[start] || !endpoints[destination] {
+			t.Error("Thawed path does not reach the source vertices")
+		}
+		for _, edge := range edges {
+			if !graph.ContainsEdge(edge) {
+				t.Error("Thawed edge is not part of the source graph")
+			}
+		}
+	}
+}
+
+func TestFrozenGraph_MST(t *testing.T) {
+	graph := BoundedRandomGraphProvider{
+		BoundingBox:    gomath.BoundingBox{MinX: 10, MinY: 10, MaxX: 20, MaxY: 20},
+		NumPoints:      50,
+		NumConnections: 5,
+	}.Build()
+	frozen := Freeze(graph)
+	for _, mst := range []MST{KruskalMST, PrimsMST} {
+		response := mst(MSTRequest{Graph: frozen})
+		if response.Error != nil {
+			t.Fatal(response.Error)
+		}
+		thawed := frozen.ThawEdges(response.Graph.GetEdges())
+		if len(thawed) != len(response.Graph.GetEdges()) {
+			t.Errorf("Expected %d thawed edges, got %d", len(response.Graph.GetEdges()), len(thawed))
+		}
+	}
+}
+
+func TestFrozenGraph_Weights(t *testing.T) {
+	graph := NewDirectedSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{2.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_DISTANCE: 4.0, COST_TYPE_TIME: 2.0}))
+	graph.AddEdge(NewSimpleEdge(&b, &c, -1, &map[string]float64{COST_TYPE_DISTANCE: 6.0}))
+	frozen := Freeze(graph)
+	first, second := frozen.Vertex(&a).GetEdge(frozen.Vertex(&b)).(FrozenEdge), frozen.Vertex(&b).GetEdge(frozen.Vertex(&c)).(FrozenEdge)
+	if weight, ok := first.Weight(COST_TYPE_TIME); !ok || weight != 2.0 {
+		t.Error("Expected the precomputed time")
+	}
+	if _, ok := second.Weight(COST_TYPE_TIME); ok || !math.IsNaN(frozen.Weights(COST_TYPE_TIME)[second.Index()]) {
+		t.Error("Expected no time for an edge without one")
+	}
+	(*first.Cost())[COST_TYPE_DISTANCE] = 100.0
+	if weight, _ := first.Weight(COST_TYPE_DISTANCE); weight != 4.0 || (*first.Cost())[COST_TYPE_DISTANCE] != 4.0 {
+		t.Error("Expected the frozen costs to be read-only")
+	}
+	response := BFS(RoutingAlgorithmRequest{Start: frozen.Vertex(&a), Destination: frozen.Vertex(&c)})
+	if !response.Completed || response.Costs[COST_TYPE_DISTANCE].Total != 10.0 {
+		t.Errorf("Expected a route of distance 10, got %v", response.Costs[COST_TYPE_DISTANCE])
+	}
+}
+
+func TestFrozenGraph_EdgeIds(t *testing.T) {
+	graph := NewMultiGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, 1, &map[string]float64{COST_TYPE_DISTANCE: 4.0}))
+	graph.AddEdge(NewSimpleEdge(&a, &b, 2, &map[string]float64{COST_TYPE_DISTANCE: 2.0}))
+	frozen := Freeze(graph)
+	for _, edge := range graph.GetEdges() {
+		for _, direction := range []Edge{edge, edge.Reverse()} {
+			original := frozen.OriginalEdge(direction)
+			if !frozen.ContainsEdge(direction) || original == nil || original.Id() != direction.Id() {
+				t.Fatalf("Expected edge %d to resolve to itself", direction.Id())
+			}
+			if VertexHashOrId(ToVertex(original.From())) != VertexHashOrId(ToVertex(direction.From())) {
+				t.Errorf("Expected edge %d to keep its direction", direction.Id())
+			}
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package gograph
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// GeoJSON feature properties written by WriteGeoJSON besides costs and properties.
+const (
+	GEOJSON_PROPERTY_VERTEX_ID = "vertex_id"
+	GEOJSON_PROPERTY_EDGE_ID   = "edge_id"
+)
+
+var ErrGeoJSONUnsupported = errors.New("unsupported GeoJSON")
+
+// GeoJSONOptions configures ReadGeoJSON and the GeoJSON writers.
+//
+// Positions closer than Tolerance under DistanceFunction are merged into one vertex. The distance
+// function defaults to gomath.HaversineDistance, which measures meters between [longitude,
+// latitude] positions, and a zero tolerance only merges equal positions. Directed reads every
+// LineString as an edge from its first to its last position. CostProperties names the numeric
+// feature properties read as edge costs; when it is nil every numeric property is a cost.
+type GeoJSONOptions struct {
+	Tolerance        float64
+	DistanceFunction gomath.DistanceFunction
+	Directed         bool
+	CostProperties   []string
+}
+
+func geoJSONOptions(options []GeoJSONOptions) GeoJSONOptions {
+	resolved := GeoJSONOptions{}
+	if len(options) > 0 {
+		resolved = options[0]
+	}
+	if resolved.DistanceFunction == nil {
+		resolved.DistanceFunction = gomath.HaversineDistance
+	}
+	return resolved
+}
+
+// geoJSONObject holds any GeoJSON object: a FeatureCollection, a Feature or a geometry.
+type geoJSONObject struct {
+	Type        string          `json:"type"`
+	Features    []geoJSONObject `json:"features,omitempty"`
+	Id          any             `json:"id,omitempty"`
+	Geometry    *geoJSONObject  `json:"geometry,omitempty"`
+	Properties  map[string]any  `json:"properties,omitempty"`
+	Coordinates json.RawMessage `json:"coordinates,omitempty"`
+	Geometries  []geoJSONObject `json:"geometries,omitempty"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Id         string          `json:"id,omitempty"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// geoJSONMerger maps positions to the first position within the tolerance of them.
+type geoJSONMerger struct {
+	index   *SpatialIndex
+	options GeoJSONOptions
+}
+
+func newGeoJSONMerger(options GeoJSONOptions) *geoJSONMerger {
+	return &geoJSONMerger{index: NewSpatialIndexFromVertices(nil), options: options}
+}
+
+func (m *geoJSONMerger) find(position []float64) Vertex {
+	point := gomath.Point{Values: position}
+	nearest := m.index.Nearest(point, m.options.DistanceFunction)
+	if nearest != nil && len(nearest.GetValues()) == len(position) && m.options.DistanceFunction(nearest, point) <= m.options.Tolerance {
+		return nearest
+	}
+	return nil
+}
+
+// WriteGeoJSON writes graph as a FeatureCollection of a Point for each vertex and a LineString for
+// each edge, so a spanning tree is written by passing the Graph of an MSTResponse. An undirected
+// edge is written once. Costs, properties and ids become feature properties and the keys of a
+// SimpleGraph become feature ids, so ReadGeoJSON restores them. Vertices within the tolerance of
+// an earlier vertex are written at its position, and only that vertex is written as a Point.
+func WriteGeoJSON(writer io.Writer, graph Graph, options ...GeoJSONOptions) error {
+	resolved := geoJSONOptions(options)
+	record := NewGraphRecord(graph)
+	vertexNames := make(map[int64]string, len(record.VertexKeys))
+	for _, name := range slices.Sorted(maps.Keys(record.VertexKeys)) {
+		if key := record.VertexKeys[name]; vertexNames[key] == "" {
+			vertexNames[key] = name
+		}
+	}
+	edgeNames := make(map[int64]string, len(record.EdgeKeys))
+	for _, name := range slices.Sorted(maps.Keys(record.EdgeKeys)) {
+		if key := record.EdgeKeys[name]; edgeNames[key] == "" {
+			edgeNames[key] = name
+		}
+	}
+
+	collection := geoJSONFeatureCollection{Type: "FeatureCollection", Features: make([]geoJSONFeature, 0, len(record.Vertices)+len(record.Edges))}
+	merger := newGeoJSONMerger(resolved)
+	positions := make(map[int64][]float64, len(record.Vertices))
+	for _, vertex := range record.Vertices {
+		if merged := merger.find(vertex.Values); merged != nil {
+			positions[vertex.Key] = merged.GetValues()
+			continue
+		}
+		positions[vertex.Key] = vertex.Values
+		merger.index.Insert(vertex.Vertex())
+		properties := geoJSONProperties(vertex.Properties)
+		if vertex.Id > 0 {
+			properties[GEOJSON_PROPERTY_VERTEX_ID] = vertex.Id
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			Id:         vertexNames[vertex.Key],
+			Geometry:   geoJSONGeometry{Type: "Point", Coordinates: vertex.Values},
+			Properties: properties,
+		})
+	}
+	for _, edge := range record.Edges {
+		coordinates := [][]float64{positions[edge.From], positions[edge.To]}
+		if len(edge.Points) > 0 {
+			coordinates = append([][]float64{positions[edge.From]}, edge.Points[1:len(edge.Points)-1]...)
+			coordinates = append(coordinates, positions[edge.To])
+		}
+		properties := geoJSONProperties(edge.Properties)
+		for key, value := range edge.Cost {
+			properties[key] = value
+		}
+		if edge.Id != -1 {
+			properties[GEOJSON_PROPERTY_EDGE_ID] = edge.Id
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			Id:         edgeNames[edge.Key],
+			Geometry:   geoJSONGeometry{Type: "LineString", Coordinates: coordinates},
+			Properties: properties,
+		})
+	}
+	return json.NewEncoder(writer).Encode(collection)
+}
+
+// WritePathGeoJSON writes path, such as a route or the Path of a TSPResponse, as a FeatureCollection
+// holding one LineString through the positions of its edges. The costs of its edges are summed
+// into the properties of the feature.
+func WritePathGeoJSON(writer io.Writer, path Path) error {
+	record := NewPathRecord(path)
+	positions := make(map[int64][]float64, len(record.Vertices))
+	for _, vertex := range record.Vertices {
+		positions[vertex.Key] = vertex.Values
+	}
+	coordinates := make([][]float64, 0)
+	properties := make(map[string]any)
+	costs := make(map[string]float64)
+	for i, edge := range record.Edges {
+		if i == 0 {
+			coordinates = append(coordinates, positions[edge.From])
+		}
+		if len(edge.Points) > 2 {
+			coordinates = append(coordinates, edge.Points[1:len(edge.Points)-1]...)
+		}
+		coordinates = append(coordinates, positions[edge.To])
+		for key, value := range edge.Cost {
+			costs[key] += value
+		}
+	}
+	for key, value := range costs {
+		properties[key] = value
+	}
+	collection := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{{
+		Type:       "Feature",
+		Geometry:   geoJSONGeometry{Type: "LineString", Coordinates: coordinates},
+		Properties: properties,
+	}}}
+	return json.NewEncoder(writer).Encode(collection)
+}
+
+func geoJSONProperties(properties *Properties) map[string]any {
+	converted := make(map[string]any, properties.Len())
+	for _, key := range properties.Keys() {
+		converted[key], _ = properties.Get(key)
+	}
+	return converted
+}
+
+type geoJSONLine struct {
+	id         string
+	positions  [][]float64
+	properties map[string]any
+}
+
+type geoJSONPoint struct {
+	id         string
+	position   []float64
+	properties map[string]any
+}
+
+// ReadGeoJSON reads a FeatureCollection, a Feature or a geometry into a SimpleGraph.
+//
+// Each LineString, also inside a MultiLineString or GeometryCollection, becomes a PolyEdge between
+// vertices at its first and last positions, or a SimpleEdge when it has two positions. Endpoints
+// within the tolerance of each other share one vertex, so lines meeting at an intersection are
+// connected, while interior positions stay part of the polyline. Points become vertices too,
+// keeping the properties of their feature, and Polygons are skipped.
+//
+// Numeric feature properties of lines become edge costs and other properties become edge
+// properties, with vertex_id and edge_id giving ids. Feature ids are registered with SetVertexKey
+// and SetEdgeKey, suffixed with :<index> for the lines of a MultiLineString. Lines between the
+// same vertices make the result a multigraph.
+func ReadGeoJSON(reader io.Reader, options ...GeoJSONOptions) (*SimpleGraph, error) {
+	resolved := geoJSONOptions(options)
+	var object geoJSONObject
+	if err := json.NewDecoder(reader).Decode(&object); err != nil {
+		return nil, err
+	}
+	points := make([]geoJSONPoint, 0)
+	lines := make([]geoJSONLine, 0)
+	var collect func(object geoJSONObject, id string, properties map[string]any) error
+	collect = func(object geoJSONObject, id string, properties map[string]any) error {
+		switch object.Type {
+		case "FeatureCollection":
+			for _, feature := range object.Features {
+				if err := collect(feature, "", nil); err != nil {
+					return err
+				}
+			}
+		case "Feature":
+			if object.Geometry == nil {
+				return nil
+			}
+			if object.Id != nil {
+				id = fmt.Sprint(object.Id)
+			}
+			return collect(*object.Geometry, id, object.Properties)
+		case "GeometryCollection":
+			for i, geometry := range object.Geometries {
+				if err := collect(geometry, geoJSONPartId(id, i), properties); err != nil {
+					return err
+				}
+			}
+		case "Point":
+			var position []float64
+			if err := json.Unmarshal(object.Coordinates, &position); err != nil || len(position) < 2 {
+				return fmt.Errorf("invalid Point %s", id)
+			}
+			points = append(points, geoJSONPoint{id, position, properties})
+		case "MultiPoint":
+			var positions [][]float64
+			if err := json.Unmarshal(object.Coordinates, &positions); err != nil {
+				return fmt.Errorf("invalid MultiPoint %s", id)
+			}
+			for i, position := range positions {
+				points = append(points, geoJSONPoint{geoJSONPartId(id, i), position, properties})
+			}
+		case "LineString":
+			var positions [][]float64
+			if err := json.Unmarshal(object.Coordinates, &positions); err != nil || len(positions) < 2 {
+				return fmt.Errorf("invalid LineString %s", id)
+			}
+			lines = append(lines, geoJSONLine{id, positions, properties})
+		case "MultiLineString":
+			var parts [][][]float64
+			if err := json.Unmarshal(object.Coordinates, &parts); err != nil {
+				return fmt.Errorf("invalid MultiLineString %s", id)
+			}
+			for i, positions := range parts {
+				if len(positions) < 2 {
+					return fmt.Errorf("invalid MultiLineString %s", id)
+				}
+				lines = append(lines, geoJSONLine{geoJSONPartId(id, i), positions, properties})
+			}
+		case "Polygon", "MultiPolygon":
+		default:
+			return fmt.Errorf("%w: type %q", ErrGeoJSONUnsupported, object.Type)
+		}
+		return nil
+	}
+	if err := collect(object, "", nil); err != nil {
+		return nil, err
+	}
+
+	graph := newSimpleGraph(resolved.Directed)
+	merger := newGeoJSONMerger(resolved)
+	vertexAt := func(position []float64) *SimpleVertex {
+		if merged := merger.find(position); merged != nil {
+			return merged.(*SimpleVertex)
+		}
+		vertex := VertexRecord{Id: -1, Values: position}.Vertex()
+		graph.AddVertex(vertex)
+		merger.index.Insert(vertex)
+		return vertex
+	}
+	for _, point := range points {
+		record := VertexRecord{Id: -1, Values: point.position, Properties: NewProperties()}
+		for key, value := range point.properties {
+			if key == GEOJSON_PROPERTY_VERTEX_ID {
+				if id, ok := value.(float64); ok && id > 0 {
+					record.Id = int64(id)
+				}
+				continue
+			}
+			setGeoJSONProperty(record.Properties, key, value)
+		}
+		vertex := merger.find(point.position)
+		if vertex == nil {
+			vertex = record.Vertex()
+			if graph.ContainsVertex(vertex) {
+				return nil, fmt.Errorf("Point %s has the same vertex_id as another Point", point.id)
+			}
+			graph.AddVertex(vertex)
+			merger.index.Insert(vertex)
+		} else {
+			for _, key := range record.Properties.Keys() {
+				value, _ := record.Properties.Get(key)
+				vertex.(*SimpleVertex).Properties().set(key, value)
+			}
+		}
+		if point.id != "" {
+			graph.SetVertexKey(point.id, vertex)
+		}
+	}
+
+	type geoJSONEdge struct {
+		id       string
+		from, to *SimpleVertex
+		record   EdgeRecord
+	}
+	edges := make([]geoJSONEdge, 0, len(lines))
+	pairs := make(map[[2]int64]bool)
+	for _, line := range lines {
+		from, to := vertexAt(line.positions[0]), vertexAt(line.positions[len(line.positions)-1])
+		record := EdgeRecord{Id: -1, Cost: make(map[string]float64), Properties: NewProperties()}
+		if len(line.positions) > 2 {
+			record.Points = line.positions
+		}
+		for key, value := range line.properties {
+			number, numeric := value.(float64)
+			switch {
+			case key == GEOJSON_PROPERTY_EDGE_ID && numeric:
+				record.Id = int64(number)
+			case numeric && (resolved.CostProperties == nil || slices.Contains(resolved.CostProperties, key)):
+				record.Cost[key] = number
+			default:
+				setGeoJSONProperty(record.Properties, key, value)
+			}
+		}
+		if len(record.Cost) == 0 {
+			record.Cost = nil
+		}
+		if record.Properties.Len() == 0 {
+			record.Properties = nil
+		}
+		pair := [2]int64{VertexHashOrId(from), VertexHashOrId(to)}
+		if !resolved.Directed && pair[1] < pair[0] {
+			pair = [2]int64{pair[1], pair[0]}
+		}
+		graph.multi = graph.multi || pairs[pair]
+		pairs[pair] = true
+		edges = append(edges, geoJSONEdge{line.id, from, to, record})
+	}
+	records := make([]*EdgeRecord, len(edges))
+	for i := range edges {
+		records[i] = &edges[i].record
+	}
+	assignEdgeIds(graph, records)
+	for _, edge := range edges {
+		created := edge.record.Edge(edge.from, edge.to)
+		graph.AddEdge(created)
+		if edge.id != "" {
+			graph.SetEdgeKey(edge.id, created)
+		}
+	}
+	return graph, nil
+}
+
+func geoJSONPartId(id string, index int) string {
+	if id == "" {
+		return ""
+	}
+	return id + ":" + strconv.Itoa(index)
+}
+
+// setGeoJSONProperty stores strings, booleans and numbers as they are and objects and arrays as
+// JSON text, skipping null.
+func setGeoJSONProperty(properties *Properties, key string, value any) {
+	switch typed := value.(type) {
+	case nil:
+	case string, bool, float64:
+		properties.set(key, typed)
+	default:
+		encoded, _ := json.Marshal(typed)
+		properties.SetString(key, string(encoded))
+	}
+}
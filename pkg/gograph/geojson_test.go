@@ -0,0 +1,182 @@
+package gograph
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"strings"
+	"testing"
+)
+
+func buildGeoJSONTestGraph() (*SimpleGraph, []*SimpleVertex) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertexWithId(gomath.Point{Values: []float64{-0.1276, 51.5072}}, 1)
+	b := NewSimpleVertex(gomath.Point{Values: []float64{-0.1200, 51.5100}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{-0.1100, 51.5050}})
+	a.Properties().SetString("name", "Charing Cross")
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_TIME: 60.0}))
+	graph.AddEdge(NewPolyEdge([]gomath.Spatial{&b, gomath.Point{Values: []float64{-0.1150, 51.5110}}, &c}, 7))
+	edge := NewSimpleEdge(&c, &a, -1)
+	edge.Properties().SetString("highway", "primary")
+	graph.AddEdge(edge)
+	graph.SetVertexKey("station", &a)
+	graph.SetEdgeKey("bridge", GetEdge(&b, &c))
+	return graph, []*SimpleVertex{&a, &b, &c}
+}
+
+func TestGeoJSON_RoundTrip(t *testing.T) {
+	graph, _ := buildGeoJSONTestGraph()
+	var buffer bytes.Buffer
+	if err := WriteGeoJSON(&buffer, graph); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadGeoJSON(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.IsDirected() || decoded.Hash() != graph.Hash() || !decoded.Validate().IsValid() {
+		t.Error("Expected the decoded graph to equal the graph")
+	}
+	if diff := DiffGraphs(graph, decoded); !diff.IsEmpty() {
+		t.Errorf("Expected no difference, got %+v", diff)
+	}
+	equalAdjacency(t, graph, decoded)
+	if decoded.GetVertexByKey("station") == nil || decoded.GetEdgeByKey("bridge").Id() != 7 {
+		t.Error("Expected the feature ids to become keys")
+	}
+}
+
+const geoJSONRoads = `{
+  "type": "FeatureCollection",
+  "features": [
+    {"type": "Feature", "id": "main", "geometry": {"type": "LineString", "coordinates": [[0, 0], [0.001, 0.0005], [0.002, 0]]},
+     "properties": {"time": 30, "lanes": 2, "name": "Main St", "tags": {"surface": "asphalt"}}},
+    {"type": "Feature", "id": 12, "geometry": {"type": "LineString", "coordinates": [[0.0020000001, 0.0000000001], [0.002, 0.001]]},
+     "properties": {"time": 10}},
+    {"type": "Feature", "id": "loop", "geometry": {"type": "MultiLineString", "coordinates": [
+      [[0.002, 0.001], [0.003, 0.001]], [[0.003, 0.001], [0.003, 0.002], [0.002, 0.001]]]}, "properties": {"time": 5}},
+    {"type": "Feature", "id": "school", "geometry": {"type": "Point", "coordinates": [0.00000001, 0]}, "properties": {"name": "School"}},
+    {"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}, "properties": {}},
+    {"type": "Feature", "geometry": null, "properties": {}}
+  ]
+}`
+
+func TestReadGeoJSON(t *testing.T) {
+	graph, err := ReadGeoJSON(strings.NewReader(geoJSONRoads), GeoJSONOptions{Tolerance: 0.5, CostProperties: []string{COST_TYPE_TIME}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graph.Size() != 4 || !graph.IsMultiGraph() {
+		t.Fatalf("Expected 4 intersections in a multigraph, got %d", graph.Size())
+	}
+	school := graph.GetVertexByKey("school")
+	if name, _ := GetProperties(school).GetString("name"); name != "School" || school.X() != 0.00000001 {
+		t.Error("Expected the Point to become a vertex with its properties")
+	}
+	main := graph.GetEdgeByKey("main")
+	polyEdge, ok := main.(PolyEdge)
+	if !ok || len(polyEdge.Points) != 3 || ToVertex(main.From()) != school {
+		t.Fatal("Expected the LineString to become a PolyEdge starting at the merged Point")
+	}
+	if (*main.Cost())[COST_TYPE_TIME] != 30.0 || len(*main.Cost()) != 1 {
+		t.Errorf("Expected only time to be a cost, got %v", *main.Cost())
+	}
+	properties := GetProperties(main)
+	if lanes, _ := properties.GetFloat("lanes"); lanes != 2.0 {
+		t.Error("Expected lanes to be a property")
+	}
+	if tags, _ := properties.GetString("tags"); tags != `{"surface":"asphalt"}` {
+		t.Errorf("Expected nested properties as JSON, got %q", tags)
+	}
+	if ToVertex(graph.GetEdgeByKey("12").From()) != ToVertex(main.To()) {
+		t.Error("Expected nearly coincident endpoints to be merged")
+	}
+	first, second := graph.GetEdgeByKey("loop:0"), graph.GetEdgeByKey("loop:1")
+	if first == nil || second == nil || first.Id() == second.Id() {
+		t.Error("Expected each line of the MultiLineString to become a parallel edge")
+	}
+
+	exact, err := ReadGeoJSON(strings.NewReader(geoJSONRoads))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exact.Size() != 6 {
+		t.Errorf("Expected no merging without a tolerance, got %d vertices", exact.Size())
+	}
+	if _, err := ReadGeoJSON(strings.NewReader(`{"type": "Topology"}`)); !errors.Is(err, ErrGeoJSONUnsupported) {
+		t.Errorf("Expected an unsupported type error, got %v", err)
+	}
+	if _, err := ReadGeoJSON(strings.NewReader(`{"type": "LineString", "coordinates": [[0, 0]]}`)); err == nil {
+		t.Error("Expected an error for a LineString with one position")
+	}
+
+	ids, err := ReadGeoJSON(strings.NewReader(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"time": 5}, "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 0]]}},
+		{"type": "Feature", "properties": {"edge_id": 1, "time": 7}, "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 0]]}}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids.GetEdges()) != 2 || ids.GetEdge(1) == nil || (*ids.GetEdge(1).Cost())[COST_TYPE_TIME] != 7.0 {
+		t.Errorf("Expected a generated id not to take the explicit id 1, got %d edges", len(ids.GetEdges()))
+	}
+}
+
+func TestWriteGeoJSON_Tolerance(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{0.001, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{0.0010000001, 0.0}})
+	d := NewSimpleVertex(gomath.Point{Values: []float64{0.002, 0.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	graph.AddEdge(NewSimpleEdge(&c, &d, -1))
+
+	var buffer bytes.Buffer
+	if err := WriteGeoJSON(&buffer, graph, GeoJSONOptions{Tolerance: 0.1}); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadGeoJSON(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Size() != 3 || len(decoded.GetEdges()) != 4 {
+		t.Errorf("Expected the nearly coincident endpoints to be written as one, got %d vertices", decoded.Size())
+	}
+}
+
+func TestWritePathGeoJSON(t *testing.T) {
+	graph, vertices := buildGeoJSONTestGraph()
+	path := NewSimplePath([]Edge{GetEdge(vertices[0], vertices[1]), GetEdge(vertices[1], vertices[2])})
+	var buffer bytes.Buffer
+	if err := WritePathGeoJSON(&buffer, path); err != nil {
+		t.Fatal(err)
+	}
+	var collection struct {
+		Features []struct {
+			Geometry struct {
+				Type        string      `json:"type"`
+				Coordinates [][]float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]float64 `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+	if len(collection.Features) != 1 || len(collection.Features[0].Geometry.Coordinates) != 4 {
+		t.Fatal("Expected one LineString through the 4 positions of the path")
+	}
+	if collection.Features[0].Properties[COST_TYPE_TIME] != 60.0 {
+		t.Error("Expected the costs of the path to be summed")
+	}
+
+	buffer.Reset()
+	tour := GreedyTSP(TSPRequest{Graph: graph}).Path
+	if err := WritePathGeoJSON(&buffer, tour); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), `"LineString"`) {
+		t.Error("Expected the tour as a LineString")
+	}
+}
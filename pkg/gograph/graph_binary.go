@@ -0,0 +1,550 @@
+package gograph
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"hash/crc32"
+	"io"
+	"maps"
+	"math"
+	"slices"
+)
+
+// BINARY_MAGIC starts every file written by WriteGraphBinary, and BINARY_VERSION is the version of
+// the format it writes.
+const (
+	BINARY_MAGIC   = "GOGRAPH"
+	BINARY_VERSION = 1
+)
+
+var (
+	ErrBinaryFormat   = errors.New("invalid binary graph")
+	ErrBinaryVersion  = errors.New("unsupported binary graph version")
+	ErrBinaryChecksum = errors.New("binary graph checksum mismatch")
+)
+
+const (
+	binaryFlagDirected = 1 << iota
+	binaryFlagMulti
+)
+
+const (
+	binaryPropertyString = iota
+	binaryPropertyInt
+	binaryPropertyBool
+	binaryPropertyFloat
+)
+
+// WriteGraphBinary encodes any Graph in the compact binary form read by ReadGraphBinary: the
+// graph after BINARY_MAGIC and a version byte, followed by the little endian CRC-32 (IEEE) of
+// everything before it. Counts and integers are varints, and coordinates and costs little endian
+// float64s:
+//
+//	flags, graph id
+//	strings: every cost type, property key, string property and key of the graph, once each
+//	vertices: id, values, properties
+//	edges: id, from and to as vertex indexes, interior polyline points, cost map, properties
+//	vertex keys and edge keys: string index, vertex or edge index
+//
+// Edges refer to vertices by index, so the Key hashes of VertexRecord and EdgeRecord are not stored. The graph is encoded
+// directly, without building its GraphRecord. It fails when an edge refers to a vertex that is not
+// in the graph.
+func WriteGraphBinary(writer io.Writer, graph Graph) error {
+	encoder := &binaryEncoder{strings: binaryStrings{indexes: make(map[string]int)}}
+	body, err := encoder.appendGraph(make([]byte, 0, 64*(graph.Size()+1)), graph)
+	if err != nil {
+		return err
+	}
+	header := append([]byte(BINARY_MAGIC), BINARY_VERSION)
+	flags := byte(0)
+	if graph.IsDirected() {
+		flags |= binaryFlagDirected
+	}
+	if multiGraph, ok := graph.(interface{ IsMultiGraph() bool }); ok && multiGraph.IsMultiGraph() {
+		flags |= binaryFlagMulti
+	}
+	header = append(header, flags)
+	header = binary.AppendVarint(header, graph.Id())
+	header = encoder.strings.append(header)
+
+	checksum := crc32.NewIEEE()
+	output := bufio.NewWriter(writer)
+	for _, data := range [][]byte{header, body} {
+		_, _ = checksum.Write(data)
+		_, _ = output.Write(data)
+	}
+	_, _ = output.Write(binary.LittleEndian.AppendUint32(nil, checksum.Sum32()))
+	return output.Flush()
+}
+
+// ReadGraphBinary decodes a graph written by WriteGraphBinary. It reads the whole input and
+// checks its checksum before decoding, so a corrupt or truncated file fails with ErrBinaryFormat,
+// ErrBinaryVersion or ErrBinaryChecksum instead of producing a wrong graph.
+func ReadGraphBinary(reader io.Reader) (*SimpleGraph, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return decodeGraphBinary(data)
+}
+
+func (g *SimpleGraph) MarshalBinary() ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := WriteGraphBinary(&buffer, g); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the contents of g with the decoded graph. Listeners are kept but not
+// told about the change.
+func (g *SimpleGraph) UnmarshalBinary(data []byte) error {
+	decoded, err := decodeGraphBinary(data)
+	if err != nil {
+		return err
+	}
+	decoded.listeners = g.listeners
+	*g = *decoded
+	return nil
+}
+
+// binaryStrings numbers the strings of a graph in the order they are first seen.
+type binaryStrings struct {
+	indexes map[string]int
+	values  []string
+}
+
+func (s *binaryStrings) index(value string) int {
+	index, ok := s.indexes[value]
+	if !ok {
+		index = len(s.values)
+		s.indexes[value] = index
+		s.values = append(s.values, value)
+	}
+	return index
+}
+
+func (s *binaryStrings) append(data []byte) []byte {
+	data = binary.AppendUvarint(data, uint64(len(s.values)))
+	for _, value := range s.values {
+		data = binary.AppendUvarint(data, uint64(len(value)))
+		data = append(data, value...)
+	}
+	return data
+}
+
+// binaryEncoder encodes the vertices, edges and keys of a graph, collecting the strings they use
+// so that the string table can be written before them.
+type binaryEncoder struct {
+	strings   binaryStrings
+	costTypes []string
+}
+
+// binaryVertexSize and binaryEdgeSize are the smallest encoded sizes of a vertex and an edge, which
+// the decoder assumes when it checks their counts against the remaining data.
+const (
+	binaryVertexSize = 3
+	binaryEdgeSize   = 6
+)
+
+func (e *binaryEncoder) appendGraph(data []byte, graph Graph) ([]byte, error) {
+	vertices := graph.GetVertices()
+	vertexIndexes := make(map[int64]int, len(vertices))
+	data = binary.AppendUvarint(data, uint64(len(vertices)))
+	for i, vertex := range vertices {
+		vertexIndexes[VertexHashOrId(vertex)] = i
+		data = binary.AppendVarint(data, vertex.Id())
+		data = appendBinaryValues(data, vertex.GetValues())
+		data = e.appendProperties(data, GetProperties(vertex))
+	}
+
+	edges := binaryGraphEdges(graph)
+	data = binary.AppendUvarint(data, uint64(len(edges)))
+	for _, edge := range edges {
+		fromIndex, fromOk := vertexIndexes[VertexHashOrId(ToVertex(edge.From()))]
+		toIndex, toOk := vertexIndexes[VertexHashOrId(ToVertex(edge.To()))]
+		if !fromOk || !toOk {
+			return nil, fmt.Errorf("edge %d refers to a vertex that is not in the graph", EdgeHashOrId(edge))
+		}
+		data = binary.AppendVarint(data, edge.Id())
+		data = binary.AppendUvarint(data, uint64(fromIndex))
+		data = binary.AppendUvarint(data, uint64(toIndex))
+		inner := edge
+		if wrapper, ok := edge.(interface{ Inner() Edge }); ok {
+			inner = wrapper.Inner()
+		}
+		if polyEdge, ok := inner.(PolyEdge); ok && len(polyEdge.Points) > 2 {
+			data = binary.AppendUvarint(data, uint64(len(polyEdge.Points)-2))
+			for _, point := range polyEdge.Points[1 : len(polyEdge.Points)-1] {
+				data = appendBinaryValues(data, point.GetValues())
+			}
+		} else {
+			data = append(data, 0)
+		}
+		data = e.appendCost(data, inner.Cost())
+		data = e.appendProperties(data, GetProperties(inner))
+	}
+
+	simpleGraph, ok := graph.(*SimpleGraph)
+	if !ok {
+		return append(data, 0, 0), nil
+	}
+	data = e.appendKeys(data, simpleGraph.vertexKeys, vertexIndexes)
+	edgeIndexes := make(map[int64]int)
+	if len(simpleGraph.edgeKeys) > 0 {
+		for i, edge := range edges {
+			edgeIndexes[EdgeHashOrId(edge)] = i
+		}
+	}
+	return e.appendKeys(data, simpleGraph.edgeKeys, edgeIndexes), nil
+}
+
+// binaryGraphEdges lists the edges of graph in the order of the adjacency of its vertices, like
+// NewGraphRecord does, with an undirected edge once in the direction it is stored in.
+func binaryGraphEdges(graph Graph) []Edge {
+	edges := make([]Edge, 0)
+	if graph.IsDirected() {
+		for _, vertex := range graph.GetVertices() {
+			edges = append(edges, vertex.GetEdges()...)
+		}
+		return edges
+	}
+	stored := make(map[int64]Edge)
+	for _, edge := range graph.GetEdges() {
+		stored[EdgeHashOrId(edge)] = edge
+	}
+	recorded := make(map[int64]bool)
+	for _, vertex := range graph.GetVertices() {
+		for _, edge := range vertex.GetEdges() {
+			key := EdgeHashOrId(edge)
+			if recorded[key] || recorded[EdgeHashOrId(edge.Reverse())] {
+				continue
+			}
+			recorded[key] = true
+			if forward, ok := stored[key]; ok {
+				edge = forward
+			}
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+func (e *binaryEncoder) appendCost(data []byte, cost *map[string]float64) []byte {
+	if cost == nil || len(*cost) == 0 {
+		return append(data, 0)
+	}
+	e.costTypes = e.costTypes[:0]
+	for costType := range *cost {
+		e.costTypes = append(e.costTypes, costType)
+	}
+	slices.Sort(e.costTypes)
+	data = binary.AppendUvarint(data, uint64(len(e.costTypes)))
+	for _, costType := range e.costTypes {
+		data = binary.AppendUvarint(data, uint64(e.strings.index(costType)))
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits((*cost)[costType]))
+	}
+	return data
+}
+
+// appendKeys writes the keys whose vertex or edge is in indexes, in sorted order.
+func (e *binaryEncoder) appendKeys(data []byte, keys map[string]int64, indexes map[int64]int) []byte {
+	names := make([]string, 0, len(keys))
+	for _, name := range slices.Sorted(maps.Keys(keys)) {
+		if _, ok := indexes[keys[name]]; ok {
+			names = append(names, name)
+		}
+	}
+	data = binary.AppendUvarint(data, uint64(len(names)))
+	for _, name := range names {
+		data = binary.AppendUvarint(data, uint64(e.strings.index(name)))
+		data = binary.AppendUvarint(data, uint64(indexes[keys[name]]))
+	}
+	return data
+}
+
+func appendBinaryValues(data []byte, values []float64) []byte {
+	data = binary.AppendUvarint(data, uint64(len(values)))
+	for _, value := range values {
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(value))
+	}
+	return data
+}
+
+func (e *binaryEncoder) appendProperties(data []byte, properties *Properties) []byte {
+	if properties.Len() == 0 {
+		return append(data, 0)
+	}
+	keys := properties.Keys()
+	data = binary.AppendUvarint(data, uint64(len(keys)))
+	for _, key := range keys {
+		data = binary.AppendUvarint(data, uint64(e.strings.index(key)))
+		value, _ := properties.Get(key)
+		switch v := value.(type) {
+		case string:
+			data = append(data, binaryPropertyString)
+			data = binary.AppendUvarint(data, uint64(e.strings.index(v)))
+		case int64:
+			data = append(data, binaryPropertyInt)
+			data = binary.AppendVarint(data, v)
+		case bool:
+			data = append(data, binaryPropertyBool)
+			if v {
+				data = append(data, 1)
+			} else {
+				data = append(data, 0)
+			}
+		case float64:
+			data = append(data, binaryPropertyFloat)
+			data = binary.LittleEndian.AppendUint64(data, math.Float64bits(v))
+		}
+	}
+	return data
+}
+
+// binaryDecoder reads the body of a binary graph. The first problem it finds is kept in err, and
+// every later read returns zero values, so decoding code checks err once per record.
+type binaryDecoder struct {
+	data    []byte
+	offset  int
+	strings []string
+	err     error
+}
+
+func (d *binaryDecoder) fail(format string, args ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: byte %d: %s", ErrBinaryFormat, d.offset, fmt.Sprintf(format, args...))
+	}
+}
+
+func (d *binaryDecoder) byte() byte {
+	if d.err != nil || d.offset >= len(d.data) {
+		d.fail("unexpected end of data")
+		return 0
+	}
+	d.offset++
+	return d.data[d.offset-1]
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(d.data[d.offset:])
+	if n <= 0 {
+		d.fail("invalid varint")
+		return 0
+	}
+	d.offset += n
+	return value
+}
+
+func (d *binaryDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	value, n := binary.Varint(d.data[d.offset:])
+	if n <= 0 {
+		d.fail("invalid varint")
+		return 0
+	}
+	d.offset += n
+	return value
+}
+
+func (d *binaryDecoder) float() float64 {
+	if d.err != nil || len(d.data)-d.offset < 8 {
+		d.fail("unexpected end of data")
+		return 0
+	}
+	d.offset += 8
+	return math.Float64frombits(binary.LittleEndian.Uint64(d.data[d.offset-8:]))
+}
+
+// count reads the length of a list whose items take at least size bytes each, rejecting lengths
+// the remaining data cannot hold before anything is allocated for them.
+func (d *binaryDecoder) count(size int) int {
+	value := d.uvarint()
+	if d.err == nil && value > uint64((len(d.data)-d.offset)/size) {
+		d.fail("count %d exceeds the data", value)
+		return 0
+	}
+	return int(value)
+}
+
+// index reads an index into a list of length n.
+func (d *binaryDecoder) index(n int) int {
+	value := d.uvarint()
+	if d.err == nil && value >= uint64(n) {
+		d.fail("index %d out of range", value)
+		return 0
+	}
+	return int(value)
+}
+
+func (d *binaryDecoder) string() string {
+	index := d.index(len(d.strings))
+	if d.err != nil {
+		return ""
+	}
+	return d.strings[index]
+}
+
+func (d *binaryDecoder) values() []float64 {
+	values := make([]float64, d.count(8))
+	for i := range values {
+		values[i] = d.float()
+	}
+	return values
+}
+
+func (d *binaryDecoder) properties() *Properties {
+	n := d.count(3)
+	if n == 0 {
+		return nil
+	}
+	properties := NewProperties()
+	for i := 0; i < n && d.err == nil; i++ {
+		key := d.string()
+		switch kind := d.byte(); kind {
+		case binaryPropertyString:
+			properties.SetString(key, d.string())
+		case binaryPropertyInt:
+			properties.SetInt(key, d.varint())
+		case binaryPropertyBool:
+			properties.SetBool(key, d.byte() != 0)
+		case binaryPropertyFloat:
+			properties.SetFloat(key, d.float())
+		default:
+			d.fail("unknown property type %d", kind)
+		}
+	}
+	return properties
+}
+
+// decodeGraphBinary builds the graph directly rather than through a GraphRecord. Edges are added
+// without the parallel edge search of AddEdge, after a lookup of their key that rejects an edge
+// the file repeats.
+func decodeGraphBinary(data []byte) (*SimpleGraph, error) {
+	header := len(BINARY_MAGIC) + 1
+	if len(data) < header+4 || string(data[:len(BINARY_MAGIC)]) != BINARY_MAGIC {
+		return nil, fmt.Errorf("%w: missing header", ErrBinaryFormat)
+	}
+	if version := data[len(BINARY_MAGIC)]; version != BINARY_VERSION {
+		return nil, fmt.Errorf("%w: %d", ErrBinaryVersion, version)
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return nil, ErrBinaryChecksum
+	}
+
+	d := &binaryDecoder{data: body, offset: header}
+	flags := d.byte()
+	graph := newSimpleGraph(flags&binaryFlagDirected != 0)
+	graph.multi = flags&binaryFlagMulti != 0
+	graph.id = d.varint()
+	d.strings = make([]string, d.count(1))
+	for i := range d.strings {
+		length := d.count(1)
+		if d.err != nil {
+			break
+		}
+		d.strings[i] = string(d.data[d.offset : d.offset+length])
+		d.offset += length
+	}
+
+	vertices := make([]*SimpleVertex, d.count(binaryVertexSize))
+	graph.vertices = make(map[int64]Vertex, len(vertices))
+	graph.incoming = make(map[int64]map[int64]Edge, len(vertices))
+	for i := range vertices {
+		id := d.varint()
+		vertices[i] = &SimpleVertex{Spatial: gomath.Point{Values: d.values()}, Edges: []Edge{}, id: id, hash: -1}
+		vertices[i].properties = d.properties()
+		if d.err != nil {
+			return nil, d.err
+		}
+		if id <= 0 {
+			vertices[i].id = -1
+		}
+		if graph.ContainsVertex(vertices[i]) {
+			return nil, fmt.Errorf("%w: vertex %d is a duplicate", ErrBinaryFormat, i)
+		}
+		graph.AddVertex(vertices[i])
+	}
+
+	edges := make([]Edge, d.count(binaryEdgeSize))
+	graph.edges = make(map[int64]Edge, 2*len(edges))
+	for i := range edges {
+		id := d.varint()
+		fromIndex, toIndex := d.index(len(vertices)), d.index(len(vertices))
+		if d.err != nil {
+			return nil, d.err
+		}
+		from, to := vertices[fromIndex], vertices[toIndex]
+		var points []gomath.Spatial
+		if interior := d.count(1); interior > 0 {
+			points = make([]gomath.Spatial, interior+2)
+			points[0], points[interior+1] = from, to
+			for j := 1; j <= interior; j++ {
+				points[j] = gomath.Point{Values: d.values()}
+			}
+		}
+		var cost *map[string]float64
+		if costs := d.count(9); costs > 0 {
+			decoded := make(map[string]float64, costs)
+			for j := 0; j < costs; j++ {
+				costType := d.string()
+				decoded[costType] = d.float()
+			}
+			cost = &decoded
+		}
+		properties := d.properties()
+		if d.err != nil {
+			return nil, d.err
+		}
+		if points == nil {
+			edge := NewSimpleEdge(from, to, id, cost)
+			if properties != nil {
+				edge.properties = properties
+			}
+			edges[i] = edge
+		} else {
+			edge := NewPolyEdge(points, id)
+			edge.cost = cost
+			if properties != nil {
+				edge.properties = properties
+			}
+			edges[i] = edge
+		}
+		if (!graph.multi || id != -1) && graph.ContainsEdge(edges[i]) {
+			return nil, fmt.Errorf("%w: edge %d is a duplicate", ErrBinaryFormat, i)
+		}
+		graph.appendEdge(edges[i])
+	}
+
+	for n, i := d.count(2), 0; i < n; i++ {
+		key, index := d.string(), d.index(len(vertices))
+		if d.err != nil {
+			return nil, d.err
+		}
+		graph.SetVertexKey(key, vertices[index])
+	}
+	for n, i := d.count(2), 0; i < n; i++ {
+		key, index := d.string(), d.index(len(edges))
+		if d.err != nil {
+			return nil, d.err
+		}
+		graph.SetEdgeKey(key, edges[index])
+	}
+	if d.err == nil && d.offset != len(d.data) {
+		d.fail("%d trailing bytes", len(d.data)-d.offset)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return graph, nil
+}
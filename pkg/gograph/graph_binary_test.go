@@ -0,0 +1,213 @@
+package gograph
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"hash/crc32"
+	"io"
+	"maps"
+	"testing"
+)
+
+func TestGraphBinary_RoundTrip(t *testing.T) {
+	graph, _ := buildGeoJSONTestGraph()
+	vertex := graph.GetVertexByKey("station")
+	GetProperties(vertex).SetInt("platforms", 6)
+	GetProperties(vertex).SetBool("staffed", true)
+	GetProperties(vertex).SetFloat("rating", 4.5)
+
+	var buffer bytes.Buffer
+	if err := WriteGraphBinary(&buffer, graph); err != nil {
+		t.Fatal(err)
+	}
+	var jsonBuffer bytes.Buffer
+	if err := WriteGraphJSON(&jsonBuffer, graph); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.Len() >= jsonBuffer.Len()/2 {
+		t.Errorf("Expected the binary form to be compact, got %d bytes against %d of JSON", buffer.Len(), jsonBuffer.Len())
+	}
+	decoded, err := ReadGraphBinary(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Id() != graph.Id() || decoded.IsDirected() || decoded.Hash() != graph.Hash() || !decoded.Validate().IsValid() {
+		t.Error("Expected the decoded graph to equal the graph")
+	}
+	if diff := DiffGraphs(graph, decoded); !diff.IsEmpty() {
+		t.Errorf("Expected no difference, got %+v", diff)
+	}
+	equalAdjacency(t, graph, decoded)
+	properties := GetProperties(decoded.GetVertexByKey("station"))
+	if !properties.Equals(GetProperties(vertex)) || properties.Type("platforms") != PROPERTY_TYPE_INT {
+		t.Error("Expected typed vertex properties to round trip")
+	}
+	if bridge, ok := decoded.GetEdgeByKey("bridge").(PolyEdge); !ok || bridge.Id() != 7 || len(bridge.Points) != 3 {
+		t.Error("Expected the keyed PolyEdge to round trip")
+	}
+}
+
+func TestSimpleGraph_MarshalBinary(t *testing.T) {
+	graph := NewDirectedMultiGraph()
+	a := NewSimpleVertexWithId(gomath.Point{Values: []float64{0.0, 0.0}}, 1)
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0, 2.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_DISTANCE: 5.0, COST_TYPE_TIME: 1.5}))
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_DISTANCE: 2.0}))
+	graph.AddEdge(NewSimpleEdge(&b, &a, -1))
+
+	data, err := graph.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := NewSimpleGraph()
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.IsDirected() || !decoded.IsMultiGraph() || len(decoded.GetEdges()) != 3 || !DiffGraphs(graph, decoded).IsEmpty() {
+		t.Error("Expected the directed multigraph to round trip")
+	}
+	equalAdjacency(t, graph, decoded)
+
+	graph.SetVertexKey("a", &a)
+	graph.SetVertexKey("gone", VertexFromSpatial(gomath.Point{Values: []float64{9.0, 9.0}}))
+	if data, err = graph.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.GetVertexByKey("a") == nil || decoded.GetVertexByKey("gone") != nil {
+		t.Error("Expected only the keys of vertices in the graph to be written")
+	}
+}
+
+func TestReadGraphBinary_Corrupt(t *testing.T) {
+	graph, _ := buildGeoJSONTestGraph()
+	data, err := graph.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resign := func(body []byte) []byte {
+		return binary.LittleEndian.AppendUint32(body, crc32.ChecksumIEEE(body))
+	}
+
+	flipped := bytes.Clone(data)
+	flipped[len(flipped)/2] ^= 0xFF
+	if _, err := ReadGraphBinary(bytes.NewReader(flipped)); !errors.Is(err, ErrBinaryChecksum) {
+		t.Errorf("Expected a checksum error, got %v", err)
+	}
+	if _, err := ReadGraphBinary(bytes.NewReader(data[:len(data)-1])); err == nil {
+		t.Error("Expected an error for a truncated file")
+	}
+	if _, err := ReadGraphBinary(bytes.NewReader([]byte("GRAPH"))); !errors.Is(err, ErrBinaryFormat) {
+		t.Errorf("Expected a format error, got %v", err)
+	}
+	future := bytes.Clone(data)
+	future[len(BINARY_MAGIC)] = BINARY_VERSION + 1
+	if _, err := ReadGraphBinary(bytes.NewReader(future)); !errors.Is(err, ErrBinaryVersion) {
+		t.Errorf("Expected a version error, got %v", err)
+	}
+
+	// Damage that slips past the checksum must still be reported rather than panic.
+	body := data[:len(data)-4]
+	for i := len(BINARY_MAGIC) + 1; i < len(body); i++ {
+		for _, value := range []byte{0x00, 0x7F, 0xFF} {
+			damaged := bytes.Clone(body)
+			damaged[i] = value
+			_, _ = ReadGraphBinary(bytes.NewReader(resign(damaged)))
+		}
+		if _, err := ReadGraphBinary(bytes.NewReader(resign(bytes.Clone(body[:i])))); !errors.Is(err, ErrBinaryFormat) {
+			t.Errorf("Expected a format error for a body cut at byte %d, got %v", i, err)
+		}
+	}
+}
+
+func TestReadGraphBinary_DuplicateEdge(t *testing.T) {
+	graph := NewDirectedMultiGraph()
+	a := NewSimpleVertexWithId(gomath.Point{Values: []float64{0.0, 0.0}}, 1)
+	c := NewSimpleVertexWithId(gomath.Point{Values: []float64{1.0, 0.0}}, 2)
+	graph.AddEdge(NewSimpleEdge(&a, &c, 5))
+	graph.AddEdge(NewSimpleEdge(&a, &c, 6))
+	data, err := graph.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadGraphBinary(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Give the second edge the id of the first one, from vertex index 0 to vertex index 1.
+	body := data[:len(data)-4]
+	repeated := bytes.Replace(body, []byte{0x0C, 0x00, 0x01}, []byte{0x0A, 0x00, 0x01}, 1)
+	if bytes.Equal(repeated, body) {
+		t.Fatal("Expected to find the second edge")
+	}
+	repeated = binary.LittleEndian.AppendUint32(repeated, crc32.ChecksumIEEE(repeated))
+	if _, err := ReadGraphBinary(bytes.NewReader(repeated)); !errors.Is(err, ErrBinaryFormat) {
+		t.Errorf("Expected a format error for a repeated edge, got %v", err)
+	}
+}
+
+// buildBenchmarkGraph builds a directed grid road network with size*size vertices and two costs on
+// each of its arcs, like a DIMACS road graph.
+func buildBenchmarkGraph(size int) *SimpleGraph {
+	graph := NewDirectedSimpleGraph()
+	vertices := make([]Vertex, size*size)
+	for i := range vertices {
+		vertex := NewSimpleVertexWithId(gomath.Point{Values: []float64{float64(i % size), float64(i / size)}}, int64(i+1))
+		vertices[i] = &vertex
+	}
+	for i, vertex := range vertices {
+		for _, j := range []int{i + 1, i + size} {
+			if j >= len(vertices) || (j == i+1 && j%size == 0) {
+				continue
+			}
+			cost := map[string]float64{COST_TYPE_DISTANCE: float64(j - i), COST_TYPE_TIME: float64(i % 7)}
+			reverse := maps.Clone(cost)
+			graph.appendEdge(NewSimpleEdge(vertex, vertices[j], -1, &cost))
+			graph.appendEdge(NewSimpleEdge(vertices[j], vertex, -1, &reverse))
+		}
+	}
+	return graph
+}
+
+// BenchmarkWriteGraph compares WriteGraphBinary with WriteGraphJSON on a graph of about 40000
+// arcs. The binary form should be written several times faster.
+func BenchmarkWriteGraph(b *testing.B) {
+	graph := buildBenchmarkGraph(100)
+	for name, write := range map[string]func(io.Writer, Graph) error{"binary": WriteGraphBinary, "json": WriteGraphJSON} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := write(io.Discard, graph); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkReadGraphBinary and BenchmarkReadGraphJSON read the graph of BenchmarkWriteGraph back
+// from memory.
+func BenchmarkReadGraphBinary(b *testing.B) {
+	benchmarkReadGraph(b, WriteGraphBinary, ReadGraphBinary)
+}
+
+func BenchmarkReadGraphJSON(b *testing.B) {
+	benchmarkReadGraph(b, WriteGraphJSON, ReadGraphJSON)
+}
+
+func benchmarkReadGraph(b *testing.B, write func(io.Writer, Graph) error, read func(io.Reader) (*SimpleGraph, error)) {
+	var buffer bytes.Buffer
+	if err := write(&buffer, buildBenchmarkGraph(100)); err != nil {
+		b.Fatal(err)
+	}
+	data := buffer.Bytes()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := read(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package gograph
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	GRAPH_CHANGE_ADD_VERTEX    = "add_vertex"
+	GRAPH_CHANGE_REMOVE_VERTEX = "remove_vertex"
+	GRAPH_CHANGE_ADD_EDGE      = "add_edge"
+	GRAPH_CHANGE_REMOVE_EDGE   = "remove_edge"
+	GRAPH_CHANGE_SET_COST      = "set_cost"
+	GRAPH_CHANGE_REMOVE_COST   = "remove_cost"
+)
+
+// ErrUnsupportedGraphChange is returned for a change of an unknown type, or a cost change applied to
+// a graph whose costs cannot be changed.
+var ErrUnsupportedGraphChange = errors.New("unsupported graph change")
+
+// GraphChange describes a single mutation of a graph. Vertex is set for vertex changes and Edge
+// for edge and cost changes. A cost change also carries the cost Key, the new Value and the
+// Previous value, which is only meaningful when HasPrevious is true. Removing a cost sets Key and
+// Previous.
+type GraphChange struct {
+	Type        string
+	Vertex      Vertex
+	Edge        Edge
+	Key         string
+	Value       float64
+	Previous    float64
+	HasPrevious bool
+}
+
+type GraphUpdateListener interface {
+	Update(change GraphChange)
+}
+
+func VisitGraphUpdateListeners(listeners []GraphUpdateListener, change GraphChange) {
+	for _, listener := range listeners {
+		listener.Update(change)
+	}
+}
+
+// ObservableGraph is a Graph that tells its listeners about every mutation, after it is applied.
+// Removing a vertex first reports the removal of each of its edges. An undirected edge is
+// reported once, in the direction it was added.
+type ObservableGraph interface {
+	Graph
+	AddUpdateListener(listener GraphUpdateListener)
+	RemoveUpdateListener(listener GraphUpdateListener)
+}
+
+// GraphChangeLog is a GraphUpdateListener that records the changes of a graph so they can be
+// replayed onto another one. Recorded vertices and edges are copies, so later mutations of the
+// observed graph do not alter the log.
+type GraphChangeLog struct {
+	Changes []GraphChange
+}
+
+func NewGraphChangeLog() *GraphChangeLog {
+	return &GraphChangeLog{Changes: make([]GraphChange, 0)}
+}
+
+func (l *GraphChangeLog) Update(change GraphChange) {
+	if change.Vertex != nil {
+		change.Vertex = copyVertex(change.Vertex)
+	}
+	if change.Edge != nil {
+		change.Edge = cloneEdge(change.Edge, copyVertex(ToVertex(change.Edge.From())), copyVertex(ToVertex(change.Edge.To())))
+	}
+	l.Changes = append(l.Changes, change)
+}
+
+// Replay applies the recorded changes to graph in order. Cost changes require a graph with
+// SetEdgeCost and RemoveEdgeCost methods, such as SimpleGraph or ConcurrentGraph. Replay stops at
+// the first change that cannot be applied, leaving the changes before it applied.
+func (l *GraphChangeLog) Replay(graph Graph) error {
+	for i, change := range l.Changes {
+		if err := ApplyGraphChange(graph, change); err != nil {
+			return fmt.Errorf("change %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ApplyGraphChange applies change to graph. Vertices and edges are copied before they are added,
+// and edge endpoints are resolved to the vertices already in graph.
+func ApplyGraphChange(graph Graph, change GraphChange) error {
+	return applyGraphChange(graph, change, true)
+}
+
+// costEditor is implemented by graphs whose edge costs can be changed in place.
+type costEditor interface {
+	SetEdgeCost(e Edge, key string, value float64)
+	RemoveEdgeCost(e Edge, key string)
+}
+
+// applyGraphChange applies change to graph, adding copies of its vertex and edge when copied is
+// set and the vertex and edge themselves otherwise.
+func applyGraphChange(graph Graph, change GraphChange, copied bool) error {
+	switch change.Type {
+	case GRAPH_CHANGE_ADD_VERTEX:
+		if graph.ContainsVertex(change.Vertex) {
+			return nil
+		}
+		if copied {
+			graph.AddVertex(copyVertex(change.Vertex))
+		} else {
+			graph.AddVertex(change.Vertex)
+		}
+	case GRAPH_CHANGE_REMOVE_VERTEX:
+		graph.RemoveVertex(change.Vertex)
+	case GRAPH_CHANGE_ADD_EDGE:
+		if copied {
+			from := resolveChangeVertex(graph, ToVertex(change.Edge.From()))
+			to := resolveChangeVertex(graph, ToVertex(change.Edge.To()))
+			graph.AddEdge(cloneEdge(change.Edge, from, to))
+		} else {
+			graph.AddEdge(change.Edge)
+		}
+	case GRAPH_CHANGE_REMOVE_EDGE:
+		graph.RemoveEdge(change.Edge)
+	case GRAPH_CHANGE_SET_COST, GRAPH_CHANGE_REMOVE_COST:
+		editor, ok := graph.(costEditor)
+		if !ok {
+			return fmt.Errorf("%w: %T does not support cost changes", ErrUnsupportedGraphChange, graph)
+		}
+		if change.Type == GRAPH_CHANGE_SET_COST {
+			editor.SetEdgeCost(change.Edge, change.Key, change.Value)
+		} else {
+			editor.RemoveEdgeCost(change.Edge, change.Key)
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedGraphChange, change.Type)
+	}
+	return nil
+}
+
+// InverseGraphChange returns the change that undoes change.
+func InverseGraphChange(change GraphChange) (GraphChange, error) {
+	inverse := change
+	switch change.Type {
+	case GRAPH_CHANGE_ADD_VERTEX:
+		inverse.Type = GRAPH_CHANGE_REMOVE_VERTEX
+	case GRAPH_CHANGE_REMOVE_VERTEX:
+		inverse.Type = GRAPH_CHANGE_ADD_VERTEX
+	case GRAPH_CHANGE_ADD_EDGE:
+		inverse.Type = GRAPH_CHANGE_REMOVE_EDGE
+	case GRAPH_CHANGE_REMOVE_EDGE:
+		inverse.Type = GRAPH_CHANGE_ADD_EDGE
+	case GRAPH_CHANGE_SET_COST:
+		if !change.HasPrevious {
+			inverse.Type = GRAPH_CHANGE_REMOVE_COST
+		}
+		inverse.Value, inverse.Previous, inverse.HasPrevious = change.Previous, change.Value, true
+	case GRAPH_CHANGE_REMOVE_COST:
+		inverse.Type = GRAPH_CHANGE_SET_COST
+		inverse.Value, inverse.Previous, inverse.HasPrevious = change.Previous, 0, false
+	default:
+		return change, fmt.Errorf("%w: %q", ErrUnsupportedGraphChange, change.Type)
+	}
+	return inverse, nil
+}
+
+func resolveChangeVertex(graph Graph, vertex Vertex) Vertex {
+	if existing := graph.GetVertex(VertexHashOrId(vertex)); existing != nil {
+		return existing
+	}
+	return copyVertex(vertex)
+}
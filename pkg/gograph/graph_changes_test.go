@@ -0,0 +1,165 @@
+package gograph
+
+import (
+	"errors"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"testing"
+)
+
+type graphChangeRecorder struct {
+	types []string
+}
+
+func (r *graphChangeRecorder) Update(change GraphChange) {
+	r.types = append(r.types, change.Type)
+}
+
+func equalChangeTypes(left []string, right []string) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for i := range left {
+		if left[i] != right[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSimpleGraph_UpdateListeners(t *testing.T) {
+	graph := NewSimpleGraph()
+	recorder := &graphChangeRecorder{}
+	graph.AddUpdateListener(recorder)
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 1.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	graph.AddEdge(NewSimpleEdge(&b, &c, -1))
+	graph.RemoveVertex(&b)
+	expected := []string{
+		GRAPH_CHANGE_ADD_VERTEX, GRAPH_CHANGE_ADD_VERTEX, GRAPH_CHANGE_ADD_EDGE,
+		GRAPH_CHANGE_ADD_VERTEX, GRAPH_CHANGE_ADD_EDGE,
+		GRAPH_CHANGE_REMOVE_EDGE, GRAPH_CHANGE_REMOVE_EDGE, GRAPH_CHANGE_REMOVE_VERTEX,
+	}
+	if !equalChangeTypes(recorder.types, expected) {
+		t.Errorf("Expected %v, got %v", expected, recorder.types)
+	}
+
+	graph.RemoveUpdateListener(recorder)
+	graph.AddVertex(&b)
+	if len(recorder.types) != len(expected) {
+		t.Error("Expected a removed listener not to be called")
+	}
+}
+
+func TestSimpleGraph_SetEdgeCost(t *testing.T) {
+	graph := NewSimpleGraph()
+	log := NewGraphChangeLog()
+	graph.AddUpdateListener(log)
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	edge := NewSimpleEdge(&a, &b, -1)
+	graph.AddEdge(edge)
+	graph.SetEdgeCost(edge, COST_TYPE_TIME, 5.0)
+	graph.SetEdgeCost(edge.Reverse(), COST_TYPE_TIME, 7.0)
+
+	forward, reverse := GetEdge(&a, &b), GetEdge(&b, &a)
+	if (*forward.Cost())[COST_TYPE_TIME] != 7.0 || (*reverse.Cost())[COST_TYPE_TIME] != 7.0 {
+		t.Error("Expected both directions to share the cost")
+	}
+	if graph.GetEdge(EdgeHashOrId(edge)).Cost() == nil {
+		t.Error("Expected the stored edge to have a cost")
+	}
+	last := log.Changes[len(log.Changes)-1]
+	if last.Type != GRAPH_CHANGE_SET_COST || !last.HasPrevious || last.Previous != 5.0 || last.Value != 7.0 {
+		t.Errorf("Expected a cost change from 5 to 7, got %+v", last)
+	}
+	if first := log.Changes[len(log.Changes)-2]; first.HasPrevious {
+		t.Error("Expected the first cost change to have no previous value")
+	}
+}
+
+func TestGraphChangeLog_Replay(t *testing.T) {
+	graph := NewSimpleGraph()
+	log := NewGraphChangeLog()
+	graph.AddUpdateListener(log)
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 1.0}})
+	d := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 1.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	graph.AddEdge(NewSimpleEdge(&b, &c, -1))
+	graph.AddEdge(NewPolyEdge([]gomath.Spatial{&c, gomath.Point{Values: []float64{0.5, 2.0}}, &d}, -1))
+	graph.SetEdgeCost(NewSimpleEdge(&b, &c, -1), COST_TYPE_TIME, 3.0)
+	graph.RemoveEdge(NewSimpleEdge(&a, &b, -1))
+	graph.AddVertex(&a)
+	graph.RemoveVertex(&d)
+
+	replayed := NewSimpleGraph()
+	if err := log.Replay(replayed); err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Hash() != graph.Hash() || replayed.Size() != graph.Size() || len(replayed.GetEdges()) != len(graph.GetEdges()) {
+		t.Error("Expected the replayed graph to equal the observed graph")
+	}
+	if cost := GetEdge(replayed.GetVertex(VertexHashOrId(&b)), &c).Cost(); cost == nil || (*cost)[COST_TYPE_TIME] != 3.0 {
+		t.Error("Expected the replayed graph to keep the cost change")
+	}
+	if replayed.GetVertex(VertexHashOrId(&b)) == Vertex(&b) {
+		t.Error("Expected the replayed graph to use copies of the vertices")
+	}
+}
+
+func TestConcurrentGraph_UpdateListeners(t *testing.T) {
+	graph := NewConcurrentGraph()
+	log := NewGraphChangeLog()
+	graph.AddUpdateListener(log)
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	snapshot := graph.Snapshot()
+	graph.Update(func(inner *SimpleGraph) {
+		inner.SetEdgeCost(NewSimpleEdge(&a, &b, -1), COST_TYPE_TIME, 2.0)
+		inner.RemoveVertex(&a)
+	})
+	if len(log.Changes) != 6 {
+		t.Errorf("Expected 6 changes, got %d", len(log.Changes))
+	}
+	replayed := NewSimpleGraph()
+	if err := log.Replay(replayed); err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Hash() != graph.Hash() {
+		t.Error("Expected the replayed graph to equal the concurrent graph")
+	}
+	if snapshot.Size() != 2 {
+		t.Error("Expected the snapshot to be unchanged")
+	}
+}
+
+func TestGraphChangeLog_ReplayErrors(t *testing.T) {
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	edge := NewSimpleEdge(&a, &b, -1)
+	log := &GraphChangeLog{Changes: []GraphChange{
+		{Type: GRAPH_CHANGE_ADD_EDGE, Edge: edge},
+		{Type: GRAPH_CHANGE_SET_COST, Edge: edge, Key: COST_TYPE_TIME, Value: 2.0},
+	}}
+	// Embedding the Graph interface hides the cost methods of the SimpleGraph.
+	if err := log.Replay(struct{ Graph }{NewSimpleGraph()}); !errors.Is(err, ErrUnsupportedGraphChange) {
+		t.Errorf("Expected a cost change on a graph without cost changes to fail, got %v", err)
+	}
+
+	log.Changes[1].Type = "rename_edge"
+	replayed := NewSimpleGraph()
+	if err := log.Replay(replayed); !errors.Is(err, ErrUnsupportedGraphChange) {
+		t.Errorf("Expected an unknown change to fail, got %v", err)
+	}
+	if len(replayed.GetEdges()) != 2 {
+		t.Error("Expected the changes before the failing one to be applied")
+	}
+	if _, err := InverseGraphChange(log.Changes[1]); !errors.Is(err, ErrUnsupportedGraphChange) {
+		t.Errorf("Expected an unknown change to have no inverse, got %v", err)
+	}
+}
@@ -0,0 +1,279 @@
+package gograph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// GraphRecord is the JSON form of a graph:
+//
+//	{
+//	  "id": 1,
+//	  "directed": false,
+//	  "multi": false,
+//	  "vertices": [{"key": 5, "id": 5, "values": [0, 0], "properties": {...}}, ...],
+//	  "edges": [{"key": 9, "id": -1, "from": 5, "to": 6, "points": [[0, 0], [1, 2], [2, 0]], "cost": {"time": 4}}, ...],
+//	  "vertex_keys": {"osm:1": 5},
+//	  "edge_keys": {"way:7": 9}
+//	}
+//
+// Vertices and edges use VertexRecord and EdgeRecord. The key of a vertex only links it to the
+// from and to of edges, so hand written files may use any unique numbers. An undirected edge is
+// listed once and gets its reverse when it is decoded.
+type GraphRecord struct {
+	Id         int64            `json:"id"`
+	Directed   bool             `json:"directed"`
+	Multi      bool             `json:"multi,omitempty"`
+	Vertices   []VertexRecord   `json:"vertices"`
+	Edges      []EdgeRecord     `json:"edges"`
+	VertexKeys map[string]int64 `json:"vertex_keys,omitempty"`
+	EdgeKeys   map[string]int64 `json:"edge_keys,omitempty"`
+}
+
+// NewGraphRecord records the vertices of graph ordered by key, and its edges in the adjacency
+// order of their from vertex. An undirected edge is recorded in the direction the graph stores it.
+func NewGraphRecord(graph Graph) GraphRecord {
+	record := GraphRecord{
+		Id:       graph.Id(),
+		Directed: graph.IsDirected(),
+		Vertices: make([]VertexRecord, 0, graph.Size()),
+		Edges:    make([]EdgeRecord, 0),
+	}
+	if multiGraph, ok := graph.(interface{ IsMultiGraph() bool }); ok {
+		record.Multi = multiGraph.IsMultiGraph()
+	}
+	stored := make(map[int64]Edge)
+	if !graph.IsDirected() {
+		for _, edge := range graph.GetEdges() {
+			stored[EdgeHashOrId(edge)] = edge
+		}
+	}
+	recorded := make(map[int64]bool)
+	for _, vertex := range graph.GetVertices() {
+		record.Vertices = append(record.Vertices, NewVertexRecord(vertex))
+		for _, edge := range vertex.GetEdges() {
+			key := EdgeHashOrId(edge)
+			if recorded[key] || (!graph.IsDirected() && recorded[EdgeHashOrId(edge.Reverse())]) {
+				continue
+			}
+			recorded[key] = true
+			if forward, ok := stored[key]; ok {
+				edge = forward
+			}
+			record.Edges = append(record.Edges, NewEdgeRecord(edge))
+		}
+	}
+	if simpleGraph, ok := graph.(*SimpleGraph); ok {
+		if len(simpleGraph.vertexKeys) > 0 {
+			record.VertexKeys = maps.Clone(simpleGraph.vertexKeys)
+		}
+		if len(simpleGraph.edgeKeys) > 0 {
+			record.EdgeKeys = maps.Clone(simpleGraph.edgeKeys)
+		}
+	}
+	return record
+}
+
+// Graph builds a SimpleGraph from the record, adding every edge to the adjacency of its vertices.
+// It fails when an edge refers to a vertex that is not in the record or repeats the id of another
+// edge. In a multigraph, edges without an id get one that no other edge of the record has.
+func (r GraphRecord) Graph() (*SimpleGraph, error) {
+	graph := newSimpleGraph(r.Directed)
+	graph.id = r.Id
+	graph.multi = r.Multi
+	vertices := make(map[int64]*SimpleVertex, len(r.Vertices))
+	for _, record := range r.Vertices {
+		if _, ok := vertices[record.Key]; ok {
+			return nil, fmt.Errorf("duplicate vertex key %d", record.Key)
+		}
+		vertex := record.Vertex()
+		vertices[record.Key] = vertex
+		graph.AddVertex(vertex)
+	}
+	records := make([]*EdgeRecord, len(r.Edges))
+	ids := make(map[int64]bool, len(r.Edges))
+	for i, record := range r.Edges {
+		if _, ok := vertices[record.From]; !ok {
+			return nil, fmt.Errorf("edge %d starts at unknown vertex %d", record.Key, record.From)
+		}
+		if _, ok := vertices[record.To]; !ok {
+			return nil, fmt.Errorf("edge %d ends at unknown vertex %d", record.Key, record.To)
+		}
+		if record.Id != -1 {
+			if ids[record.Id] {
+				return nil, fmt.Errorf("repeated edge id %d", record.Id)
+			}
+			ids[record.Id] = true
+		}
+		records[i] = &record
+	}
+	assignEdgeIds(graph, records)
+	edgeKeys := make(map[int64]int64, len(r.Edges))
+	for i, record := range records {
+		edge := record.Edge(vertices[record.From], vertices[record.To])
+		graph.AddEdge(edge)
+		edgeKeys[r.Edges[i].Key] = EdgeHashOrId(edge)
+	}
+	for key, vertexKey := range r.VertexKeys {
+		if vertex, ok := vertices[vertexKey]; ok {
+			graph.SetVertexKey(key, vertex)
+		}
+	}
+	for key, edgeKey := range r.EdgeKeys {
+		if decodedKey, ok := edgeKeys[edgeKey]; ok {
+			graph.edgeKeys[key] = decodedKey
+		}
+	}
+	return graph, nil
+}
+
+func (g *SimpleGraph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewGraphRecord(g))
+}
+
+// UnmarshalJSON replaces the contents of g with the decoded graph. Listeners are kept but not
+// told about the change.
+func (g *SimpleGraph) UnmarshalJSON(data []byte) error {
+	var record GraphRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+	decoded, err := record.Graph()
+	if err != nil {
+		return err
+	}
+	decoded.listeners = g.listeners
+	*g = *decoded
+	return nil
+}
+
+// WriteGraphJSON encodes any Graph as a GraphRecord.
+func WriteGraphJSON(writer io.Writer, graph Graph) error {
+	return json.NewEncoder(writer).Encode(NewGraphRecord(graph))
+}
+
+func ReadGraphJSON(reader io.Reader) (*SimpleGraph, error) {
+	var record GraphRecord
+	if err := json.NewDecoder(reader).Decode(&record); err != nil {
+		return nil, err
+	}
+	return record.Graph()
+}
+
+// PathRecord is the JSON form of a path: the vertices its edges refer to, ordered by key, and its
+// edges in path order.
+//
+//	{"id": -1, "vertices": [{"key": 5, ...}, ...], "edges": [{"key": 9, "from": 5, "to": 6, ...}, ...]}
+type PathRecord struct {
+	Id       int64          `json:"id"`
+	Vertices []VertexRecord `json:"vertices"`
+	Edges    []EdgeRecord   `json:"edges"`
+}
+
+func NewPathRecord(path Path) PathRecord {
+	record := PathRecord{Id: path.Id(), Edges: make([]EdgeRecord, 0, path.Length())}
+	vertices := make(map[int64]Vertex)
+	for _, edge := range path.GetEdges() {
+		from, to := ToVertex(edge.From()), ToVertex(edge.To())
+		vertices[VertexHashOrId(from)] = from
+		vertices[VertexHashOrId(to)] = to
+		record.Edges = append(record.Edges, NewEdgeRecord(edge))
+	}
+	record.Vertices = make([]VertexRecord, 0, len(vertices))
+	for _, key := range slices.Sorted(maps.Keys(vertices)) {
+		record.Vertices = append(record.Vertices, NewVertexRecord(vertices[key]))
+	}
+	return record
+}
+
+// Path builds a SimplePath from the record. Each edge is also added to the adjacency of its from
+// vertex, so the vertices of the path can be walked like those of a graph.
+func (r PathRecord) Path() (*SimplePath, error) {
+	vertices := make(map[int64]*SimpleVertex, len(r.Vertices))
+	for _, record := range r.Vertices {
+		vertices[record.Key] = record.Vertex()
+	}
+	edges := make([]Edge, 0, len(r.Edges))
+	for _, record := range r.Edges {
+		from, fromOk := vertices[record.From]
+		to, toOk := vertices[record.To]
+		if !fromOk || !toOk {
+			return nil, fmt.Errorf("path edge %d refers to an unknown vertex", record.Key)
+		}
+		edge := record.Edge(from, to)
+		if !vertexContainsEdge(from, edge) {
+			from.AddEdge(edge)
+		}
+		edges = append(edges, edge)
+	}
+	path := NewSimplePath(edges)
+	path.id = r.Id
+	return path, nil
+}
+
+func (p *SimplePath) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewPathRecord(p))
+}
+
+func (p *SimplePath) UnmarshalJSON(data []byte) error {
+	var record PathRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+	decoded, err := record.Path()
+	if err != nil {
+		return err
+	}
+	*p = *decoded
+	return nil
+}
+
+// routingResponseRecord is the JSON form of a RoutingAlgorithmResponse:
+//
+//	{"costs": {"distance": {"accumulated": 3, "current": 1, "total": 3}}, "path": {...}, "visited": [5, 6], "completed": true}
+//
+// Path is null when no path was found, and visited lists the keys of the visited vertices.
+type routingResponseRecord struct {
+	Costs     map[string]CostEntry `json:"costs"`
+	Path      *PathRecord          `json:"path"`
+	Visited   []int64              `json:"visited"`
+	Completed bool                 `json:"completed"`
+}
+
+func (r RoutingAlgorithmResponse) MarshalJSON() ([]byte, error) {
+	record := routingResponseRecord{Costs: r.Costs, Visited: make([]int64, 0, len(r.Visited)), Completed: r.Completed}
+	if r.Path != nil {
+		path := NewPathRecord(r.Path)
+		record.Path = &path
+	}
+	for _, key := range slices.Sorted(maps.Keys(r.Visited)) {
+		if r.Visited[key] {
+			record.Visited = append(record.Visited, key)
+		}
+	}
+	return json.Marshal(record)
+}
+
+// UnmarshalJSON decodes the path of the response as a *SimplePath.
+func (r *RoutingAlgorithmResponse) UnmarshalJSON(data []byte) error {
+	var record routingResponseRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+	decoded := RoutingAlgorithmResponse{Costs: record.Costs, Visited: make(map[int64]bool, len(record.Visited)), Completed: record.Completed}
+	if record.Path != nil {
+		path, err := record.Path.Path()
+		if err != nil {
+			return err
+		}
+		decoded.Path = path
+	}
+	for _, key := range record.Visited {
+		decoded.Visited[key] = true
+	}
+	*r = decoded
+	return nil
+}
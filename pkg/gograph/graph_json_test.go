@@ -0,0 +1,187 @@
+package gograph
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"testing"
+)
+
+func equalAdjacency(t *testing.T, left Graph, right Graph) {
+	for _, vertex := range left.GetVertices() {
+		other := right.GetVertex(VertexHashOrId(vertex))
+		if other == nil {
+			t.Errorf("Expected vertex %d to be decoded", VertexHashOrId(vertex))
+			continue
+		}
+		keys := make(map[int64]bool)
+		for _, edge := range vertex.GetEdges() {
+			keys[EdgeHashOrId(edge)] = true
+		}
+		if len(other.GetEdges()) != len(keys) {
+			t.Errorf("Expected vertex %d to have %d edges, got %d", VertexHashOrId(vertex), len(keys), len(other.GetEdges()))
+		}
+		for _, edge := range other.GetEdges() {
+			if !keys[EdgeHashOrId(edge)] || ToVertex(edge.From()) != other {
+				t.Errorf("Expected edge %d to leave vertex %d", EdgeHashOrId(edge), VertexHashOrId(vertex))
+			}
+		}
+	}
+}
+
+func TestSimpleGraph_JSON(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertexWithId(gomath.Point{Values: []float64{0.0, 0.0}}, 1)
+	b := NewSimpleVertex(gomath.Point{Values: []float64{2.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{2.0, 2.0}})
+	a.Properties().SetString("name", "depot")
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_TIME: 2.5}))
+	graph.AddEdge(NewPolyEdge([]gomath.Spatial{&b, gomath.Point{Values: []float64{3.0, 1.0}}, &c}, 7))
+	graph.AddEdge(NewSimpleEdge(&c, &a, -1))
+	graph.SetVertexKey("depot", &a)
+	graph.SetEdgeKey("ring", GetEdge(&b, &c))
+
+	data, err := json.Marshal(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &SimpleGraph{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Id() != graph.Id() || decoded.IsDirected() || decoded.Hash() != graph.Hash() || !decoded.Validate().IsValid() {
+		t.Error("Expected the decoded graph to equal the graph")
+	}
+	if diff := DiffGraphs(graph, decoded); !diff.IsEmpty() {
+		t.Errorf("Expected no difference, got %+v", diff)
+	}
+	equalAdjacency(t, graph, decoded)
+	if name, _ := GetProperties(decoded.GetVertexByKey("depot")).GetString("name"); name != "depot" {
+		t.Error("Expected the vertex key and properties to be decoded")
+	}
+	if ring, ok := decoded.GetEdgeByKey("ring").(PolyEdge); !ok || len(ring.Points) != 3 || ring.Id() != 7 {
+		t.Error("Expected the polyline to be decoded")
+	}
+}
+
+func TestReadGraphJSON(t *testing.T) {
+	graph := NewDirectedMultiGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_DISTANCE: 5.0}))
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_DISTANCE: 2.0}))
+	graph.AddEdge(NewSimpleEdge(&b, &a, -1))
+
+	var buffer bytes.Buffer
+	if err := WriteGraphJSON(&buffer, NewConcurrentGraphFrom(graph)); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadGraphJSON(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.IsDirected() || !decoded.IsMultiGraph() || len(decoded.GetEdges()) != 3 || !DiffGraphs(graph, decoded).IsEmpty() {
+		t.Error("Expected the directed multigraph to round trip")
+	}
+	equalAdjacency(t, graph, decoded)
+
+	_, err = ReadGraphJSON(bytes.NewBufferString(`{"directed": true, "vertices": [{"key": 1, "id": 1, "values": [0, 0]}], "edges": [{"key": 3, "id": -1, "from": 1, "to": 2}]}`))
+	if err == nil {
+		t.Error("Expected an error for an edge to an unknown vertex")
+	}
+}
+
+func TestReadGraphJSON_MixedEdgeIds(t *testing.T) {
+	data := `{"directed": true, "multi": true,
+		"vertices": [{"key": 1, "id": 1, "values": [0, 0]}, {"key": 2, "id": 2, "values": [1, 0]}],
+		"edges": [{"key": 10, "id": -1, "from": 1, "to": 2}, {"key": 11, "id": 1, "from": 1, "to": 2}],
+		"edge_keys": {"generated": 10, "explicit": 11}}`
+	decoded, err := ReadGraphJSON(bytes.NewBufferString(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.GetEdges()) != 2 || len(decoded.GetVertex(1).GetEdges()) != 2 {
+		t.Fatalf("Expected 2 edges, got %d", len(decoded.GetEdges()))
+	}
+	generated, explicit := decoded.GetEdgeByKey("generated"), decoded.GetEdgeByKey("explicit")
+	if generated == nil || explicit == nil || generated.Id() == 1 || explicit.Id() != 1 {
+		t.Error("Expected the edge keys to resolve to the stored edges")
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteGraphJSON(&buffer, decoded); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ReadGraphJSON(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again.GetEdges()) != 2 || !DiffGraphs(decoded, again).IsEmpty() {
+		t.Error("Expected the multigraph to round trip")
+	}
+
+	_, err = ReadGraphJSON(bytes.NewBufferString(`{"directed": true, "multi": true,
+		"vertices": [{"key": 1, "id": 1, "values": [0, 0]}, {"key": 2, "id": 2, "values": [1, 0]}],
+		"edges": [{"key": 10, "id": 1, "from": 1, "to": 2}, {"key": 11, "id": 1, "from": 2, "to": 1}]}`))
+	if err == nil {
+		t.Error("Expected an error for a repeated edge id")
+	}
+}
+
+func TestRoutingAlgorithmResponse_JSON(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{2.0, 1.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	graph.AddEdge(NewPolyEdge([]gomath.Spatial{&b, gomath.Point{Values: []float64{2.0, 0.0}}, &c}, -1))
+	response := AStar(RoutingAlgorithmRequest{Start: &a, Destination: &c})
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded RoutingAlgorithmResponse
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Completed != response.Completed || len(decoded.Visited) != len(response.Visited) {
+		t.Error("Expected the response to round trip")
+	}
+	if decoded.Costs[COST_TYPE_DISTANCE] != response.Costs[COST_TYPE_DISTANCE] {
+		t.Errorf("Expected costs %v, got %v", response.Costs, decoded.Costs)
+	}
+	if decoded.Path.Length() != response.Path.Length() {
+		t.Fatalf("Expected a path of %d edges, got %d", response.Path.Length(), decoded.Path.Length())
+	}
+	for i, edge := range response.Path.GetEdges() {
+		if EdgeHashOrId(decoded.Path.GetEdges()[i]) != EdgeHashOrId(edge) {
+			t.Error("Expected the path edges to keep their order")
+		}
+	}
+	if GetPathDistance(decoded.Path) != GetPathDistance(response.Path) {
+		t.Error("Expected the decoded path to keep its geometry")
+	}
+}
+
+func TestMaze_JSON(t *testing.T) {
+	maze := AldousBroderMazeGenerator(MazeGeneratorRequest{Rows: 4, Cols: 5, Random: NewSeededRandom(3)}).Maze
+	data, err := json.Marshal(maze)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Maze
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Rows != 4 || decoded.Cols != 5 {
+		t.Fatal("Expected the maze dimensions to round trip")
+	}
+	for i := range maze.Cells {
+		for j := range maze.Cells[i] {
+			if decoded.Cells[i][j] != maze.Cells[i][j] {
+				t.Errorf("Expected cell %d, %d to round trip", i, j)
+			}
+		}
+	}
+}
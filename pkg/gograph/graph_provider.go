@@ -3,7 +3,6 @@ package gograph
 import (
 	"github.com/mtresnik/gomath/pkg/gomath"
 	"math/rand"
-	"sort"
 )
 
 type GraphProvider interface {
@@ -38,56 +37,41 @@ func (m MazeToGraphProvider) Build() Graph {
 		from := cellToVertexMap[HashMazeCoordinate(connection.From)]
 		to := cellToVertexMap[HashMazeCoordinate(connection.To)]
 		if from != nil && to != nil {
-			edge := NewSimpleEdge(from, to, -1)
-			from.AddEdge(edge)
-			to.AddEdge(edge.Reverse())
-			retGraph.AddEdge(edge)
-			retGraph.AddEdge(edge.Reverse())
-			retGraph.AddVertex(from)
-			retGraph.AddVertex(to)
+			retGraph.AddEdge(NewSimpleEdge(from, to, -1))
 			calls++
 		}
 	}
 	return retGraph
 }
 
+// CloneGraphProvider builds a Clone of Graph, so that providers such as RandomPruneGraphProvider
+// can work on an existing graph without changing it.
+type CloneGraphProvider struct {
+	Graph Graph
+}
+
+func (c CloneGraphProvider) Build() Graph {
+	cloned, _ := Clone(c.Graph)
+	return cloned
+}
+
 type RandomPruneGraphProvider struct {
 	InternalProvider GraphProvider
 	PruneRatio       float64
+	Random           *rand.Rand
 }
 
 func (r RandomPruneGraphProvider) Build() Graph {
 	graph := r.InternalProvider.Build()
-	toRemoveVertices := make([]Vertex, 0)
-	toRemoveEdges := make([]Edge, 0)
+	random := randomOrDefault(r.Random)
 	for _, vertex := range graph.GetVertices() {
-		if rand.Float64() < r.PruneRatio {
-			toRemoveVertices = append(toRemoveVertices, vertex)
-			for _, other := range graph.GetVertices() {
-				localRemove := make([]Edge, 0)
-				for _, edge := range other.GetEdges() {
-					if vertex.Hash() == ToVertex(edge.To()).Hash() {
-						toRemoveEdges = append(toRemoveEdges, edge)
-						toRemoveEdges = append(toRemoveEdges, edge.Reverse())
-						localRemove = append(localRemove, edge)
-					}
-				}
-				for _, edge := range localRemove {
-					other.RemoveEdge(edge)
-					vertex.RemoveEdge(edge.Reverse())
-				}
-			}
+		if random.Float64() < r.PruneRatio {
+			graph.RemoveVertex(vertex)
 		}
 	}
-	for _, vertex := range toRemoveVertices {
-		graph.RemoveVertex(vertex)
-	}
-	for _, edge := range toRemoveEdges {
-		graph.RemoveEdge(edge)
-	}
-	toRemoveVertices = make([]Vertex, 0)
+	toRemoveVertices := make([]Vertex, 0)
 	for _, vertex := range graph.GetVertices() {
-		if len(vertex.GetEdges()) == 0 {
+		if graph.InDegree(vertex)+graph.OutDegree(vertex) == 0 {
 			toRemoveVertices = append(toRemoveVertices, vertex)
 		}
 	}
@@ -123,18 +107,10 @@ func (b BoundedGridGraphProvider) Build() Graph {
 	for ROW := 0; ROW < b.Height; ROW++ {
 		for COL := 0; COL < b.Width; COL++ {
 			if ROW > 0 {
-				edge := NewSimpleEdge(vertexMatrix[ROW-1][COL], vertexMatrix[ROW][COL], -1)
-				vertexMatrix[ROW-1][COL].AddEdge(edge)
-				vertexMatrix[ROW][COL].AddEdge(edge.Reverse())
-				graph.AddEdge(edge)
-				graph.AddEdge(edge.Reverse())
+				graph.AddEdge(NewSimpleEdge(vertexMatrix[ROW-1][COL], vertexMatrix[ROW][COL], -1))
 			}
 			if COL > 0 {
-				edge := NewSimpleEdge(vertexMatrix[ROW][COL-1], vertexMatrix[ROW][COL], -1)
-				vertexMatrix[ROW][COL-1].AddEdge(edge)
-				vertexMatrix[ROW][COL].AddEdge(edge.Reverse())
-				graph.AddEdge(edge)
-				graph.AddEdge(edge.Reverse())
+				graph.AddEdge(NewSimpleEdge(vertexMatrix[ROW][COL-1], vertexMatrix[ROW][COL], -1))
 			}
 		}
 	}
@@ -151,14 +127,16 @@ type BoundedRandomGraphProvider struct {
 	NumPoints      int
 	NumConnections int
 	CostFunctions  *map[string]gomath.DistanceFunction
+	Random         *rand.Rand
 }
 
 func (b BoundedRandomGraphProvider) Build() Graph {
 	dx := b.BoundingBox.MaxX - b.BoundingBox.MinX
 	dy := b.BoundingBox.MaxY - b.BoundingBox.MinY
 	graph := NewSimpleGraph()
+	random := randomOrDefault(b.Random)
 	for i := 0; i < b.NumPoints; i++ {
-		vertex := NewSimpleVertex(gomath.Point{Values: []float64{rand.Float64()*dx + b.BoundingBox.MinX, rand.Float64()*dy + b.BoundingBox.MinY}}, make([]Edge, 0)...)
+		vertex := NewSimpleVertex(gomath.Point{Values: []float64{random.Float64()*dx + b.BoundingBox.MinX, random.Float64()*dy + b.BoundingBox.MinY}}, make([]Edge, 0)...)
 		graph.AddVertex(&vertex)
 	}
 
@@ -167,24 +145,15 @@ func (b BoundedRandomGraphProvider) Build() Graph {
 		costFunctions = *b.CostFunctions
 	}
 
-	allVertices := graph.GetVertices()
-	copiedVertices := make([]Vertex, len(allVertices))
-	copy(copiedVertices, allVertices)
+	index := graph.SpatialIndex()
 	for _, vertex := range graph.GetVertices() {
-		sort.Slice(copiedVertices, func(i, j int) bool {
-			return gomath.EuclideanDistance(copiedVertices[i], vertex) < gomath.EuclideanDistance(copiedVertices[j], vertex)
-		})
-		for i := 1; i < b.NumConnections+1; i++ {
-			if vertex.Hash() != copiedVertices[i].Hash() {
+		for _, neighbor := range index.KNearest(vertex, b.NumConnections+1) {
+			if VertexHashOrId(vertex) != VertexHashOrId(neighbor) {
 				cost := map[string]float64{}
 				for key, function := range costFunctions {
-					cost[key] = function(vertex, copiedVertices[i])
+					cost[key] = function(vertex, neighbor)
 				}
-				edge := NewSimpleEdge(vertex, copiedVertices[i], -1, &cost)
-				vertex.AddEdge(edge)
-				copiedVertices[i].AddEdge(edge.Reverse())
-				graph.AddEdge(edge)
-				graph.AddEdge(edge.Reverse())
+				graph.AddEdge(NewSimpleEdge(vertex, neighbor, -1, &cost))
 			}
 		}
 	}
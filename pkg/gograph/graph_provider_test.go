@@ -20,3 +20,31 @@ func TestBoundedGraphProvider_Build(t *testing.T) {
 	graph := provider.Build()
 	print("Num Vertices:", len(graph.GetVertices()), "\tNum Edges:", len(graph.GetEdges()))
 }
+
+func TestGraphProviders_Seeded(t *testing.T) {
+	build := func(seed int64) Graph {
+		return RandomPruneGraphProvider{
+			InternalProvider: BoundedRandomGraphProvider{
+				BoundingBox:    gomath.BoundingBox{MinX: 0, MinY: 0, MaxX: 100, MaxY: 100},
+				NumPoints:      200,
+				NumConnections: 3,
+				Random:         NewSeededRandom(seed),
+			},
+			PruneRatio: 0.2,
+			Random:     NewSeededRandom(seed),
+		}.Build()
+	}
+	first, second, other := build(42), build(42), build(43)
+	if first.Hash() != second.Hash() || len(first.GetEdges()) != len(second.GetEdges()) {
+		t.Error("Expected the same seed to build the same graph")
+	}
+	if first.Hash() == other.Hash() {
+		t.Error("Expected a different seed to build a different graph")
+	}
+	firstVertices, secondVertices := first.GetVertices(), second.GetVertices()
+	for i := range firstVertices {
+		if VertexHashOrId(firstVertices[i]) != VertexHashOrId(secondVertices[i]) {
+			t.Fatal("Expected GetVertices to return the vertices in the same order")
+		}
+	}
+}
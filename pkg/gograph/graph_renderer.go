@@ -6,6 +6,7 @@ import (
 	"image"
 	"image/color"
 	"image/gif"
+	"math"
 )
 
 type IGraphRenderer interface {
@@ -154,6 +155,27 @@ func (g *GraphRenderer) drawLine(img *image.RGBA, from, to gomath.Spatial, color
 	goutils.DrawLine(img, fromCoords.X, fromCoords.Y, toCoords.X, toCoords.Y, color, g.lineThickness)
 }
 
+func (g *GraphRenderer) drawArrowHead(img *image.RGBA, from, to gomath.Spatial, color color.RGBA) {
+	fromCoords := g.convertPixels(from)
+	toCoords := g.convertPixels(to)
+	dx := float64(toCoords.X - fromCoords.X)
+	dy := float64(toCoords.Y - fromCoords.Y)
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return
+	}
+	// Pull the tip back so it is not hidden underneath the destination point.
+	tipX := float64(toCoords.X) - dx/length*float64(g.pointRadius)
+	tipY := float64(toCoords.Y) - dy/length*float64(g.pointRadius)
+	headLength := math.Min(float64(g.lineThickness*4), length/2)
+	theta := math.Atan2(dy, dx)
+	for _, wing := range []float64{theta + math.Pi*5/6, theta - math.Pi*5/6} {
+		wingX := tipX + headLength*math.Cos(wing)
+		wingY := tipY + headLength*math.Sin(wing)
+		goutils.DrawLine(img, int(tipX), int(tipY), int(wingX), int(wingY), color, g.lineThickness)
+	}
+}
+
 func (g *GraphRenderer) drawPoint(img *image.RGBA, point gomath.Spatial, color color.RGBA) {
 	coords := g.convertPixels(point)
 	goutils.FillCircle(img, coords.X, coords.Y, g.pointRadius, color)
@@ -194,6 +216,9 @@ func (g *GraphRenderer) Render() *image.RGBA {
 		}
 		for _, edge := range graph.GetEdges() {
 			g.drawLine(img, edge.From(), edge.To(), graphColor)
+			if graph.IsDirected() {
+				g.drawArrowHead(img, edge.From(), edge.To(), graphColor)
+			}
 		}
 		for _, vertex := range graph.GetVertices() {
 			g.drawPoint(img, vertex, graphColor)
@@ -280,7 +305,7 @@ func (g *LiveGraphRenderer) Update(response RoutingAlgorithmResponse) {
 	}
 	if g.RenderVisited {
 		for _, vertex := range g.Graph.GetVertices() {
-			hash := vertex.Hash()
+			hash := VertexHashOrId(vertex)
 			_, ok := response.Visited[hash]
 			if ok {
 				colorMap[hash] = visitedColor
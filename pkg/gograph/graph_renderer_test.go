@@ -34,7 +34,7 @@ func testLiveGraphRenderer_RenderFrames(t *testing.T) {
 		BoundingBox: gomath.BoundingBox{10, 10, 20, 20},
 		Width:       size,
 		Height:      size,
-	}, 0.10}
+	}, 0.10, nil}
 	graph := randomPruneProvider.Build()
 	vertices := graph.GetVertices()
 	random := rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -95,7 +95,7 @@ func TestRandomGraphProvider_UI(t *testing.T) {
 		BoundingBox: gomath.BoundingBox{10, 10, 20, 20},
 		Width:       40,
 		Height:      40,
-	}, 0.10}
+	}, 0.10, nil}
 	graph := randomPruneProvider.Build()
 	renderer := NewGraphRenderer(4000, 4000)
 	renderer.AddGraph(graph)
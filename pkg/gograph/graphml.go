@@ -0,0 +1,428 @@
+package gograph
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GraphML keys written by WriteGraphML. Vertex coordinates use the keys x, y, z and w, then c4,
+// c5 and so on. Costs are written as cost_<name> and properties as vp_<name> and ep_<name>, each
+// with the cost or property name as attr.name.
+const (
+	GRAPHML_NAMESPACE     = "http://graphml.graphdrawing.org/xmlns"
+	GRAPHML_KEY_VERTEX_ID = "vertex_id"
+	GRAPHML_KEY_EDGE_ID   = "edge_id"
+	GRAPHML_KEY_POINTS    = "points"
+	GRAPHML_KEY_MULTI     = "multi"
+	graphMLCostPrefix     = "cost_"
+	graphMLVertexPrefix   = "vp_"
+	graphMLEdgePrefix     = "ep_"
+)
+
+var ErrGraphMLUnsupported = errors.New("unsupported GraphML construct")
+
+var graphMLAxes = []string{"x", "y", "z", "w"}
+
+type graphMLDocument struct {
+	XMLName xml.Name       `xml:"graphml"`
+	Xmlns   string         `xml:"xmlns,attr,omitempty"`
+	Keys    []graphMLKey   `xml:"key"`
+	Graphs  []graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	Id      string  `xml:"id,attr"`
+	For     string  `xml:"for,attr"`
+	Name    string  `xml:"attr.name,attr,omitempty"`
+	Type    string  `xml:"attr.type,attr,omitempty"`
+	Default *string `xml:"default"`
+}
+
+type graphMLGraph struct {
+	Id          string             `xml:"id,attr,omitempty"`
+	EdgeDefault string             `xml:"edgedefault,attr"`
+	Data        []graphMLData      `xml:"data"`
+	Nodes       []graphMLNode      `xml:"node"`
+	Edges       []graphMLEdge      `xml:"edge"`
+	Hyperedges  []graphMLUnhandled `xml:"hyperedge"`
+}
+
+type graphMLNode struct {
+	Id     string             `xml:"id,attr"`
+	Data   []graphMLData      `xml:"data"`
+	Graphs []graphMLUnhandled `xml:"graph"`
+	Ports  []graphMLUnhandled `xml:"port"`
+}
+
+type graphMLEdge struct {
+	Id         string             `xml:"id,attr,omitempty"`
+	Source     string             `xml:"source,attr"`
+	Target     string             `xml:"target,attr"`
+	Directed   string             `xml:"directed,attr,omitempty"`
+	SourcePort string             `xml:"sourceport,attr,omitempty"`
+	TargetPort string             `xml:"targetport,attr,omitempty"`
+	Data       []graphMLData      `xml:"data"`
+	Graphs     []graphMLUnhandled `xml:"graph"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLUnhandled struct {
+	Id string `xml:"id,attr"`
+}
+
+// WriteGraphML writes graph as a GraphML document. Vertex coordinates, ids and properties and edge
+// ids, polylines, costs and properties are written as typed <data> elements. An undirected edge
+// is written once.
+func WriteGraphML(writer io.Writer, graph Graph) error {
+	record := NewGraphRecord(graph)
+	document := graphMLDocument{Xmlns: GRAPHML_NAMESPACE, Keys: make([]graphMLKey, 0)}
+	graphElement := graphMLGraph{Id: "G", EdgeDefault: "undirected"}
+	if record.Directed {
+		graphElement.EdgeDefault = "directed"
+	}
+	if record.Multi {
+		document.Keys = append(document.Keys, graphMLKey{Id: GRAPHML_KEY_MULTI, For: "graph", Name: GRAPHML_KEY_MULTI, Type: "boolean"})
+		graphElement.Data = append(graphElement.Data, graphMLData{Key: GRAPHML_KEY_MULTI, Value: "true"})
+	}
+
+	dimensions := 0
+	vertexProperties := make(map[string]string)
+	for _, vertex := range record.Vertices {
+		dimensions = max(dimensions, len(vertex.Values))
+		node := graphMLNode{Id: graphMLNodeId(vertex.Key)}
+		for i, value := range vertex.Values {
+			node.Data = append(node.Data, graphMLData{Key: graphMLAxis(i), Value: formatFloat(value)})
+		}
+		if vertex.Id > 0 {
+			node.Data = append(node.Data, graphMLData{Key: GRAPHML_KEY_VERTEX_ID, Value: strconv.FormatInt(vertex.Id, 10)})
+		}
+		node.Data = append(node.Data, graphMLPropertyData(vertex.Properties, graphMLVertexPrefix, vertexProperties)...)
+		graphElement.Nodes = append(graphElement.Nodes, node)
+	}
+	for i := 0; i < dimensions; i++ {
+		document.Keys = append(document.Keys, graphMLKey{Id: graphMLAxis(i), For: "node", Name: graphMLAxis(i), Type: "double"})
+	}
+	document.Keys = append(document.Keys, graphMLKey{Id: GRAPHML_KEY_VERTEX_ID, For: "node", Name: GRAPHML_KEY_VERTEX_ID, Type: "long"})
+	document.Keys = append(document.Keys, graphMLPropertyKeys(vertexProperties, graphMLVertexPrefix, "node")...)
+
+	costs := make(map[string]bool)
+	edgeProperties := make(map[string]string)
+	for i, edge := range record.Edges {
+		edgeElement := graphMLEdge{Id: "e" + strconv.Itoa(i), Source: graphMLNodeId(edge.From), Target: graphMLNodeId(edge.To)}
+		if edge.Id != -1 {
+			edgeElement.Data = append(edgeElement.Data, graphMLData{Key: GRAPHML_KEY_EDGE_ID, Value: strconv.FormatInt(edge.Id, 10)})
+		}
+		if len(edge.Points) > 0 {
+			edgeElement.Data = append(edgeElement.Data, graphMLData{Key: GRAPHML_KEY_POINTS, Value: formatPolyline(edge.Points)})
+		}
+		for _, key := range slices.Sorted(maps.Keys(edge.Cost)) {
+			costs[key] = true
+			edgeElement.Data = append(edgeElement.Data, graphMLData{Key: graphMLCostPrefix + key, Value: formatFloat(edge.Cost[key])})
+		}
+		edgeElement.Data = append(edgeElement.Data, graphMLPropertyData(edge.Properties, graphMLEdgePrefix, edgeProperties)...)
+		graphElement.Edges = append(graphElement.Edges, edgeElement)
+	}
+	document.Keys = append(document.Keys,
+		graphMLKey{Id: GRAPHML_KEY_EDGE_ID, For: "edge", Name: GRAPHML_KEY_EDGE_ID, Type: "long"},
+		graphMLKey{Id: GRAPHML_KEY_POINTS, For: "edge", Name: GRAPHML_KEY_POINTS, Type: "string"})
+	for _, key := range slices.Sorted(maps.Keys(costs)) {
+		document.Keys = append(document.Keys, graphMLKey{Id: graphMLCostPrefix + key, For: "edge", Name: key, Type: "double"})
+	}
+	document.Keys = append(document.Keys, graphMLPropertyKeys(edgeProperties, graphMLEdgePrefix, "edge")...)
+	document.Graphs = []graphMLGraph{graphElement}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
+
+func graphMLNodeId(key int64) string {
+	return "n" + strconv.FormatInt(key, 10)
+}
+
+func graphMLAxis(index int) string {
+	if index < len(graphMLAxes) {
+		return graphMLAxes[index]
+	}
+	return "c" + strconv.Itoa(index)
+}
+
+func graphMLPropertyType(propertyType string) string {
+	switch propertyType {
+	case PROPERTY_TYPE_INT:
+		return "long"
+	case PROPERTY_TYPE_FLOAT:
+		return "double"
+	case PROPERTY_TYPE_BOOL:
+		return "boolean"
+	}
+	return "string"
+}
+
+// graphMLPropertyData writes properties as data elements, collecting their types in types.
+func graphMLPropertyData(properties *Properties, prefix string, types map[string]string) []graphMLData {
+	data := make([]graphMLData, 0, properties.Len())
+	for _, key := range properties.Keys() {
+		value, _ := properties.Get(key)
+		types[key] = graphMLPropertyType(properties.Type(key))
+		formatted := fmt.Sprint(value)
+		if floatValue, ok := value.(float64); ok {
+			formatted = formatFloat(floatValue)
+		}
+		data = append(data, graphMLData{Key: prefix + key, Value: formatted})
+	}
+	return data
+}
+
+func graphMLPropertyKeys(types map[string]string, prefix string, domain string) []graphMLKey {
+	keys := make([]graphMLKey, 0, len(types))
+	for _, name := range slices.Sorted(maps.Keys(types)) {
+		keys = append(keys, graphMLKey{Id: prefix + name, For: domain, Name: name, Type: types[name]})
+	}
+	return keys
+}
+
+// ReadGraphML reads the graph of a GraphML document into a SimpleGraph. Node data named x, y, z
+// and w become coordinates, vertex_id and edge_id become ids, numeric edge data become costs and
+// any other data become properties. Keys without attr.name or attr.type, like yEd graphics, are
+// skipped. Each node id is registered with SetVertexKey, and each edge id with SetEdgeKey.
+//
+// A vertex without vertex_id is identified by its coordinates, or by IdFromKey of its node id
+// when it has none or shares them with another vertex. Parallel edges make the result a
+// multigraph. Hyperedges, ports, nested graphs, documents with several graphs and mixed
+// directedness are rejected with an error wrapping ErrGraphMLUnsupported.
+func ReadGraphML(reader io.Reader) (*SimpleGraph, error) {
+	var document graphMLDocument
+	if err := xml.NewDecoder(reader).Decode(&document); err != nil {
+		return nil, err
+	}
+	if len(document.Graphs) != 1 {
+		return nil, fmt.Errorf("%w: expected one graph, found %d", ErrGraphMLUnsupported, len(document.Graphs))
+	}
+	input := document.Graphs[0]
+	if len(input.Hyperedges) > 0 {
+		return nil, fmt.Errorf("%w: hyperedge", ErrGraphMLUnsupported)
+	}
+	directed := input.EdgeDefault == "directed"
+	keys := make(map[string]graphMLKey, len(document.Keys))
+	for _, key := range document.Keys {
+		keys[key.Id] = key
+	}
+	multi := false
+	if values, err := graphMLValues(keys, "graph", input.Data); err != nil {
+		return nil, err
+	} else if value, ok := values[GRAPHML_KEY_MULTI].Value.(bool); ok {
+		multi = value
+	}
+	pairs := make(map[[2]string]bool)
+	for _, edge := range input.Edges {
+		if edge.Directed != "" && (edge.Directed == "true") != directed {
+			return nil, fmt.Errorf("%w: edge %s has a different directedness than its graph", ErrGraphMLUnsupported, edge.Source+"-"+edge.Target)
+		}
+		if len(edge.Graphs) > 0 || edge.SourcePort != "" || edge.TargetPort != "" {
+			return nil, fmt.Errorf("%w: nested graph or port on edge %s", ErrGraphMLUnsupported, edge.Source+"-"+edge.Target)
+		}
+		pair := [2]string{edge.Source, edge.Target}
+		if !directed && edge.Target < edge.Source {
+			pair = [2]string{edge.Target, edge.Source}
+		}
+		multi = multi || pairs[pair]
+		pairs[pair] = true
+	}
+
+	graph := newSimpleGraph(directed)
+	graph.multi = multi
+	vertices := make(map[string]*SimpleVertex, len(input.Nodes))
+	for _, node := range input.Nodes {
+		if len(node.Graphs) > 0 || len(node.Ports) > 0 {
+			return nil, fmt.Errorf("%w: nested graph or port in node %s", ErrGraphMLUnsupported, node.Id)
+		}
+		if _, ok := vertices[node.Id]; ok {
+			return nil, fmt.Errorf("duplicate GraphML node %s", node.Id)
+		}
+		values, err := graphMLValues(keys, "node", node.Data)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", node.Id, err)
+		}
+		record := VertexRecord{Id: -1, Values: make([]float64, 0), Properties: NewProperties()}
+		for i := 0; ; i++ {
+			value, ok := graphMLFloat(values[graphMLAxis(i)].Value)
+			if !ok {
+				break
+			}
+			record.Values = append(record.Values, value)
+		}
+		for name, value := range values {
+			switch {
+			case name == GRAPHML_KEY_VERTEX_ID:
+				record.Id = graphMLInt(value.Value)
+			case graphMLAxisIndex(name) < 0:
+				if err := record.Properties.Set(name, value.Value); err != nil {
+					return nil, fmt.Errorf("node %s: %w", node.Id, err)
+				}
+			}
+		}
+		if record.Properties.Len() == 0 {
+			record.Properties = nil
+		}
+		vertex, err := addKeyedVertex(graph, node.Id, record)
+		if err != nil {
+			return nil, err
+		}
+		vertices[node.Id] = vertex
+	}
+
+	records := make([]*EdgeRecord, len(input.Edges))
+	for i, edge := range input.Edges {
+		if _, ok := vertices[edge.Source]; !ok {
+			return nil, fmt.Errorf("GraphML edge refers to unknown node %s", edge.Source)
+		}
+		if _, ok := vertices[edge.Target]; !ok {
+			return nil, fmt.Errorf("GraphML edge refers to unknown node %s", edge.Target)
+		}
+		values, err := graphMLValues(keys, "edge", edge.Data)
+		if err != nil {
+			return nil, fmt.Errorf("edge %s-%s: %w", edge.Source, edge.Target, err)
+		}
+		if records[i], err = graphMLEdgeRecord(values); err != nil {
+			return nil, fmt.Errorf("edge %s-%s: %w", edge.Source, edge.Target, err)
+		}
+	}
+	assignEdgeIds(graph, records)
+	for i, edge := range input.Edges {
+		created := records[i].Edge(vertices[edge.Source], vertices[edge.Target])
+		graph.AddEdge(created)
+		if edge.Id != "" {
+			graph.SetEdgeKey(edge.Id, created)
+		}
+	}
+	return graph, nil
+}
+
+func graphMLEdgeRecord(values map[string]graphMLValue) (*EdgeRecord, error) {
+	record := &EdgeRecord{Id: -1, Cost: make(map[string]float64), Properties: NewProperties()}
+	for name, value := range values {
+		var err error
+		switch {
+		case name == GRAPHML_KEY_EDGE_ID:
+			record.Id = graphMLInt(value.Value)
+		case name == GRAPHML_KEY_POINTS:
+			record.Points, err = parsePolyline(fmt.Sprint(value.Value))
+		case strings.HasPrefix(value.Key.Id, graphMLEdgePrefix):
+			err = record.Properties.Set(name, value.Value)
+		default:
+			if cost, ok := graphMLFloat(value.Value); ok {
+				record.Cost[name] = cost
+			} else {
+				err = record.Properties.Set(name, value.Value)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(record.Cost) == 0 {
+		record.Cost = nil
+	}
+	if record.Properties.Len() == 0 {
+		record.Properties = nil
+	}
+	return record, nil
+}
+
+func graphMLAxisIndex(name string) int {
+	if index := slices.Index(graphMLAxes, name); index >= 0 {
+		return index
+	}
+	if index, err := strconv.Atoi(strings.TrimPrefix(name, "c")); err == nil && strings.HasPrefix(name, "c") && index >= len(graphMLAxes) {
+		return index
+	}
+	return -1
+}
+
+type graphMLValue struct {
+	Key   graphMLKey
+	Value any
+}
+
+// graphMLValues parses the data of an element, along with the defaults of its domain, by
+// attr.name. Keys without attr.name or attr.type are skipped.
+func graphMLValues(keys map[string]graphMLKey, domain string, data []graphMLData) (map[string]graphMLValue, error) {
+	values := make(map[string]graphMLValue)
+	for _, key := range keys {
+		if key.Default != nil && key.Name != "" && key.Type != "" && (key.For == domain || key.For == "all") {
+			value, err := parseGraphMLValue(key, *key.Default)
+			if err != nil {
+				return nil, err
+			}
+			values[key.Name] = graphMLValue{key, value}
+		}
+	}
+	for _, element := range data {
+		key, ok := keys[element.Key]
+		if !ok {
+			return nil, fmt.Errorf("undeclared GraphML key %s", element.Key)
+		}
+		if key.Name == "" || key.Type == "" {
+			continue
+		}
+		value, err := parseGraphMLValue(key, element.Value)
+		if err != nil {
+			return nil, err
+		}
+		values[key.Name] = graphMLValue{key, value}
+	}
+	return values, nil
+}
+
+func parseGraphMLValue(key graphMLKey, value string) (any, error) {
+	value = strings.TrimSpace(value)
+	switch key.Type {
+	case "boolean":
+		return strconv.ParseBool(value)
+	case "int", "long":
+		return strconv.ParseInt(value, 10, 64)
+	case "float", "double":
+		return strconv.ParseFloat(value, 64)
+	case "string":
+		return value, nil
+	}
+	return nil, fmt.Errorf("%w: attr.type %s of key %s", ErrGraphMLUnsupported, key.Type, key.Id)
+}
+
+func graphMLFloat(value any) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case int64:
+		return float64(number), true
+	}
+	return 0, false
+}
+
+func graphMLInt(value any) int64 {
+	switch number := value.(type) {
+	case int64:
+		return number
+	case float64:
+		return int64(number)
+	}
+	return -1
+}
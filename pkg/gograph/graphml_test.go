@@ -0,0 +1,151 @@
+package gograph
+
+import (
+	"bytes"
+	"errors"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"strings"
+	"testing"
+)
+
+func TestGraphML_RoundTrip(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertexWithId(gomath.Point{Values: []float64{0.0, 0.0}}, 1)
+	b := NewSimpleVertex(gomath.Point{Values: []float64{2.5, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{2.5, 2.0}})
+	a.Properties().SetString("name", "depot")
+	a.Properties().SetInt("capacity", 12)
+	edge := NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_TIME: 2.5, COST_TYPE_DISTANCE: 1.0 / 3.0})
+	edge.Properties().SetFloat("lanes", 2.0)
+	edge.Properties().SetBool("toll", true)
+	graph.AddEdge(edge)
+	graph.AddEdge(NewPolyEdge([]gomath.Spatial{&b, gomath.Point{Values: []float64{3.0, 1.0}}, &c}, 7))
+	graph.AddEdge(NewSimpleEdge(&c, &a, -1))
+
+	var buffer bytes.Buffer
+	if err := WriteGraphML(&buffer, graph); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadGraphML(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.IsDirected() || decoded.Hash() != graph.Hash() || !decoded.Validate().IsValid() {
+		t.Error("Expected the decoded graph to equal the graph")
+	}
+	if diff := DiffGraphs(graph, decoded); !diff.IsEmpty() {
+		t.Errorf("Expected no difference, got %+v", diff)
+	}
+	equalAdjacency(t, graph, decoded)
+	if toll, _ := GetProperties(GetEdge(decoded.GetVertex(1), decoded.GetVertex(VertexHashOrId(&b)))).GetBool("toll"); !toll {
+		t.Error("Expected the typed edge properties to round trip")
+	}
+}
+
+func TestGraphML_DirectedMultiGraph(t *testing.T) {
+	graph := NewDirectedMultiGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0, 1.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0, 1.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_DISTANCE: 5.0}))
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_DISTANCE: 2.0}))
+
+	var buffer bytes.Buffer
+	if err := WriteGraphML(&buffer, graph); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ReadGraphML(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.IsDirected() || !decoded.IsMultiGraph() || !DiffGraphs(graph, decoded).IsEmpty() {
+		t.Error("Expected the directed multigraph to round trip")
+	}
+}
+
+const networkXGraphML = `<?xml version="1.0" encoding="utf-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="d0" for="node" attr.name="x" attr.type="double"/>
+  <key id="d1" for="node" attr.name="y" attr.type="double"/>
+  <key id="d2" for="node" attr.name="label" attr.type="string"/>
+  <key id="d3" for="edge" attr.name="weight" attr.type="double">
+    <default>1.0</default>
+  </key>
+  <key id="d4" for="edge" attr.name="road" attr.type="string"/>
+  <key id="g0" for="node" yfiles.type="nodegraphics"/>
+  <graph edgedefault="undirected">
+    <node id="a"><data key="d0">0</data><data key="d1">0</data><data key="d2">Depot</data></node>
+    <node id="b"><data key="d0">3</data><data key="d1">4</data><data key="g0"><shape/></data></node>
+    <node id="c"/>
+    <node id="d"/>
+    <edge id="ab" source="a" target="b"><data key="d3">5</data><data key="d4">Main</data></edge>
+    <edge source="b" target="c"/>
+    <edge source="c" target="d"/>
+    <edge source="a" target="b"><data key="d3">7</data></edge>
+  </graph>
+</graphml>`
+
+func TestReadGraphML_NetworkX(t *testing.T) {
+	graph, err := ReadGraphML(strings.NewReader(networkXGraphML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graph.Size() != 4 || !graph.IsMultiGraph() || len(graph.GetEdges()) != 4 {
+		t.Errorf("Expected 4 vertices and 4 edges in a multigraph, got %d and %d", graph.Size(), len(graph.GetEdges()))
+	}
+	a, b := graph.GetVertexByKey("a"), graph.GetVertexByKey("b")
+	if b.X() != 3.0 || b.Y() != 4.0 {
+		t.Errorf("Expected b at (3, 4), got %v", b.GetValues())
+	}
+	if label, _ := GetProperties(a).GetString("label"); label != "Depot" {
+		t.Error("Expected the node label to become a property")
+	}
+	if VertexHashOrId(graph.GetVertexByKey("c")) == VertexHashOrId(graph.GetVertexByKey("d")) {
+		t.Error("Expected nodes without coordinates to stay distinct")
+	}
+	named := graph.GetEdgeByKey("ab")
+	if (*named.Cost())["weight"] != 5.0 {
+		t.Error("Expected the weight to become a cost")
+	}
+	if road, _ := GetProperties(named).GetString("road"); road != "Main" {
+		t.Error("Expected the road name to become a property")
+	}
+	if cost := GetEdge(b, graph.GetVertexByKey("c")).Cost(); cost == nil || (*cost)["weight"] != 1.0 {
+		t.Error("Expected the default weight")
+	}
+}
+
+func TestReadGraphML_EdgeIds(t *testing.T) {
+	document := `<graphml>
+  <key id="eid" for="edge" attr.name="edge_id" attr.type="long"/>
+  <key id="w" for="edge" attr.name="weight" attr.type="double"/>
+  <graph edgedefault="directed">
+    <node id="a"/><node id="b"/>
+    <edge source="a" target="b"><data key="w">5</data></edge>
+    <edge source="a" target="b"><data key="eid">1</data><data key="w">7</data></edge>
+  </graph>
+</graphml>`
+	graph, err := ReadGraphML(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.GetEdges()) != 2 || (*graph.GetEdge(1).Cost())["weight"] != 7.0 {
+		t.Errorf("Expected a generated id not to take the explicit id 1, got %d edges", len(graph.GetEdges()))
+	}
+}
+
+func TestReadGraphML_Unsupported(t *testing.T) {
+	documents := []string{
+		`<graphml><graph edgedefault="directed"><node id="a"/><hyperedge><endpoint node="a"/></hyperedge></graph></graphml>`,
+		`<graphml><graph edgedefault="directed"><node id="a"><graph edgedefault="directed"/></node></graph></graphml>`,
+		`<graphml><graph edgedefault="directed"><node id="a"/><node id="b"/><edge source="a" target="b" directed="false"/></graph></graphml>`,
+		`<graphml><graph edgedefault="directed"/><graph edgedefault="directed"/></graphml>`,
+	}
+	for i, document := range documents {
+		if _, err := ReadGraphML(strings.NewReader(document)); !errors.Is(err, ErrGraphMLUnsupported) {
+			t.Errorf("Expected document %d to be rejected, got %v", i, err)
+		}
+	}
+	if _, err := ReadGraphML(strings.NewReader(`<graphml><graph edgedefault="directed"><node id="a"/><edge source="a" target="z"/></graph></graphml>`)); err == nil {
+		t.Error("Expected an error for an edge to an unknown node")
+	}
+}
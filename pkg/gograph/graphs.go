@@ -1,8 +1,11 @@
 package gograph
 
 import (
+	"github.com/mtresnik/gomath/pkg/gomath"
 	"hash/fnv"
 	"maps"
+	"math"
+	"slices"
 	"time"
 )
 
@@ -21,6 +24,21 @@ type Graph interface {
 	Size() int
 	Clear()
 	Hash() int64
+	IsDirected() bool
+	InDegree(v Vertex) int
+	OutDegree(v Vertex) int
+	GetIncomingEdges(v Vertex) []Edge
+}
+
+// IdFromKey derives a stable, positive id from an external string key.
+func IdFromKey(key string) int64 {
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(key))
+	id := int64(hasher.Sum64() & math.MaxInt64)
+	if id == 0 {
+		return 1
+	}
+	return id
 }
 
 func GraphHashOrId(graph Graph) int64 {
@@ -30,19 +48,59 @@ func GraphHashOrId(graph Graph) int64 {
 	return graph.Hash()
 }
 
+// SimpleGraph stores vertices and edges in maps keyed by VertexHashOrId and EdgeHashOrId.
+// An undirected SimpleGraph stores every edge alongside its reverse, and AddEdge keeps the
+// adjacency of both endpoints in sync with the graph. An edge with an id names the undirected
+// edge in both directions, so only the edge as added is stored under that id.
+//
+// A multigraph, created with NewMultiGraph or NewDirectedMultiGraph, keeps parallel edges between
+// the same vertices apart: AddEdge gives every edge without an id a new one, and ContainsEdge and
+// RemoveEdge treat an edge without an id as every edge between its endpoints.
 type SimpleGraph struct {
-	id       int64
-	edges    map[int64]Edge
-	vertices map[int64]Vertex
-	hash     int64
+	id         int64
+	edges      map[int64]Edge
+	vertices   map[int64]Vertex
+	incoming   map[int64]map[int64]Edge
+	vertexKeys map[string]int64
+	edgeKeys   map[string]int64
+	directed   bool
+	multi      bool
+	nextEdgeId int64
+	index      *SpatialIndex
+	listeners  []GraphUpdateListener
+	hash       int64
 }
 
 func NewSimpleGraph() *SimpleGraph {
+	return newSimpleGraph(false)
+}
+
+func NewDirectedSimpleGraph() *SimpleGraph {
+	return newSimpleGraph(true)
+}
+
+func NewMultiGraph() *SimpleGraph {
+	graph := newSimpleGraph(false)
+	graph.multi = true
+	return graph
+}
+
+func NewDirectedMultiGraph() *SimpleGraph {
+	graph := newSimpleGraph(true)
+	graph.multi = true
+	return graph
+}
+
+func newSimpleGraph(directed bool) *SimpleGraph {
 	return &SimpleGraph{
-		id:       time.Now().UnixNano(),
-		edges:    make(map[int64]Edge),
-		vertices: make(map[int64]Vertex),
-		hash:     -1,
+		id:         time.Now().UnixNano(),
+		edges:      make(map[int64]Edge),
+		vertices:   make(map[int64]Vertex),
+		incoming:   make(map[int64]map[int64]Edge),
+		vertexKeys: make(map[string]int64),
+		edgeKeys:   make(map[string]int64),
+		directed:   directed,
+		hash:       -1,
 	}
 }
 
@@ -54,9 +112,33 @@ func (g *SimpleGraph) Size() int {
 	return len(g.vertices)
 }
 
+func (g *SimpleGraph) IsDirected() bool {
+	return g.directed
+}
+
+func (g *SimpleGraph) IsMultiGraph() bool {
+	return g.multi
+}
+
+// Clear removes every vertex and edge. Listeners are told about each removal.
 func (g *SimpleGraph) Clear() {
+	if len(g.listeners) > 0 {
+		for _, edge := range g.GetEdges() {
+			if g.storedEdge(edge) != nil {
+				g.RemoveEdge(edge)
+			}
+		}
+		for _, vertex := range g.GetVertices() {
+			g.RemoveVertex(vertex)
+		}
+	}
 	g.edges = make(map[int64]Edge)
 	g.vertices = make(map[int64]Vertex)
+	g.incoming = make(map[int64]map[int64]Edge)
+	g.vertexKeys = make(map[string]int64)
+	g.edgeKeys = make(map[string]int64)
+	g.index = nil
+	g.hash = -1
 }
 
 func (g *SimpleGraph) GetEdge(id int64) Edge {
@@ -67,11 +149,111 @@ func (g *SimpleGraph) GetVertex(id int64) Vertex {
 	return g.vertices[id]
 }
 
+// AddEdge adds e to the graph and to the adjacency of its from vertex, registering either
+// endpoint that is not yet part of the graph. Undirected graphs also add e.Reverse().
 func (g *SimpleGraph) AddEdge(e Edge) {
-	g.edges[EdgeHashOrId(e)] = e
+	g.addEdge(e, false)
+}
+
+// appendEdge adds e like AddEdge, but the caller guarantees that e is not in the graph yet, so the
+// adjacency of its endpoints is not searched. Readers use it to build large graphs in linear time.
+func (g *SimpleGraph) appendEdge(e Edge) {
+	g.addEdge(e, true)
+}
+
+func (g *SimpleGraph) addEdge(e Edge, unique bool) {
+	if g.multi && e.Id() == -1 {
+		e = withEdgeId(e, g.newEdgeId())
+	}
+	existed := !unique && g.storedEdge(e) != nil
+	stored := g.addDirectedEdge(e, true, unique)
+	if !g.directed {
+		reverse := e.Reverse()
+		g.addDirectedEdge(reverse, EdgeHashOrId(reverse) != EdgeHashOrId(e), unique)
+	}
+	if !existed {
+		VisitGraphUpdateListeners(g.listeners, GraphChange{Type: GRAPH_CHANGE_ADD_EDGE, Edge: stored})
+	}
+}
+
+// storedEdge returns the graph's copy of e in the direction of e, or nil.
+func (g *SimpleGraph) storedEdge(e Edge) Edge {
+	key := EdgeHashOrId(e)
+	fromKey := VertexHashOrId(ToVertex(e.From()))
+	if stored, ok := g.edges[key]; ok && VertexHashOrId(ToVertex(stored.From())) == fromKey {
+		return stored
+	}
+	if from := g.vertices[fromKey]; from != nil {
+		for _, edge := range from.GetEdges() {
+			if EdgeHashOrId(edge) == key {
+				return edge
+			}
+		}
+	}
+	return nil
+}
+
+func (g *SimpleGraph) addDirectedEdge(e Edge, register bool, unique bool) Edge {
+	from := g.resolveVertex(e.From())
+	to := g.resolveVertex(e.To())
+	e = rebindEdge(e, from, to)
+	key := EdgeHashOrId(e)
+	if register {
+		g.edges[key] = e
+	}
+	if unique || !vertexContainsEdge(from, e) {
+		from.AddEdge(e)
+	}
+	if vertex, ok := from.(*SimpleVertex); ok && g.multi {
+		vertex.multi = true
+	}
+	toKey := VertexHashOrId(to)
+	if g.incoming[toKey] == nil {
+		g.incoming[toKey] = make(map[int64]Edge)
+	}
+	g.incoming[toKey][key] = e
+	g.hash = -1
+	return e
+}
+
+func (g *SimpleGraph) newEdgeId() int64 {
+	for {
+		g.nextEdgeId++
+		if _, ok := g.edges[g.nextEdgeId]; !ok {
+			return g.nextEdgeId
+		}
+	}
+}
+
+// parallelEdges returns every edge of the graph from the endpoints of e to each other.
+func (g *SimpleGraph) parallelEdges(e Edge) []Edge {
+	from := g.vertices[VertexHashOrId(ToVertex(e.From()))]
+	if from == nil {
+		return []Edge{}
+	}
+	return GetEdges(from, ToVertex(e.To()))
+}
+
+// resolveVertex returns the graph's vertex at the location of spatial, adding it when missing.
+func (g *SimpleGraph) resolveVertex(spatial gomath.Spatial) Vertex {
+	vertex := ToVertex(spatial)
+	key := VertexHashOrId(vertex)
+	if existing, ok := g.vertices[key]; ok {
+		return existing
+	}
+	g.vertices[key] = vertex
+	if g.index != nil {
+		g.index.Insert(vertex)
+	}
+	g.hash = -1
+	VisitGraphUpdateListeners(g.listeners, GraphChange{Type: GRAPH_CHANGE_ADD_VERTEX, Vertex: vertex})
+	return vertex
 }
 
 func (g *SimpleGraph) ContainsEdge(e Edge) bool {
+	if g.multi && e.Id() == -1 {
+		return len(g.parallelEdges(e)) > 0
+	}
 	return g.edges[EdgeHashOrId(e)] != nil
 }
 
@@ -79,32 +261,244 @@ func (g *SimpleGraph) ContainsVertex(v Vertex) bool {
 	return g.vertices[VertexHashOrId(v)] != nil
 }
 
+// AddVertex adds v unless a vertex with the same hash or id is already present.
 func (g *SimpleGraph) AddVertex(v Vertex) {
-	g.vertices[VertexHashOrId(v)] = v
+	key := VertexHashOrId(v)
+	if _, ok := g.vertices[key]; ok {
+		return
+	}
+	g.vertices[key] = v
+	if g.index != nil {
+		g.index.Insert(v)
+	}
+	g.hash = -1
+	VisitGraphUpdateListeners(g.listeners, GraphChange{Type: GRAPH_CHANGE_ADD_VERTEX, Vertex: v})
 }
 
+// GetVertices returns the vertices ordered by VertexHashOrId, so that iteration is reproducible.
 func (g *SimpleGraph) GetVertices() []Vertex {
 	vertices := make([]Vertex, 0, len(g.vertices))
-	for _, v := range g.vertices {
-		vertices = append(vertices, v)
+	for _, key := range slices.Sorted(maps.Keys(g.vertices)) {
+		vertices = append(vertices, g.vertices[key])
 	}
 	return vertices
 }
 
+// GetEdges returns the edges ordered by EdgeHashOrId.
 func (g *SimpleGraph) GetEdges() []Edge {
 	edges := make([]Edge, 0, len(g.edges))
-	for _, e := range g.edges {
-		edges = append(edges, e)
+	for _, key := range slices.Sorted(maps.Keys(g.edges)) {
+		edges = append(edges, g.edges[key])
 	}
 	return edges
 }
 
+// SetVertexKey associates an external string key, such as a node id from another system, with v.
+func (g *SimpleGraph) SetVertexKey(key string, v Vertex) {
+	g.vertexKeys[key] = VertexHashOrId(v)
+}
+
+func (g *SimpleGraph) GetVertexByKey(key string) Vertex {
+	id, ok := g.vertexKeys[key]
+	if !ok {
+		return nil
+	}
+	return g.vertices[id]
+}
+
+func (g *SimpleGraph) SetEdgeKey(key string, e Edge) {
+	g.edgeKeys[key] = EdgeHashOrId(e)
+}
+
+func (g *SimpleGraph) GetEdgeByKey(key string) Edge {
+	id, ok := g.edgeKeys[key]
+	if !ok {
+		return nil
+	}
+	return g.edges[id]
+}
+
+func (g *SimpleGraph) InDegree(v Vertex) int {
+	return len(g.incoming[VertexHashOrId(v)])
+}
+
+func (g *SimpleGraph) OutDegree(v Vertex) int {
+	vertex := g.vertices[VertexHashOrId(v)]
+	if vertex == nil {
+		return 0
+	}
+	return len(vertex.GetEdges())
+}
+
+func (g *SimpleGraph) GetIncomingEdges(v Vertex) []Edge {
+	incoming := g.incoming[VertexHashOrId(v)]
+	edges := make([]Edge, 0, len(incoming))
+	for _, key := range slices.Sorted(maps.Keys(incoming)) {
+		edges = append(edges, incoming[key])
+	}
+	return edges
+}
+
+// adjacency returns copies of the outgoing and incoming edges of the vertex with key.
+func (g *SimpleGraph) adjacency(key int64) ([]Edge, []Edge, bool) {
+	vertex, ok := g.vertices[key]
+	if !ok {
+		return nil, nil, false
+	}
+	return append([]Edge{}, vertex.GetEdges()...), g.GetIncomingEdges(vertex), true
+}
+
 func (g *SimpleGraph) RemoveEdge(e Edge) {
-	delete(g.edges, EdgeHashOrId(e))
+	if g.multi && e.Id() == -1 {
+		for _, edge := range g.parallelEdges(e) {
+			g.RemoveEdge(edge)
+		}
+		return
+	}
+	stored := g.storedEdge(e)
+	if stored == nil {
+		return
+	}
+	g.removeDirectedEdge(stored)
+	if !g.directed {
+		g.removeDirectedEdge(stored.Reverse())
+	}
+	VisitGraphUpdateListeners(g.listeners, GraphChange{Type: GRAPH_CHANGE_REMOVE_EDGE, Edge: stored})
+}
+
+func (g *SimpleGraph) removeDirectedEdge(e Edge) {
+	key := EdgeHashOrId(e)
+	if stored, ok := g.edges[key]; ok && VertexHashOrId(ToVertex(stored.From())) == VertexHashOrId(ToVertex(e.From())) {
+		e = stored
+		delete(g.edges, key)
+	}
+	if from := g.vertices[VertexHashOrId(ToVertex(e.From()))]; from != nil {
+		from.RemoveEdge(e)
+	}
+	toKey := VertexHashOrId(ToVertex(e.To()))
+	if incoming, ok := g.incoming[toKey]; ok {
+		delete(incoming, key)
+		if len(incoming) == 0 {
+			delete(g.incoming, toKey)
+		}
+	}
+	g.hash = -1
 }
 
+// RemoveVertex removes v along with every edge that starts or ends at it, both from the
+// graph and from the adjacency of the neighbouring vertices.
 func (g *SimpleGraph) RemoveVertex(v Vertex) {
-	delete(g.vertices, VertexHashOrId(v))
+	key := VertexHashOrId(v)
+	vertex := g.vertices[key]
+	if vertex == nil {
+		return
+	}
+	edges := make([]Edge, 0, len(vertex.GetEdges()))
+	edges = append(edges, vertex.GetEdges()...)
+	edges = append(edges, g.GetIncomingEdges(vertex)...)
+	for _, edge := range edges {
+		if g.storedEdge(edge) != nil {
+			g.RemoveEdge(edge)
+		}
+	}
+	delete(g.incoming, key)
+	delete(g.vertices, key)
+	if g.index != nil {
+		g.index.Remove(vertex)
+	}
+	g.hash = -1
+	VisitGraphUpdateListeners(g.listeners, GraphChange{Type: GRAPH_CHANGE_REMOVE_VERTEX, Vertex: vertex})
+}
+
+// SetEdgeCost sets the cost of e for key, creating its cost map when it has none, and does nothing
+// when e is not in the graph. In an undirected graph the reverse edge shares the cost map.
+func (g *SimpleGraph) SetEdgeCost(e Edge, key string, value float64) {
+	stored := g.storedEdge(e)
+	if stored == nil {
+		return
+	}
+	change := GraphChange{Type: GRAPH_CHANGE_SET_COST, Edge: stored, Key: key, Value: value}
+	if stored.Cost() == nil {
+		cost := make(map[string]float64)
+		replacement := withCost(stored, &cost)
+		g.replaceDirectedEdge(stored, replacement)
+		if !g.directed {
+			if reverse := g.storedEdge(stored.Reverse()); reverse != nil {
+				g.replaceDirectedEdge(reverse, withCost(reverse, &cost))
+			}
+		}
+		stored = replacement
+		change.Edge = replacement
+	}
+	change.Previous, change.HasPrevious = (*stored.Cost())[key]
+	(*stored.Cost())[key] = value
+	g.hash = -1
+	VisitGraphUpdateListeners(g.listeners, change)
+}
+
+// RemoveEdgeCost removes the cost of e for key, if the edge is in the graph and has one.
+func (g *SimpleGraph) RemoveEdgeCost(e Edge, key string) {
+	stored := g.storedEdge(e)
+	if stored == nil || stored.Cost() == nil {
+		return
+	}
+	previous, ok := (*stored.Cost())[key]
+	if !ok {
+		return
+	}
+	delete(*stored.Cost(), key)
+	g.hash = -1
+	VisitGraphUpdateListeners(g.listeners, GraphChange{Type: GRAPH_CHANGE_REMOVE_COST, Edge: stored, Key: key, Previous: previous, HasPrevious: true})
+}
+
+// replaceDirectedEdge swaps old for replacement, which has the same key and endpoints, keeping
+// its position in the adjacency of its from vertex.
+func (g *SimpleGraph) replaceDirectedEdge(old Edge, replacement Edge) {
+	key := EdgeHashOrId(old)
+	fromKey := VertexHashOrId(ToVertex(old.From()))
+	if stored, ok := g.edges[key]; ok && VertexHashOrId(ToVertex(stored.From())) == fromKey {
+		g.edges[key] = replacement
+	}
+	if from, ok := g.vertices[fromKey].(*SimpleVertex); ok {
+		for i, edge := range from.Edges {
+			if EdgeHashOrId(edge) == key {
+				from.Edges[i] = replacement
+			}
+		}
+	} else if from != nil {
+		from.RemoveEdge(old)
+		from.AddEdge(replacement)
+	}
+	if incoming, ok := g.incoming[VertexHashOrId(ToVertex(old.To()))]; ok {
+		incoming[key] = replacement
+	}
+}
+
+func (g *SimpleGraph) AddUpdateListener(listener GraphUpdateListener) {
+	g.listeners = append(g.listeners, listener)
+}
+
+func (g *SimpleGraph) RemoveUpdateListener(listener GraphUpdateListener) {
+	for i, existing := range g.listeners {
+		if existing == listener {
+			g.listeners = append(g.listeners[:i:i], g.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+// SpatialIndex returns an index of the vertices of the graph, building it on first use. The graph
+// keeps it up to date as vertices are added and removed. Build it before sharing the graph
+// between goroutines.
+func (g *SimpleGraph) SpatialIndex() *SpatialIndex {
+	if g.index == nil {
+		g.index = NewSpatialIndex(g)
+	}
+	return g.index
+}
+
+func (g *SimpleGraph) Validate() GraphValidationReport {
+	return ValidateGraph(g)
 }
 
 func (g *SimpleGraph) SetId(id int64) {
@@ -125,6 +519,8 @@ func (g *SimpleGraph) Hash() int64 {
 	for edgeKey := range edgeKeysIter {
 		edgeKeys = append(edgeKeys, edgeKey)
 	}
+	slices.Sort(vertexKeys)
+	slices.Sort(edgeKeys)
 	allKeys := make([]int64, 0, len(g.vertices)+len(g.edges))
 	allKeys = append(allKeys, vertexKeys...)
 	allKeys = append(allKeys, edgeKeys...)
@@ -143,3 +539,40 @@ func (g *SimpleGraph) Hash() int64 {
 	g.hash = int64(hasher.Sum64())
 	return g.hash
 }
+
+type GraphValidationReport struct {
+	// DanglingEdges are referenced by a vertex's adjacency but are not part of the graph.
+	DanglingEdges []Edge
+	// MissingEndpointEdges are part of the graph but start or end at a vertex that is not.
+	MissingEndpointEdges []Edge
+	// MissingReverseEdges are part of an undirected graph that does not contain their reverse.
+	MissingReverseEdges []Edge
+}
+
+func (r GraphValidationReport) IsValid() bool {
+	return len(r.DanglingEdges) == 0 && len(r.MissingEndpointEdges) == 0 && len(r.MissingReverseEdges) == 0
+}
+
+func ValidateGraph(graph Graph) GraphValidationReport {
+	report := GraphValidationReport{
+		DanglingEdges:        make([]Edge, 0),
+		MissingEndpointEdges: make([]Edge, 0),
+		MissingReverseEdges:  make([]Edge, 0),
+	}
+	for _, vertex := range graph.GetVertices() {
+		for _, edge := range vertex.GetEdges() {
+			if !graph.ContainsEdge(edge) {
+				report.DanglingEdges = append(report.DanglingEdges, edge)
+			}
+		}
+	}
+	for _, edge := range graph.GetEdges() {
+		if !graph.ContainsVertex(ToVertex(edge.From())) || !graph.ContainsVertex(ToVertex(edge.To())) {
+			report.MissingEndpointEdges = append(report.MissingEndpointEdges, edge)
+		}
+		if !graph.IsDirected() && !graph.ContainsEdge(edge.Reverse()) {
+			report.MissingReverseEdges = append(report.MissingReverseEdges, edge)
+		}
+	}
+	return report
+}
@@ -0,0 +1,237 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"testing"
+)
+
+func TestSimpleGraph_AddEdgeUndirected(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	edge := NewSimpleEdge(&a, &b, -1)
+	graph.AddEdge(edge)
+	if graph.IsDirected() {
+		t.Error("NewSimpleGraph should be undirected")
+	}
+	if len(graph.GetVertices()) != 2 || len(graph.GetEdges()) != 2 {
+		t.Errorf("Expected 2 vertices and 2 edges, got %d and %d", len(graph.GetVertices()), len(graph.GetEdges()))
+	}
+	if !graph.ContainsEdge(edge.Reverse()) {
+		t.Error("Undirected graph is missing the reverse edge")
+	}
+	if len(a.GetEdges()) != 1 || len(b.GetEdges()) != 1 {
+		t.Errorf("Expected one edge on each vertex, got %d and %d", len(a.GetEdges()), len(b.GetEdges()))
+	}
+	if graph.InDegree(&a) != 1 || graph.OutDegree(&a) != 1 {
+		t.Errorf("Expected in and out degree 1, got %d and %d", graph.InDegree(&a), graph.OutDegree(&a))
+	}
+	graph.AddEdge(edge)
+	if len(a.GetEdges()) != 1 {
+		t.Error("Adding the same edge twice should not duplicate adjacency")
+	}
+}
+
+func TestSimpleGraph_AddEdgeDirected(t *testing.T) {
+	graph := NewDirectedSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 1.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	graph.AddEdge(NewSimpleEdge(&c, &b, -1))
+	if len(graph.GetEdges()) != 2 {
+		t.Errorf("Expected 2 edges, got %d", len(graph.GetEdges()))
+	}
+	if len(b.GetEdges()) != 0 {
+		t.Error("Directed graph should not add the reverse edge to the destination")
+	}
+	if graph.InDegree(&b) != 2 || graph.OutDegree(&b) != 0 {
+		t.Errorf("Expected in degree 2 and out degree 0, got %d and %d", graph.InDegree(&b), graph.OutDegree(&b))
+	}
+	if len(graph.GetIncomingEdges(&b)) != 2 {
+		t.Errorf("Expected 2 incoming edges, got %d", len(graph.GetIncomingEdges(&b)))
+	}
+	graph.RemoveEdge(NewSimpleEdge(&a, &b, -1))
+	if graph.InDegree(&b) != 1 {
+		t.Errorf("Expected in degree 1 after removal, got %d", graph.InDegree(&b))
+	}
+}
+
+func TestSimpleGraph_AddEdgeFromPoints(t *testing.T) {
+	graph := NewSimpleGraph()
+	graph.AddEdge(NewEdge(gomath.Point{Values: []float64{0.0, 0.0}}, gomath.Point{Values: []float64{1.0, 0.0}}))
+	graph.AddEdge(NewEdge(gomath.Point{Values: []float64{1.0, 0.0}}, gomath.Point{Values: []float64{2.0, 0.0}}))
+	vertices := graph.GetVertices()
+	if len(vertices) != 3 {
+		t.Fatalf("Expected 3 vertices, got %d", len(vertices))
+	}
+	middle := graph.GetVertex(HashVertex(VertexFromSpatial(gomath.Point{Values: []float64{1.0, 0.0}})))
+	if middle == nil || len(middle.GetEdges()) != 2 {
+		t.Fatal("Expected the shared endpoint to be a single vertex with two edges")
+	}
+	for _, edge := range middle.GetEdges() {
+		if len(ToVertex(edge.To()).GetEdges()) == 0 {
+			t.Error("Edge endpoints should resolve to the graph's vertices")
+		}
+	}
+}
+
+func TestSimpleGraph_RemoveVertex(t *testing.T) {
+	graph := BoundedGridGraphProvider{
+		BoundingBox: gomath.BoundingBox{MinX: 0, MinY: 0, MaxX: 3, MaxY: 3},
+		Width:       3,
+		Height:      3,
+	}.Build()
+	center := graph.GetVertex(HashVertex(VertexFromSpatial(gomath.Point{Values: []float64{1.0, 1.0}})))
+	if center == nil {
+		t.Fatal("Expected a center vertex")
+	}
+	numEdges := len(graph.GetEdges())
+	graph.RemoveVertex(center)
+	if graph.ContainsVertex(center) {
+		t.Error("Center vertex was not removed")
+	}
+	if len(graph.GetEdges()) != numEdges-8 {
+		t.Errorf("Expected %d edges, got %d", numEdges-8, len(graph.GetEdges()))
+	}
+	for _, vertex := range graph.GetVertices() {
+		for _, edge := range vertex.GetEdges() {
+			if VertexHashOrId(ToVertex(edge.To())) == VertexHashOrId(center) {
+				t.Error("Neighbor still references the removed vertex")
+			}
+		}
+	}
+	report := ValidateGraph(graph)
+	if !report.IsValid() {
+		t.Errorf("Expected a valid graph, got %+v", report)
+	}
+}
+
+func TestSimpleGraph_Validate(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{2.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	a.AddEdge(NewSimpleEdge(&a, &c, -1))
+	graph.edges[EdgeHashOrId(NewSimpleEdge(&b, &c, -1))] = NewSimpleEdge(&b, &c, -1)
+	report := graph.Validate()
+	if len(report.DanglingEdges) != 1 {
+		t.Errorf("Expected 1 dangling edge, got %d", len(report.DanglingEdges))
+	}
+	if len(report.MissingEndpointEdges) != 1 {
+		t.Errorf("Expected 1 edge with a missing endpoint, got %d", len(report.MissingEndpointEdges))
+	}
+	if len(report.MissingReverseEdges) != 1 {
+		t.Errorf("Expected 1 edge missing its reverse, got %d", len(report.MissingReverseEdges))
+	}
+}
+
+func TestSimpleGraph_VertexIds(t *testing.T) {
+	graph := NewSimpleGraph()
+	lower := NewSimpleVertexWithId(gomath.Point{Values: []float64{0.0, 0.0}}, 1)
+	upper := NewSimpleVertexWithId(gomath.Point{Values: []float64{0.0, 0.0}}, 2)
+	east := NewSimpleVertexWithId(gomath.Point{Values: []float64{1.0, 0.0}}, 3)
+	graph.AddEdge(NewSimpleEdge(&lower, &east, 10))
+	graph.AddEdge(NewSimpleEdge(&upper, &lower, 11))
+	graph.SetVertexKey("platform-2", &upper)
+	if graph.Size() != 3 {
+		t.Fatalf("Vertices at the same location collapsed, got %d vertices", graph.Size())
+	}
+	if graph.GetVertexByKey("platform-2") != &upper {
+		t.Error("Expected lookup by key to return the upper vertex")
+	}
+	if len(graph.GetEdges()) != 2 || graph.GetEdge(10).Id() != 10 {
+		t.Errorf("Expected 2 edges stored by id, got %d", len(graph.GetEdges()))
+	}
+	if VertexHashOrId(ToVertex(graph.GetEdge(10).From())) != 1 {
+		t.Error("Edge stored under an id should keep the direction it was added in")
+	}
+	response := BFS(RoutingAlgorithmRequest{Start: &upper, Destination: &east})
+	if response.Path.Length() != 2 {
+		t.Errorf("Expected a path of 2 edges, got %d", response.Path.Length())
+	}
+	mst := KruskalMST(MSTRequest{Graph: graph})
+	if mst.Graph.Size() != 3 || mst.Graph.GetVertex(2) == nil {
+		t.Error("Expected the MST to keep vertex ids")
+	}
+	graph.RemoveEdge(graph.GetEdge(11))
+	if len(lower.GetEdges()) != 1 || len(upper.GetEdges()) != 0 {
+		t.Errorf("Expected both directions of edge 11 to be removed, got %d and %d", len(lower.GetEdges()), len(upper.GetEdges()))
+	}
+	if !graph.Validate().IsValid() {
+		t.Errorf("Expected a valid graph, got %+v", graph.Validate())
+	}
+}
+
+func TestIdFromKey(t *testing.T) {
+	if IdFromKey("node/42") != IdFromKey("node/42") || IdFromKey("node/42") <= 0 {
+		t.Error("Expected a stable positive id")
+	}
+	if IdFromKey("node/42") == IdFromKey("node/43") {
+		t.Error("Expected distinct keys to produce distinct ids")
+	}
+}
+
+func TestMultiGraph_ParallelEdges(t *testing.T) {
+	graph := NewMultiGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_DISTANCE: 3.0}))
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_DISTANCE: 1.0}))
+	graph.AddEdge(NewSimpleEdge(&a, &b, 42))
+	graph.AddEdge(NewSimpleEdge(&a, &b, 42))
+	if len(graph.GetEdges()) != 3 {
+		t.Errorf("Expected 3 parallel edges, got %d", len(graph.GetEdges()))
+	}
+	if len(GetEdges(&a, &b)) != 3 || len(GetEdges(&b, &a)) != 3 {
+		t.Errorf("Expected 3 edges in each direction, got %d and %d", len(GetEdges(&a, &b)), len(GetEdges(&b, &a)))
+	}
+	ids := make(map[int64]bool)
+	for _, edge := range GetEdges(&a, &b) {
+		ids[edge.Id()] = true
+	}
+	if len(ids) != 3 || !ids[42] {
+		t.Errorf("Expected distinct ids, got %v", ids)
+	}
+	if !graph.Validate().IsValid() {
+		t.Error("Multigraph should be valid")
+	}
+	graph.RemoveEdge(NewSimpleEdge(&a, &b, 42))
+	if len(GetEdges(&a, &b)) != 2 || len(GetEdges(&b, &a)) != 2 {
+		t.Error("Removing an edge by id should keep its parallel edges")
+	}
+	if !graph.ContainsEdge(NewSimpleEdge(&a, &b, -1)) {
+		t.Error("An edge without an id should match its parallel edges")
+	}
+	graph.RemoveEdge(NewSimpleEdge(&b, &a, -1))
+	if len(graph.GetEdges()) != 0 || len(a.GetEdges()) != 0 {
+		t.Errorf("Removing an edge without an id should remove every parallel edge, %d left", len(graph.GetEdges()))
+	}
+}
+
+func TestSimpleGraph_Hash(t *testing.T) {
+	build := func(order []int) int64 {
+		graph := NewSimpleGraph()
+		for _, i := range order {
+			a := NewSimpleVertex(gomath.Point{Values: []float64{float64(i), 0.0}})
+			b := NewSimpleVertex(gomath.Point{Values: []float64{float64(i), 1.0}})
+			graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+		}
+		return graph.Hash()
+	}
+	if build([]int{0, 1, 2, 3, 4, 5, 6, 7}) != build([]int{7, 3, 5, 1, 0, 6, 2, 4}) {
+		t.Error("Expected the hash not to depend on the order of insertion")
+	}
+}
+
+func TestSimpleGraph_VertexLiterals(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := &SimpleVertex{Spatial: gomath.Point{Values: []float64{0.0, 0.0}}}
+	b := &SimpleVertex{Spatial: gomath.Point{Values: []float64{5.0, 5.0}}}
+	graph.AddVertex(a)
+	graph.AddVertex(b)
+	if a.Hash() == b.Hash() || graph.Size() != 2 {
+		t.Errorf("Expected vertex literals to be hashed apart, got %d vertices", graph.Size())
+	}
+}
@@ -3,12 +3,12 @@ package gograph
 import (
 	"github.com/mtresnik/goutils/pkg/goutils"
 	"math/rand"
-	"time"
 )
 
 type MazeGeneratorRequest struct {
 	Rows, Cols          int
 	MazeUpdateListeners *[]MazeUpdateListener
+	Random              *rand.Rand
 }
 
 type MazeUpdateListener interface {
@@ -49,7 +49,7 @@ var AldousBroderMazeGenerator = func(request MazeGeneratorRequest) MazeGenerator
 	VisitMazeUpdateListeners(mazeUpdateListeners, MazeGeneratorResponse{maze, visitedHashes, false})
 
 	allCells := maze.Flatten()
-	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	random := randomOrDefault(request.Random)
 	random.Shuffle(len(allCells), func(i, j int) {
 		allCells[i], allCells[j] = allCells[j], allCells[i]
 	})
@@ -5,3 +5,17 @@ import "testing"
 func TestAldousBroderMazeGenerator_Build(t *testing.T) {
 	AldousBroderMazeGenerator(NewMazeGeneratorRequest(10, 10))
 }
+
+func TestAldousBroderMazeGenerator_Seeded(t *testing.T) {
+	build := func(seed int64) Graph {
+		request := NewMazeGeneratorRequest(15, 15)
+		request.Random = NewSeededRandom(seed)
+		return MazeToGraphProvider{AldousBroderMazeGenerator(request).Maze}.Build()
+	}
+	if build(3).Hash() != build(3).Hash() {
+		t.Error("Expected the same seed to generate the same maze")
+	}
+	if build(3).Hash() == build(4).Hash() {
+		t.Error("Expected a different seed to generate a different maze")
+	}
+}
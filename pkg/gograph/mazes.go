@@ -38,10 +38,10 @@ func EqualsMazeCoordinate(a, b MazeCoordinate) bool {
 }
 
 type MazeCell struct {
-	Row        int
-	Col        int
-	LeftIsWall bool
-	UpIsWall   bool
+	Row        int  `json:"row"`
+	Col        int  `json:"col"`
+	LeftIsWall bool `json:"left_is_wall"`
+	UpIsWall   bool `json:"up_is_wall"`
 }
 
 func (m MazeCell) GetRow() int {
@@ -72,10 +72,11 @@ func (m MazeCell) Hash() int64 {
 	return HashMazeCoordinate(m)
 }
 
+// Maze encodes to JSON as {"rows": 2, "cols": 2, "cells": [[{"row": 0, "col": 0, "left_is_wall": true, "up_is_wall": true}, ...], ...]}.
 type Maze struct {
-	Rows  int
-	Cols  int
-	Cells [][]MazeCell
+	Rows  int          `json:"rows"`
+	Cols  int          `json:"cols"`
+	Cells [][]MazeCell `json:"cells"`
 }
 
 func NewMaze(rows, cols int) Maze {
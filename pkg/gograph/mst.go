@@ -1,26 +1,32 @@
 package gograph
 
 import (
+	"errors"
 	"fmt"
 	"github.com/mtresnik/goutils/pkg/goutils"
 	"math"
 	"math/rand"
 	"sort"
-	"time"
 )
 
 type MSTRequest struct {
-	Graph Graph
+	Graph  Graph
+	Random *rand.Rand
 }
 
 type MSTResponse struct {
 	Graph Graph
+	Error error
 }
 
+var ErrDirectedGraph = errors.New("minimum spanning trees require an undirected graph")
+
 type MST func(MSTRequest) MSTResponse
 
 var KruskalMST MST = func(request MSTRequest) MSTResponse {
-	retGraph := NewSimpleGraph()
+	if request.Graph.IsDirected() {
+		return MSTResponse{Error: ErrDirectedGraph}
+	}
 	allSets := make([][]Vertex, 0)
 	sortedEdges := make([]Edge, 0)
 	visitedSortedEdges := map[int64]bool{}
@@ -29,9 +35,9 @@ var KruskalMST MST = func(request MSTRequest) MSTResponse {
 	for _, vertex := range request.Graph.GetVertices() {
 		for _, edge := range vertex.GetEdges() {
 			// Ensure no duplicate edges are added
-			if !goutils.SetContains(visitedSortedEdges, edge.Hash()) {
+			if !goutils.SetContains(visitedSortedEdges, EdgeHashOrId(edge)) {
 				sortedEdges = append(sortedEdges, edge)
-				visitedSortedEdges[edge.Hash()] = true
+				visitedSortedEdges[EdgeHashOrId(edge)] = true
 			}
 		}
 		allSets = append(allSets, []Vertex{vertex})
@@ -54,12 +60,12 @@ var KruskalMST MST = func(request MSTRequest) MSTResponse {
 		sortedEdges = sortedEdges[1:] // Remove the first edge
 
 		// fromIndex := indexOfSetContainingVertex(allSets, currentEdge.From)
-		fromIndex := indexOfSetContainingVertex(allSets, VertexFromSpatial(currentEdge.From()))
+		fromIndex := indexOfSetContainingVertex(allSets, ToVertex(currentEdge.From()))
 		if fromIndex != -1 {
 			fromSet := allSets[fromIndex]
 			allSets = append(allSets[:fromIndex], allSets[fromIndex+1:]...)
 
-			toIndex := indexOfSetContainingVertex(allSets, VertexFromSpatial(currentEdge.To()))
+			toIndex := indexOfSetContainingVertex(allSets, ToVertex(currentEdge.To()))
 
 			if toIndex != -1 {
 				toSet := allSets[toIndex]
@@ -69,21 +75,21 @@ var KruskalMST MST = func(request MSTRequest) MSTResponse {
 				joinedSet := map[int64]bool{}
 				joined := make([]Vertex, 0)
 				for _, vertex := range fromSet {
-					if !goutils.SetContains(joinedSet, vertex.Hash()) {
-						joinedSet[vertex.Hash()] = true
+					if !goutils.SetContains(joinedSet, VertexHashOrId(vertex)) {
+						joinedSet[VertexHashOrId(vertex)] = true
 						joined = append(joined, vertex)
 					}
 				}
 				for _, vertex := range toSet {
-					if !goutils.SetContains(joinedSet, vertex.Hash()) {
-						joinedSet[vertex.Hash()] = true
+					if !goutils.SetContains(joinedSet, VertexHashOrId(vertex)) {
+						joinedSet[VertexHashOrId(vertex)] = true
 						joined = append(joined, vertex)
 					}
 				}
 				allSets = append(allSets, joined)
 
 				validEdges = append(validEdges, currentEdge)
-				validEdgesSet[currentEdge.Hash()] = true
+				validEdgesSet[EdgeHashOrId(currentEdge)] = true
 			} else {
 				allSets = append(allSets, fromSet)
 			}
@@ -95,23 +101,25 @@ var KruskalMST MST = func(request MSTRequest) MSTResponse {
 		fmt.Println("Graph is disjoint!")
 	}
 
-	// Save valid edges and construct the resulting graph
-	for _, edge := range validEdges {
-		cloned := NewSimpleEdge(edge.From(), edge.To(), -1)
-		retGraph.AddEdge(cloned)
-		from := VertexFromSpatial(cloned.From())
-		from.AddEdge(cloned)
-		to := VertexFromSpatial(cloned.To())
-		retGraph.AddVertex(from)
-		retGraph.AddVertex(to)
+	return MSTResponse{Graph: buildForest(validEdges)}
+}
+
+// buildForest copies the endpoints of edges into new vertices so the input graph is left untouched.
+func buildForest(edges []Edge) *SimpleGraph {
+	retGraph := NewSimpleGraph()
+	for _, edge := range edges {
+		from, to := ToVertex(edge.From()), ToVertex(edge.To())
+		copiedFrom := NewSimpleVertexWithId(from, from.Id())
+		copiedTo := NewSimpleVertexWithId(to, to.Id())
+		retGraph.AddEdge(NewSimpleEdge(&copiedFrom, &copiedTo, -1))
 	}
-	return MSTResponse{Graph: retGraph}
+	return retGraph
 }
 
 func indexOfSetContainingVertex(sets [][]Vertex, vertex Vertex) int {
 	for i, set := range sets {
 		for _, v := range set {
-			if v.Hash() == vertex.Hash() {
+			if VertexHashOrId(v) == VertexHashOrId(vertex) {
 				return i
 			}
 		}
@@ -120,8 +128,10 @@ func indexOfSetContainingVertex(sets [][]Vertex, vertex Vertex) int {
 }
 
 func PrimsMST(request MSTRequest) MSTResponse {
-	random := rand.New(rand.NewSource(time.Now().UnixNano()))
-	retGraph := NewSimpleGraph()
+	if request.Graph.IsDirected() {
+		return MSTResponse{Error: ErrDirectedGraph}
+	}
+	random := randomOrDefault(request.Random)
 	cheapestVertexCost := map[int64]float64{}
 	edgeProvidingConnection := map[int64]*Edge{}
 	graphVertices := request.Graph.GetVertices()
@@ -129,9 +139,9 @@ func PrimsMST(request MSTRequest) MSTResponse {
 	notVisitedSet := map[int64]bool{}
 	copy(notVisited, graphVertices)
 	for _, vertex := range notVisited {
-		cheapestVertexCost[vertex.Hash()] = math.MaxFloat64
-		edgeProvidingConnection[vertex.Hash()] = nil
-		notVisitedSet[vertex.Hash()] = true
+		cheapestVertexCost[VertexHashOrId(vertex)] = math.MaxFloat64
+		edgeProvidingConnection[VertexHashOrId(vertex)] = nil
+		notVisitedSet[VertexHashOrId(vertex)] = true
 	}
 
 	edgeForest := make([]Edge, 0)
@@ -140,8 +150,8 @@ func PrimsMST(request MSTRequest) MSTResponse {
 		toRemoveIndex := -1
 		cheapestCost := math.MaxFloat64
 		for i, vertex := range notVisited {
-			if cheapestVertexCost[vertex.Hash()] < cheapestCost {
-				cheapestCost = cheapestVertexCost[vertex.Hash()]
+			if cheapestVertexCost[VertexHashOrId(vertex)] < cheapestCost {
+				cheapestCost = cheapestVertexCost[VertexHashOrId(vertex)]
 				toRemoveIndex = i
 			}
 		}
@@ -150,34 +160,24 @@ func PrimsMST(request MSTRequest) MSTResponse {
 		}
 		removed := notVisited[toRemoveIndex]
 		notVisited = append(notVisited[:toRemoveIndex], notVisited[toRemoveIndex+1:]...)
-		notVisitedSet[removed.Hash()] = false
-		edgeRemoved, ok := edgeProvidingConnection[removed.Hash()]
+		notVisitedSet[VertexHashOrId(removed)] = false
+		edgeRemoved, ok := edgeProvidingConnection[VertexHashOrId(removed)]
 		if ok && edgeRemoved != nil {
 			edgeForest = append(edgeForest, *edgeRemoved)
 		}
 		for _, edge := range removed.GetEdges() {
-			if goutils.SetContains(notVisitedSet, VertexFromSpatial(edge.To()).Hash()) {
+			if goutils.SetContains(notVisitedSet, VertexHashOrId(ToVertex(edge.To()))) {
 				newCost := edge.DistanceCached()
-				cheapestToCost, ok := cheapestVertexCost[VertexFromSpatial(edge.To()).Hash()]
+				cheapestToCost, ok := cheapestVertexCost[VertexHashOrId(ToVertex(edge.To()))]
 				if !ok {
 					cheapestToCost = math.MaxFloat64
 				}
 				if newCost < cheapestToCost {
-					cheapestVertexCost[VertexFromSpatial(edge.To()).Hash()] = newCost
-					edgeProvidingConnection[VertexFromSpatial(edge.To()).Hash()] = &edge
+					cheapestVertexCost[VertexHashOrId(ToVertex(edge.To()))] = newCost
+					edgeProvidingConnection[VertexHashOrId(ToVertex(edge.To()))] = &edge
 				}
 			}
 		}
 	}
-	for _, edge := range edgeForest {
-		cloned := NewSimpleEdge(edge.From(), edge.To(), -1)
-		retGraph.AddEdge(cloned)
-		from := VertexFromSpatial(cloned.From())
-		from.AddEdge(cloned)
-		to := VertexFromSpatial(cloned.To())
-		retGraph.AddVertex(from)
-		retGraph.AddVertex(to)
-	}
-
-	return MSTResponse{Graph: retGraph}
+	return MSTResponse{Graph: buildForest(edgeForest)}
 }
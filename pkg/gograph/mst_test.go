@@ -64,3 +64,26 @@ func TestPrimsMST(t *testing.T) {
 	defer file.Close()
 	png.Encode(file, img)
 }
+
+func TestKruskalMST_Directed(t *testing.T) {
+	graph := NewDirectedSimpleGraph()
+	graph.AddEdge(NewEdge(gomath.Point{Values: []float64{0.0, 0.0}}, gomath.Point{Values: []float64{1.0, 0.0}}))
+	response := KruskalMST(MSTRequest{Graph: graph})
+	if response.Error != ErrDirectedGraph {
+		t.Errorf("Expected ErrDirectedGraph, got %v", response.Error)
+	}
+}
+
+func TestPrimsMST_Seeded(t *testing.T) {
+	graph := BoundedRandomGraphProvider{
+		BoundingBox:    gomath.BoundingBox{MinX: 10, MinY: 10, MaxX: 20, MaxY: 20},
+		NumPoints:      80,
+		NumConnections: 3,
+		Random:         NewSeededRandom(5),
+	}.Build()
+	first := PrimsMST(MSTRequest{Graph: graph, Random: NewSeededRandom(9)})
+	second := PrimsMST(MSTRequest{Graph: graph, Random: NewSeededRandom(9)})
+	if first.Graph.Hash() != second.Graph.Hash() {
+		t.Error("Expected the same seed to produce the same tree")
+	}
+}
@@ -0,0 +1,325 @@
+package gograph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Edge properties set by ReadOSM.
+const (
+	OSM_PROPERTY_WAY_ID  = "way_id"
+	OSM_PROPERTY_HIGHWAY = "highway"
+	OSM_PROPERTY_NAME    = "name"
+)
+
+// OSM_DEFAULT_SPEEDS is the speed in km/h assumed for each highway class when a way has no usable
+// maxspeed tag. ReadOSM imports the highway classes listed here unless told otherwise.
+var OSM_DEFAULT_SPEEDS = map[string]float64{
+	"motorway":       110,
+	"motorway_link":  60,
+	"trunk":          90,
+	"trunk_link":     50,
+	"primary":        70,
+	"primary_link":   50,
+	"secondary":      60,
+	"secondary_link": 40,
+	"tertiary":       50,
+	"tertiary_link":  30,
+	"unclassified":   40,
+	"residential":    30,
+	"living_street":  10,
+	"service":        20,
+	"road":           40,
+	"track":          15,
+	"pedestrian":     5,
+	"footway":        5,
+	"path":           5,
+	"steps":          3,
+	"cycleway":       15,
+	"bridleway":      5,
+}
+
+// OSMOptions configures ReadOSM. Highways lists the highway classes to import and defaults to the
+// classes of OSM_DEFAULT_SPEEDS, and Speeds overrides their default speeds in km/h.
+type OSMOptions struct {
+	Highways []string
+	Speeds   map[string]float64
+}
+
+type osmWay struct {
+	id   int64
+	refs []int64
+	tags map[string]string
+}
+
+// ReadOSM streams an OpenStreetMap XML file into a directed SimpleGraph of its road network. Each
+// highway way is split at the nodes it shares with other highway ways, and at its ends, into
+// PolyEdges between vertices whose id is the OSM node id and whose coordinates are [longitude,
+// latitude], so the graph works with HaversineDistanceCostFunction.
+//
+// Two way roads get an edge in each direction, while oneway tags, and the oneway implied by
+// motorways and roundabouts, keep only one. Every edge has a distance cost in meters and a time
+// cost in seconds at the speed of its maxspeed tag, or the default speed of its highway class, and
+// way_id, highway and name properties. Ways are split where they refer to nodes missing from the
+// file, as in clipped extracts.
+func ReadOSM(reader io.Reader, options ...OSMOptions) (*SimpleGraph, error) {
+	resolved := OSMOptions{}
+	if len(options) > 0 {
+		resolved = options[0]
+	}
+	speeds := make(map[string]float64, len(OSM_DEFAULT_SPEEDS))
+	for highway, speed := range OSM_DEFAULT_SPEEDS {
+		if resolved.Highways == nil || slices.Contains(resolved.Highways, highway) {
+			speeds[highway] = speed
+		}
+	}
+	for _, highway := range resolved.Highways {
+		if _, ok := speeds[highway]; !ok {
+			speeds[highway] = OSM_DEFAULT_SPEEDS["unclassified"]
+		}
+	}
+	for highway, speed := range resolved.Speeds {
+		if _, ok := speeds[highway]; ok {
+			speeds[highway] = speed
+		}
+	}
+
+	nodes := make(map[int64][]float64)
+	ways := make([]osmWay, 0)
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "osm", "osmChange", "create", "modify":
+			continue
+		case "node":
+			if id, position, err := parseOSMNode(start); err != nil {
+				return nil, err
+			} else if !isOSMDeleted(start) {
+				nodes[id] = position
+			}
+		case "way":
+			way, err := parseOSMWay(decoder, start)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := speeds[way.tags["highway"]]; ok && way.tags["area"] != "yes" && !isOSMDeleted(start) {
+				ways = append(ways, way)
+			}
+			continue
+		}
+		if err := decoder.Skip(); err != nil {
+			return nil, err
+		}
+	}
+
+	uses := make(map[int64]int)
+	for _, way := range ways {
+		for _, ref := range way.refs {
+			uses[ref]++
+		}
+	}
+	graph := NewDirectedSimpleGraph()
+	vertices := make(map[int64]*SimpleVertex)
+	vertexAt := func(ref int64) *SimpleVertex {
+		if vertex, ok := vertices[ref]; ok {
+			return vertex
+		}
+		vertex := VertexRecord{Id: ref, Values: nodes[ref]}.Vertex()
+		vertices[ref] = vertex
+		graph.AddVertex(vertex)
+		return vertex
+	}
+	for _, way := range ways {
+		speed := speeds[way.tags["highway"]]
+		if maxspeed, ok := parseOSMSpeed(way.tags["maxspeed"]); ok {
+			speed = maxspeed
+		}
+		forward, backward := osmDirections(way.tags)
+		properties := NewProperties()
+		properties.SetInt(OSM_PROPERTY_WAY_ID, way.id)
+		properties.SetString(OSM_PROPERTY_HIGHWAY, way.tags["highway"])
+		if name, ok := way.tags["name"]; ok {
+			properties.SetString(OSM_PROPERTY_NAME, name)
+		}
+		for _, run := range osmRuns(way.refs, nodes) {
+			start := 0
+			for i := 1; i < len(run); i++ {
+				if i < len(run)-1 && uses[run[i]] < 2 {
+					continue
+				}
+				points := make([][]float64, 0, i-start+1)
+				distance := 0.0
+				for j := start; j <= i; j++ {
+					points = append(points, nodes[run[j]])
+					if j > start {
+						distance += gomath.HaversineDistance(gomath.Point{Values: nodes[run[j-1]]}, gomath.Point{Values: nodes[run[j]]})
+					}
+				}
+				record := EdgeRecord{
+					Id:         -1,
+					Cost:       map[string]float64{COST_TYPE_DISTANCE: distance, COST_TYPE_TIME: distance / (speed / 3.6)},
+					Properties: properties,
+				}
+				from, to := vertexAt(run[start]), vertexAt(run[i])
+				if forward {
+					if len(points) > 2 {
+						record.Points = points
+					}
+					graph.AddEdge(record.Edge(from, to))
+				}
+				if backward {
+					if len(points) > 2 {
+						record.Points = slices.Clone(points)
+						slices.Reverse(record.Points)
+					}
+					graph.AddEdge(record.Edge(to, from))
+				}
+				start = i
+			}
+		}
+	}
+	return graph, nil
+}
+
+func isOSMDeleted(start xml.StartElement) bool {
+	for _, attribute := range start.Attr {
+		if (attribute.Name.Local == "visible" && attribute.Value == "false") || (attribute.Name.Local == "action" && attribute.Value == "delete") {
+			return true
+		}
+	}
+	return false
+}
+
+func osmAttribute(start xml.StartElement, name string) string {
+	for _, attribute := range start.Attr {
+		if attribute.Name.Local == name {
+			return attribute.Value
+		}
+	}
+	return ""
+}
+
+func parseOSMNode(start xml.StartElement) (int64, []float64, error) {
+	id, err := strconv.ParseInt(osmAttribute(start, "id"), 10, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("OSM node with invalid id %q", osmAttribute(start, "id"))
+	}
+	latitude, latitudeErr := strconv.ParseFloat(osmAttribute(start, "lat"), 64)
+	longitude, longitudeErr := strconv.ParseFloat(osmAttribute(start, "lon"), 64)
+	if latitudeErr != nil || longitudeErr != nil {
+		if isOSMDeleted(start) {
+			return id, nil, nil
+		}
+		return 0, nil, fmt.Errorf("OSM node %d has an invalid position", id)
+	}
+	return id, []float64{longitude, latitude}, nil
+}
+
+// parseOSMWay reads the node references and tags of a way, consuming its end element.
+func parseOSMWay(decoder *xml.Decoder, start xml.StartElement) (osmWay, error) {
+	way := osmWay{refs: make([]int64, 0), tags: make(map[string]string)}
+	id, err := strconv.ParseInt(osmAttribute(start, "id"), 10, 64)
+	if err != nil {
+		return way, fmt.Errorf("OSM way with invalid id %q", osmAttribute(start, "id"))
+	}
+	way.id = id
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return way, err
+		}
+		switch element := token.(type) {
+		case xml.EndElement:
+			if element.Name.Local == "way" {
+				return way, nil
+			}
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "nd":
+				ref, err := strconv.ParseInt(osmAttribute(element, "ref"), 10, 64)
+				if err != nil {
+					return way, fmt.Errorf("OSM way %d refers to invalid node %q", id, osmAttribute(element, "ref"))
+				}
+				way.refs = append(way.refs, ref)
+			case "tag":
+				way.tags[osmAttribute(element, "k")] = osmAttribute(element, "v")
+			}
+			if err := decoder.Skip(); err != nil {
+				return way, err
+			}
+		}
+	}
+}
+
+// osmRuns splits refs into runs of at least two nodes present in nodes.
+func osmRuns(refs []int64, nodes map[int64][]float64) [][]int64 {
+	runs := make([][]int64, 0, 1)
+	run := make([]int64, 0, len(refs))
+	for _, ref := range refs {
+		if _, ok := nodes[ref]; ok {
+			run = append(run, ref)
+			continue
+		}
+		if len(run) > 1 {
+			runs = append(runs, run)
+		}
+		run = make([]int64, 0, len(refs))
+	}
+	if len(run) > 1 {
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// parseOSMSpeed reads the first value of a maxspeed tag in km/h, converting mph and knots.
+func parseOSMSpeed(value string) (float64, bool) {
+	value = strings.TrimSpace(strings.Split(value, ";")[0])
+	if value == "walk" {
+		return 5, true
+	}
+	factor := 1.0
+	for unit, unitFactor := range map[string]float64{"mph": 1.609344, "knots": 1.852, "km/h": 1, "kmh": 1, "kph": 1} {
+		if strings.HasSuffix(value, unit) {
+			value, factor = strings.TrimSpace(strings.TrimSuffix(value, unit)), unitFactor
+			break
+		}
+	}
+	speed, err := strconv.ParseFloat(value, 64)
+	if err != nil || speed <= 0 {
+		return 0, false
+	}
+	return speed * factor, true
+}
+
+// osmDirections reports whether a way can be travelled from its first node to its last, and back.
+func osmDirections(tags map[string]string) (bool, bool) {
+	switch tags["oneway"] {
+	case "yes", "true", "1":
+		return true, false
+	case "-1", "reverse":
+		return false, true
+	case "no", "false", "0":
+		return true, true
+	}
+	if highway := tags["highway"]; highway == "motorway" || highway == "motorway_link" ||
+		tags["junction"] == "roundabout" || tags["junction"] == "circular" {
+		return true, false
+	}
+	return true, true
+}
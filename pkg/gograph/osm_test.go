@@ -0,0 +1,123 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"math"
+	"strings"
+	"testing"
+)
+
+const osmExtract = `<?xml version="1.0" encoding="UTF-8"?>
+<osm version="0.6" generator="test">
+  <bounds minlat="51.50" minlon="-0.13" maxlat="51.52" maxlon="-0.11"/>
+  <node id="1" lat="51.5000" lon="-0.1300"/>
+  <node id="2" lat="51.5010" lon="-0.1290"><tag k="highway" v="traffic_signals"/></node>
+  <node id="3" lat="51.5020" lon="-0.1280"/>
+  <node id="4" lat="51.5030" lon="-0.1260"/>
+  <node id="5" lat="51.5040" lon="-0.1250"/>
+  <node id="6" lat="51.5000" lon="-0.1280"/>
+  <node id="7" lat="51.5100" lon="-0.1200"/>
+  <way id="100">
+    <nd ref="1"/><nd ref="2"/><nd ref="3"/>
+    <tag k="highway" v="residential"/><tag k="name" v="Main Street"/>
+  </way>
+  <way id="101">
+    <nd ref="3"/><nd ref="4"/><nd ref="5"/>
+    <tag k="highway" v="primary"/><tag k="oneway" v="yes"/><tag k="maxspeed" v="30 mph"/>
+  </way>
+  <way id="102">
+    <nd ref="1"/><nd ref="6"/><nd ref="3"/><nd ref="1"/>
+    <tag k="building" v="yes"/>
+  </way>
+  <way id="103">
+    <nd ref="6"/><nd ref="2"/>
+    <tag k="highway" v="footway"/><tag k="oneway" v="-1"/>
+  </way>
+  <way id="104">
+    <nd ref="5"/><nd ref="99"/><nd ref="7"/>
+    <tag k="highway" v="service"/>
+  </way>
+  <relation id="200"><member type="way" ref="100" role=""/><tag k="type" v="route"/></relation>
+</osm>`
+
+func TestReadOSM(t *testing.T) {
+	graph, err := ReadOSM(strings.NewReader(osmExtract))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !graph.IsDirected() || graph.Size() != 5 || len(graph.GetEdges()) != 6 {
+		t.Fatalf("Expected 5 intersections and 6 edges, got %d and %d", graph.Size(), len(graph.GetEdges()))
+	}
+	if graph.GetVertex(4) != nil || graph.GetVertex(7) != nil {
+		t.Error("Expected interior and unconnected nodes to be left out")
+	}
+	one, two, three, five, six := graph.GetVertex(1), graph.GetVertex(2), graph.GetVertex(3), graph.GetVertex(5), graph.GetVertex(6)
+	if one.X() != -0.13 || one.Y() != 51.5 {
+		t.Error("Expected vertices at [longitude, latitude]")
+	}
+	if len(GetEdges(one, two)) != 1 || len(GetEdges(two, one)) != 1 || len(GetEdges(three, five)) != 1 || len(GetEdges(five, three)) != 0 {
+		t.Error("Expected two way and oneway roads to be honoured")
+	}
+	if len(GetEdges(two, six)) != 1 || len(GetEdges(six, two)) != 0 {
+		t.Error("Expected oneway=-1 to reverse the way")
+	}
+	primary, ok := GetEdge(three, five).(PolyEdge)
+	if !ok || len(primary.Points) != 3 {
+		t.Fatal("Expected the primary road to be a PolyEdge through node 4")
+	}
+	expected := gomath.HaversineDistance(gomath.Point{Values: []float64{-0.128, 51.502}}, gomath.Point{Values: []float64{-0.126, 51.503}}) +
+		gomath.HaversineDistance(gomath.Point{Values: []float64{-0.126, 51.503}}, gomath.Point{Values: []float64{-0.125, 51.504}})
+	cost := *primary.Cost()
+	if math.Abs(cost[COST_TYPE_DISTANCE]-expected) > 1e-9 || math.Abs(cost[COST_TYPE_TIME]-expected/(30*1.609344/3.6)) > 1e-9 {
+		t.Errorf("Unexpected costs %v", cost)
+	}
+	if residential := *GetEdge(one, two).Cost(); math.Abs(residential[COST_TYPE_TIME]-residential[COST_TYPE_DISTANCE]/(30/3.6)) > 1e-9 {
+		t.Error("Expected the residential default speed")
+	}
+	properties := GetProperties(GetEdge(two, three))
+	if name, _ := properties.GetString(OSM_PROPERTY_NAME); name != "Main Street" {
+		t.Error("Expected the name of the way")
+	}
+	if wayId, _ := properties.GetInt(OSM_PROPERTY_WAY_ID); wayId != 100 {
+		t.Error("Expected the id of the way")
+	}
+
+	costFunctions := map[string]CostFunction{COST_TYPE_DISTANCE: HaversineDistanceCostFunction{}}
+	response := AStar(RoutingAlgorithmRequest{Start: one, Destination: five, CostFunctions: &costFunctions})
+	if !response.Completed || response.Path == nil || response.Path.Length() != 3 {
+		t.Error("Expected a route from 1 to 5")
+	}
+	response = AStar(RoutingAlgorithmRequest{Start: five, Destination: one, CostFunctions: &costFunctions})
+	if response.Path != nil && response.Path.Length() > 0 {
+		t.Error("Expected no route against the oneway")
+	}
+}
+
+func TestReadOSM_Options(t *testing.T) {
+	graph, err := ReadOSM(strings.NewReader(osmExtract), OSMOptions{Highways: []string{"residential", "primary"}, Speeds: map[string]float64{"residential": 20}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graph.GetVertex(6) != nil {
+		t.Error("Expected the footway to be left out")
+	}
+	if cost := *GetEdge(graph.GetVertex(1), graph.GetVertex(3)).Cost(); math.Abs(cost[COST_TYPE_TIME]-cost[COST_TYPE_DISTANCE]/(20/3.6)) > 1e-9 {
+		t.Error("Expected the overridden residential speed")
+	}
+	if _, err := ReadOSM(strings.NewReader(`<osm><node id="1" lat="north" lon="0"/></osm>`)); err == nil {
+		t.Error("Expected an error for an invalid position")
+	}
+}
+
+func TestParseOSMSpeed(t *testing.T) {
+	for value, expected := range map[string]float64{"50": 50, "50 km/h": 50, "30 mph": 48.28032, "60;40": 60, "walk": 5, "10knots": 18.52} {
+		if speed, ok := parseOSMSpeed(value); !ok || math.Abs(speed-expected) > 1e-9 {
+			t.Errorf("Expected %s to be %v km/h, got %v", value, expected, speed)
+		}
+	}
+	for _, value := range []string{"", "none", "signals", "DE:urban"} {
+		if _, ok := parseOSMSpeed(value); ok {
+			t.Errorf("Expected %q to have no speed", value)
+		}
+	}
+}
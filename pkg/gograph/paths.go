@@ -22,9 +22,8 @@ func GetPathCost(path Path, pCostFunctions *map[string]CostFunction) map[string]
 	startWrapper := NewVertexWrapper(ToVertex(path.GetEdges()[0].From()), initialCosts)
 	var curr = startWrapper
 	for _, edge := range path.GetEdges() {
-		toVertex := ToVertex(edge.To())
-		nextCosts := GenerateNextCosts(curr, toVertex, costFunctions)
-		curr = NewVertexWrapper(toVertex, nextCosts)
+		nextCosts := GenerateNextCosts(curr, ViaEdge(edge), costFunctions)
+		curr = NewVertexWrapper(ToVertex(edge.To()), nextCosts)
 	}
 	return curr.Costs
 }
@@ -91,7 +90,7 @@ func (p *SimplePath) Id() int64 {
 }
 
 func (p *SimplePath) Wrap() Path {
-	if ToVertex(p.Edges[0].From()).Hash() == ToVertex(p.Edges[len(p.Edges)-1].To()).Hash() {
+	if VertexHashOrId(ToVertex(p.Edges[0].From())) == VertexHashOrId(ToVertex(p.Edges[len(p.Edges)-1].To())) {
 		return p
 	}
 	p.Edges = append(p.Edges, NewEdge(p.Edges[len(p.Edges)-1].To(), p.Edges[0].From()))
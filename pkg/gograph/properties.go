@@ -0,0 +1,317 @@
+package gograph
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"maps"
+	"sort"
+)
+
+const (
+	PROPERTY_TYPE_STRING = "string"
+	PROPERTY_TYPE_INT    = "int"
+	PROPERTY_TYPE_BOOL   = "bool"
+	PROPERTY_TYPE_FLOAT  = "float"
+)
+
+// Properties is a bag of string, int, bool and float values. All getters are safe to call on a
+// nil *Properties, which behaves like an empty bag.
+type Properties struct {
+	values map[string]any
+}
+
+type PropertyHolder interface {
+	Properties() *Properties
+}
+
+// GetProperties returns the properties of a vertex, edge or any other PropertyHolder, or nil.
+func GetProperties(value any) *Properties {
+	if holder, ok := value.(PropertyHolder); ok {
+		return holder.Properties()
+	}
+	return nil
+}
+
+func NewProperties() *Properties {
+	return &Properties{values: make(map[string]any)}
+}
+
+func (p *Properties) set(key string, value any) {
+	if p.values == nil {
+		p.values = make(map[string]any)
+	}
+	p.values[key] = value
+}
+
+func (p *Properties) SetString(key string, value string) {
+	p.set(key, value)
+}
+
+func (p *Properties) SetInt(key string, value int64) {
+	p.set(key, value)
+}
+
+func (p *Properties) SetBool(key string, value bool) {
+	p.set(key, value)
+}
+
+func (p *Properties) SetFloat(key string, value float64) {
+	p.set(key, value)
+}
+
+// Set stores value if it is a string, bool, float or any integer type.
+func (p *Properties) Set(key string, value any) error {
+	normalized, ok := normalizeProperty(value)
+	if !ok {
+		return fmt.Errorf("unsupported property type %T for key %q", value, key)
+	}
+	p.set(key, normalized)
+	return nil
+}
+
+func normalizeProperty(value any) (any, bool) {
+	switch v := value.(type) {
+	case string, bool, int64, float64:
+		return v, true
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case float32:
+		return float64(v), true
+	}
+	return nil, false
+}
+
+func (p *Properties) Get(key string) (any, bool) {
+	if p == nil {
+		return nil, false
+	}
+	value, ok := p.values[key]
+	return value, ok
+}
+
+func (p *Properties) GetString(key string) (string, bool) {
+	value, _ := p.Get(key)
+	retValue, ok := value.(string)
+	return retValue, ok
+}
+
+func (p *Properties) GetInt(key string) (int64, bool) {
+	value, _ := p.Get(key)
+	retValue, ok := value.(int64)
+	return retValue, ok
+}
+
+func (p *Properties) GetBool(key string) (bool, bool) {
+	value, _ := p.Get(key)
+	retValue, ok := value.(bool)
+	return retValue, ok
+}
+
+// GetFloat also converts int properties, so that numeric properties can feed cost functions.
+func (p *Properties) GetFloat(key string) (float64, bool) {
+	value, _ := p.Get(key)
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func (p *Properties) Has(key string) bool {
+	_, ok := p.Get(key)
+	return ok
+}
+
+func (p *Properties) Remove(key string) {
+	if p == nil {
+		return
+	}
+	delete(p.values, key)
+}
+
+func (p *Properties) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.values)
+}
+
+func (p *Properties) Keys() []string {
+	if p == nil {
+		return []string{}
+	}
+	keys := make([]string, 0, len(p.values))
+	for key := range p.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Type returns one of the PROPERTY_TYPE constants, or "" when key is not set.
+func (p *Properties) Type(key string) string {
+	value, _ := p.Get(key)
+	switch value.(type) {
+	case string:
+		return PROPERTY_TYPE_STRING
+	case int64:
+		return PROPERTY_TYPE_INT
+	case bool:
+		return PROPERTY_TYPE_BOOL
+	case float64:
+		return PROPERTY_TYPE_FLOAT
+	}
+	return ""
+}
+
+// Clone returns an independent copy of the bag. A nil bag clones to an empty one, so that the
+// clone can always be set.
+func (p *Properties) Clone() *Properties {
+	if p.Len() == 0 {
+		return &Properties{}
+	}
+	return &Properties{values: maps.Clone(p.values)}
+}
+
+func (p *Properties) Equals(other *Properties) bool {
+	if p.Len() != other.Len() {
+		return false
+	}
+	for _, key := range p.Keys() {
+		value, _ := p.Get(key)
+		otherValue, ok := other.Get(key)
+		if !ok || value != otherValue {
+			return false
+		}
+	}
+	return true
+}
+
+type typedProperty struct {
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// MarshalJSON writes each property as {"type": ..., "value": ...} so ints and floats survive a round trip.
+func (p *Properties) MarshalJSON() ([]byte, error) {
+	typed := make(map[string]typedProperty, p.Len())
+	for _, key := range p.Keys() {
+		value, _ := p.Get(key)
+		typed[key] = typedProperty{Type: p.Type(key), Value: value}
+	}
+	return json.Marshal(typed)
+}
+
+func (p *Properties) UnmarshalJSON(data []byte) error {
+	var typed map[string]struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return err
+	}
+	p.values = make(map[string]any, len(typed))
+	for key, property := range typed {
+		var err error
+		switch property.Type {
+		case PROPERTY_TYPE_STRING:
+			var value string
+			err = json.Unmarshal(property.Value, &value)
+			p.values[key] = value
+		case PROPERTY_TYPE_INT:
+			var value int64
+			err = json.Unmarshal(property.Value, &value)
+			p.values[key] = value
+		case PROPERTY_TYPE_BOOL:
+			var value bool
+			err = json.Unmarshal(property.Value, &value)
+			p.values[key] = value
+		case PROPERTY_TYPE_FLOAT:
+			var value float64
+			err = json.Unmarshal(property.Value, &value)
+			p.values[key] = value
+		default:
+			err = fmt.Errorf("unsupported property type %q for key %q", property.Type, key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type PropertyPredicate func(properties *Properties) bool
+
+func PropertyEquals(key string, value any) PropertyPredicate {
+	normalized, _ := normalizeProperty(value)
+	return func(properties *Properties) bool {
+		current, ok := properties.Get(key)
+		return ok && current == normalized
+	}
+}
+
+func PropertyExists(key string) PropertyPredicate {
+	return func(properties *Properties) bool {
+		return properties.Has(key)
+	}
+}
+
+// FilterEdges returns every edge of graph whose properties satisfy predicate, e.g.
+// FilterEdges(graph, PropertyEquals("highway", "motorway")).
+func FilterEdges(graph Graph, predicate PropertyPredicate) []Edge {
+	retArray := make([]Edge, 0)
+	for _, edge := range graph.GetEdges() {
+		if predicate(GetProperties(edge)) {
+			retArray = append(retArray, edge)
+		}
+	}
+	return retArray
+}
+
+func FilterVertices(graph Graph, predicate PropertyPredicate) []Vertex {
+	retArray := make([]Vertex, 0)
+	for _, vertex := range graph.GetVertices() {
+		if predicate(GetProperties(vertex)) {
+			retArray = append(retArray, vertex)
+		}
+	}
+	return retArray
+}
+
+// PropertyCostFunction reads a numeric property of the traversed edge, falling back to Default.
+type PropertyCostFunction struct {
+	Key     string
+	Default float64
+}
+
+func (f PropertyCostFunction) Eval(vertexWrapper *VertexWrapper, to gomath.Spatial) float64 {
+	value, ok := GetProperties(TraversedEdge(vertexWrapper.Inner, to)).GetFloat(f.Key)
+	if !ok {
+		return f.Default
+	}
+	return value
+}
+
+// VertexPropertyConstraint passes when the properties of the current vertex satisfy Predicate.
+type VertexPropertyConstraint struct {
+	Predicate PropertyPredicate
+}
+
+func (c VertexPropertyConstraint) Check(currentVertex *VertexWrapper, _ map[string]CostEntry) bool {
+	return c.Predicate(GetProperties(currentVertex))
+}
@@ -0,0 +1,121 @@
+package gograph
+
+import (
+	"encoding/json"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"testing"
+)
+
+func TestProperties_Types(t *testing.T) {
+	properties := NewProperties()
+	properties.SetString("name", "Main Street")
+	properties.SetInt("lanes", 2)
+	properties.SetBool("oneway", true)
+	properties.SetFloat("maxspeed", 13.9)
+	if err := properties.Set("layer", 1); err != nil {
+		t.Error(err)
+	}
+	if err := properties.Set("tags", []string{"a"}); err == nil {
+		t.Error("Expected an error for an unsupported property type")
+	}
+	if name, ok := properties.GetString("name"); !ok || name != "Main Street" {
+		t.Errorf("Unexpected name %q", name)
+	}
+	if lanes, ok := properties.GetInt("lanes"); !ok || lanes != 2 {
+		t.Errorf("Unexpected lanes %d", lanes)
+	}
+	if _, ok := properties.GetString("lanes"); ok {
+		t.Error("GetString should not read an int property")
+	}
+	if layer, ok := properties.GetFloat("layer"); !ok || layer != 1.0 {
+		t.Errorf("GetFloat should convert int properties, got %f", layer)
+	}
+	if properties.Type("oneway") != PROPERTY_TYPE_BOOL || properties.Type("missing") != "" {
+		t.Error("Unexpected property types")
+	}
+	var empty *Properties
+	if empty.Has("name") || empty.Len() != 0 {
+		t.Error("A nil bag should behave like an empty one")
+	}
+}
+
+func TestProperties_CloneAndJSON(t *testing.T) {
+	properties := NewProperties()
+	properties.SetString("highway", "motorway")
+	properties.SetInt("lanes", 3)
+	properties.SetFloat("maxspeed", 27.5)
+	properties.SetBool("toll", false)
+	cloned := properties.Clone()
+	cloned.SetInt("lanes", 4)
+	if lanes, _ := properties.GetInt("lanes"); lanes != 3 {
+		t.Error("Clone should not share values with the original")
+	}
+	data, err := json.Marshal(properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := NewProperties()
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equals(properties) {
+		t.Errorf("JSON round trip changed properties: %s", string(data))
+	}
+	if decoded.Type("lanes") != PROPERTY_TYPE_INT {
+		t.Error("JSON round trip should keep ints as ints")
+	}
+}
+
+func TestProperties_Edges(t *testing.T) {
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	simple := NewSimpleEdge(&a, &b, -1)
+	simple.Properties().SetString("name", "Main Street")
+	poly := NewPolyEdge([]gomath.Spatial{&a, gomath.Point{Values: []float64{0.5, 1.0}}, &b}, -1)
+	poly.Properties().SetInt("lanes", 2)
+	if name, _ := GetProperties(simple.Reverse()).GetString("name"); name != "Main Street" {
+		t.Error("Expected new edges to have a bag shared with their reverse")
+	}
+	if lanes, _ := GetProperties(poly.Reverse()).GetInt("lanes"); lanes != 2 {
+		t.Error("Expected new poly edges to have a bag shared with their reverse")
+	}
+	var empty *Properties
+	if cloned := empty.Clone(); cloned == nil || cloned.Len() != 0 {
+		t.Error("Expected a nil bag to clone to an empty one")
+	}
+}
+
+func TestProperties_FilterAndCost(t *testing.T) {
+	graph := NewDirectedSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{2.0, 0.0}})
+	motorway := NewSimpleEdge(&a, &b, -1)
+	motorway.Properties().SetString("highway", "motorway")
+	motorway.Properties().SetFloat("toll", 2.5)
+	residential := NewSimpleEdge(&b, &c, -1)
+	residential.Properties().SetString("highway", "residential")
+	graph.AddEdge(motorway)
+	graph.AddEdge(residential)
+	a.Properties().SetBool("junction", true)
+
+	filtered := FilterEdges(graph, PropertyEquals("highway", "motorway"))
+	if len(filtered) != 1 || VertexHashOrId(ToVertex(filtered[0].To())) != VertexHashOrId(&b) {
+		t.Errorf("Expected only the motorway edge, got %d edges", len(filtered))
+	}
+	if len(FilterVertices(graph, PropertyExists("junction"))) != 1 {
+		t.Error("Expected one junction vertex")
+	}
+
+	costFunction := PropertyCostFunction{Key: "toll", Default: 0.0}
+	wrapper := &VertexWrapper{Inner: &a}
+	if cost := costFunction.Eval(wrapper, &b); cost != 2.5 {
+		t.Errorf("Expected toll 2.5, got %f", cost)
+	}
+	if cost := costFunction.Eval(&VertexWrapper{Inner: &b}, &c); cost != 0.0 {
+		t.Errorf("Expected default toll, got %f", cost)
+	}
+	if GetProperties(motorway.Reverse()) != motorway.Properties() {
+		t.Error("Reverse should carry the properties of the edge")
+	}
+}
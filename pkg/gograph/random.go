@@ -0,0 +1,20 @@
+package gograph
+
+import (
+	"math/rand"
+	"time"
+)
+
+// NewSeededRandom returns a source for the Random field of requests and providers. The same seed
+// produces the same graph, maze, tree or tour on every run.
+func NewSeededRandom(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// randomOrDefault returns random, or a source seeded from the clock when it is nil.
+func randomOrDefault(random *rand.Rand) *rand.Rand {
+	if random != nil {
+		return random
+	}
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
@@ -0,0 +1,246 @@
+package gograph
+
+import (
+	"fmt"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// VertexRecord is the serializable form of a vertex. Key is its VertexHashOrId, which edges use to
+// refer to it, and Id is -1 for a vertex identified by its coordinates.
+type VertexRecord struct {
+	Key        int64       `json:"key"`
+	Id         int64       `json:"id"`
+	Values     []float64   `json:"values"`
+	Properties *Properties `json:"properties,omitempty"`
+}
+
+func NewVertexRecord(vertex Vertex) VertexRecord {
+	record := VertexRecord{
+		Key:    VertexHashOrId(vertex),
+		Id:     vertex.Id(),
+		Values: append([]float64{}, vertex.GetValues()...),
+	}
+	if properties := GetProperties(vertex); properties.Len() > 0 {
+		record.Properties = properties.Clone()
+	}
+	return record
+}
+
+// Vertex builds a SimpleVertex without edges from the record.
+func (r VertexRecord) Vertex() *SimpleVertex {
+	id := r.Id
+	if id <= 0 {
+		id = -1
+	}
+	return &SimpleVertex{
+		Spatial:    gomath.Point{Values: append([]float64{}, r.Values...)},
+		Edges:      []Edge{},
+		id:         id,
+		hash:       -1,
+		properties: r.Properties.Clone(),
+	}
+}
+
+// EdgeRecord is the serializable form of an edge. From and To are the keys of its endpoints, and
+// Points holds the whole polyline of a PolyEdge, endpoints included, and is empty for other edges.
+type EdgeRecord struct {
+	Key        int64              `json:"key"`
+	Id         int64              `json:"id"`
+	From       int64              `json:"from"`
+	To         int64              `json:"to"`
+	Points     [][]float64        `json:"points,omitempty"`
+	Cost       map[string]float64 `json:"cost,omitempty"`
+	Properties *Properties        `json:"properties,omitempty"`
+}
+
+func NewEdgeRecord(edge Edge) EdgeRecord {
+	record := EdgeRecord{
+		Key:  EdgeHashOrId(edge),
+		Id:   edge.Id(),
+		From: VertexHashOrId(ToVertex(edge.From())),
+		To:   VertexHashOrId(ToVertex(edge.To())),
+	}
+	if wrapper, ok := edge.(interface{ Inner() Edge }); ok {
+		edge = wrapper.Inner()
+	}
+	if polyEdge, ok := edge.(PolyEdge); ok {
+		record.Points = make([][]float64, len(polyEdge.Points))
+		for i, point := range polyEdge.Points {
+			record.Points[i] = append([]float64{}, point.GetValues()...)
+		}
+	}
+	if edge.Cost() != nil {
+		record.Cost = make(map[string]float64, len(*edge.Cost()))
+		for key, value := range *edge.Cost() {
+			record.Cost[key] = value
+		}
+	}
+	if properties := GetProperties(edge); properties.Len() > 0 {
+		record.Properties = properties.Clone()
+	}
+	return record
+}
+
+// Edge builds the edge between from and to described by the record: a PolyEdge through the
+// interior points of the record when it has a polyline, and a SimpleEdge otherwise.
+func (r EdgeRecord) Edge(from Vertex, to Vertex) Edge {
+	var cost *map[string]float64
+	if r.Cost != nil {
+		copied := make(map[string]float64, len(r.Cost))
+		for key, value := range r.Cost {
+			copied[key] = value
+		}
+		cost = &copied
+	}
+	if len(r.Points) < 2 {
+		edge := NewSimpleEdge(from, to, r.Id, cost)
+		edge.properties = r.Properties.Clone()
+		return edge
+	}
+	points := make([]gomath.Spatial, len(r.Points))
+	points[0], points[len(points)-1] = from, to
+	for i := 1; i < len(points)-1; i++ {
+		points[i] = gomath.Point{Values: append([]float64{}, r.Points[i]...)}
+	}
+	edge := NewPolyEdge(points, r.Id)
+	edge.cost = cost
+	edge.properties = r.Properties.Clone()
+	return edge
+}
+
+// addKeyedVertex adds the vertex of record to graph and registers name as its key. A vertex
+// without an id is identified by its coordinates, or by IdFromKey of name when it has none or
+// shares them with a vertex already in graph.
+func addKeyedVertex(graph *SimpleGraph, name string, record VertexRecord) (*SimpleVertex, error) {
+	vertex := record.Vertex()
+	if vertex.id == -1 && (len(record.Values) == 0 || graph.ContainsVertex(vertex)) {
+		vertex.id = IdFromKey(name)
+	}
+	if graph.ContainsVertex(vertex) {
+		return nil, fmt.Errorf("vertex %s has the same id as another vertex", name)
+	}
+	graph.AddVertex(vertex)
+	graph.SetVertexKey(name, vertex)
+	return vertex, nil
+}
+
+// assignEdgeIds gives each record without an id a new one when graph is a multigraph, so that its
+// parallel edges stay apart. The ids of the other records are reserved first, so that a generated
+// id never collides with an explicit id of an edge read later.
+func assignEdgeIds(graph *SimpleGraph, records []*EdgeRecord) {
+	if !graph.multi {
+		return
+	}
+	reserved := make(map[int64]bool, len(records))
+	for _, record := range records {
+		if record.Id != -1 {
+			reserved[record.Id] = true
+		}
+	}
+	for _, record := range records {
+		if record.Id != -1 {
+			continue
+		}
+		record.Id = graph.newEdgeId()
+		for reserved[record.Id] {
+			record.Id = graph.newEdgeId()
+		}
+	}
+}
+
+// vertexNames names each vertex by its key in the graph, when it has one, or n<key> otherwise,
+// for formats that refer to vertices by name.
+func vertexNames(record GraphRecord) map[int64]string {
+	names := make(map[int64]string, len(record.Vertices))
+	used := make(map[string]bool, len(record.Vertices))
+	for _, name := range slices.Sorted(maps.Keys(record.VertexKeys)) {
+		if key := record.VertexKeys[name]; names[key] == "" {
+			names[key] = name
+			used[name] = true
+		}
+	}
+	for _, vertex := range record.Vertices {
+		if names[vertex.Key] != "" {
+			continue
+		}
+		name := "n" + strconv.FormatInt(vertex.Key, 10)
+		for used[name] {
+			name += "_"
+		}
+		names[vertex.Key] = name
+		used[name] = true
+	}
+	return names
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// formatPolyline writes a polyline as space separated points with comma separated values.
+func formatPolyline(points [][]float64) string {
+	formatted := make([]string, len(points))
+	for i, point := range points {
+		values := make([]string, len(point))
+		for j, value := range point {
+			values[j] = formatFloat(value)
+		}
+		formatted[i] = strings.Join(values, ",")
+	}
+	return strings.Join(formatted, " ")
+}
+
+func parsePolyline(value string) ([][]float64, error) {
+	fields := strings.Fields(value)
+	points := make([][]float64, len(fields))
+	for i, field := range fields {
+		for _, part := range strings.Split(field, ",") {
+			parsed, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return nil, err
+			}
+			points[i] = append(points[i], parsed)
+		}
+	}
+	return points, nil
+}
+
+func equalValues(left []float64, right []float64) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for i := range left {
+		if left[i] != right[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalCosts(left map[string]float64, right map[string]float64) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for key, value := range left {
+		if otherValue, ok := right[key]; !ok || otherValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+func equalPolylines(left [][]float64, right [][]float64) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for i := range left {
+		if !equalValues(left[i], right[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,210 @@
+package gograph
+
+// EdgeConflictResolver decides which edge ends up in the result when an edge with the same
+// EdgeHashOrId appears in both inputs of a Union or Intersection.
+type EdgeConflictResolver func(left Edge, right Edge) Edge
+
+type CostConflictResolver func(key string, left float64, right float64) float64
+
+type PropertyConflictResolver func(key string, left any, right any) any
+
+var KeepLeftEdge EdgeConflictResolver = func(left Edge, _ Edge) Edge {
+	return left
+}
+
+var KeepRightEdge EdgeConflictResolver = func(_ Edge, right Edge) Edge {
+	return right
+}
+
+var MinCostResolver CostConflictResolver = func(_ string, left float64, right float64) float64 {
+	return min(left, right)
+}
+
+var MaxCostResolver CostConflictResolver = func(_ string, left float64, right float64) float64 {
+	return max(left, right)
+}
+
+var KeepLeftProperty PropertyConflictResolver = func(_ string, left any, _ any) any {
+	return left
+}
+
+var KeepRightProperty PropertyConflictResolver = func(_ string, _ any, right any) any {
+	return right
+}
+
+// MergeEdgeConflicts keeps the left edge with the union of both cost maps and property bags.
+// Keys present on both sides are resolved with costResolver and propertyResolver, where nil
+// keeps the left value. A property resolved to a type that Properties cannot hold also keeps the
+// left value.
+func MergeEdgeConflicts(costResolver CostConflictResolver, propertyResolver PropertyConflictResolver) EdgeConflictResolver {
+	return func(left Edge, right Edge) Edge {
+		from, to := ToVertex(left.From()), ToVertex(left.To())
+		merged := cloneEdge(left, from, to)
+		if right.Cost() != nil {
+			cost := make(map[string]float64)
+			if merged.Cost() != nil {
+				cost = *merged.Cost()
+			}
+			for key, value := range *right.Cost() {
+				if current, ok := cost[key]; ok && costResolver != nil {
+					value = costResolver(key, current, value)
+				} else if ok {
+					continue
+				}
+				cost[key] = value
+			}
+			merged = withCost(merged, &cost)
+		}
+		rightProperties := GetProperties(right)
+		if rightProperties.Len() == 0 {
+			return merged
+		}
+		properties := GetProperties(merged).Clone()
+		for _, key := range rightProperties.Keys() {
+			value, _ := rightProperties.Get(key)
+			if current, ok := properties.Get(key); ok {
+				if propertyResolver == nil {
+					continue
+				}
+				resolved := propertyResolver(key, current, value)
+				if _, ok := normalizeProperty(resolved); !ok {
+					continue
+				}
+				value = resolved
+			}
+			_ = properties.Set(key, value)
+		}
+		return withProperties(merged, properties)
+	}
+}
+
+// setOperationBuilder copies vertices and edges of the inputs into a new SimpleGraph, so the
+// result shares no vertices, edges, cost maps or properties with them.
+type setOperationBuilder struct {
+	graph *SimpleGraph
+}
+
+func newSetOperationBuilder(directed bool) *setOperationBuilder {
+	return &setOperationBuilder{graph: newSimpleGraph(directed)}
+}
+
+func (b *setOperationBuilder) addVertex(v Vertex) Vertex {
+	if existing := b.graph.GetVertex(VertexHashOrId(v)); existing != nil {
+		return existing
+	}
+	copied := copyVertex(v)
+	b.graph.AddVertex(copied)
+	return copied
+}
+
+func (b *setOperationBuilder) addEdge(e Edge) {
+	if b.graph.ContainsEdge(e) {
+		return
+	}
+	from := b.addVertex(ToVertex(e.From()))
+	to := b.addVertex(ToVertex(e.To()))
+	b.graph.AddEdge(cloneEdge(e, from, to))
+}
+
+func edgesByKey(graph Graph) map[int64]Edge {
+	edges := make(map[int64]Edge)
+	for _, edge := range graph.GetEdges() {
+		edges[EdgeHashOrId(edge)] = edge
+	}
+	return edges
+}
+
+// Union contains every vertex and edge of left and right. Edges present in both are resolved
+// with pResolver, which defaults to KeepLeftEdge. The result is directed when left is.
+func Union(left Graph, right Graph, pResolver ...EdgeConflictResolver) *SimpleGraph {
+	resolver := KeepLeftEdge
+	if len(pResolver) > 0 {
+		resolver = pResolver[0]
+	}
+	builder := newSetOperationBuilder(left.IsDirected())
+	rightEdges := edgesByKey(right)
+	for _, vertex := range left.GetVertices() {
+		builder.addVertex(vertex)
+	}
+	for _, vertex := range right.GetVertices() {
+		builder.addVertex(vertex)
+	}
+	for _, edge := range left.GetEdges() {
+		if other, ok := rightEdges[EdgeHashOrId(edge)]; ok {
+			edge = resolver(edge, other)
+		}
+		builder.addEdge(edge)
+	}
+	for _, edge := range right.GetEdges() {
+		builder.addEdge(edge)
+	}
+	return builder.graph
+}
+
+// Intersection contains the vertices and edges present in both left and right. Edges are
+// resolved with pResolver, which defaults to KeepLeftEdge. The result is directed when left is.
+func Intersection(left Graph, right Graph, pResolver ...EdgeConflictResolver) *SimpleGraph {
+	resolver := KeepLeftEdge
+	if len(pResolver) > 0 {
+		resolver = pResolver[0]
+	}
+	builder := newSetOperationBuilder(left.IsDirected())
+	rightEdges := edgesByKey(right)
+	for _, vertex := range left.GetVertices() {
+		if right.ContainsVertex(vertex) {
+			builder.addVertex(vertex)
+		}
+	}
+	for _, edge := range left.GetEdges() {
+		if other, ok := rightEdges[EdgeHashOrId(edge)]; ok {
+			builder.addEdge(resolver(edge, other))
+		}
+	}
+	return builder.graph
+}
+
+// Difference contains every vertex of left and the edges of left that are not in right.
+func Difference(left Graph, right Graph) *SimpleGraph {
+	builder := newSetOperationBuilder(left.IsDirected())
+	rightEdges := edgesByKey(right)
+	for _, vertex := range left.GetVertices() {
+		builder.addVertex(vertex)
+	}
+	for _, edge := range left.GetEdges() {
+		if _, ok := rightEdges[EdgeHashOrId(edge)]; !ok {
+			builder.addEdge(edge)
+		}
+	}
+	return builder.graph
+}
+
+// Complement contains every vertex of graph and a SimpleEdge between each pair of distinct
+// vertices that are not adjacent in graph.
+func Complement(graph Graph) *SimpleGraph {
+	builder := newSetOperationBuilder(graph.IsDirected())
+	vertices := graph.GetVertices()
+	adjacent := make(map[[2]int64]bool)
+	for _, edge := range graph.GetEdges() {
+		from := VertexHashOrId(ToVertex(edge.From()))
+		to := VertexHashOrId(ToVertex(edge.To()))
+		adjacent[[2]int64{from, to}] = true
+		if !graph.IsDirected() {
+			adjacent[[2]int64{to, from}] = true
+		}
+	}
+	for _, vertex := range vertices {
+		builder.addVertex(vertex)
+	}
+	for i, from := range vertices {
+		for j, to := range vertices {
+			if i == j || (!graph.IsDirected() && j < i) {
+				continue
+			}
+			if adjacent[[2]int64{VertexHashOrId(from), VertexHashOrId(to)}] {
+				continue
+			}
+			builder.graph.AddEdge(NewSimpleEdge(builder.addVertex(from), builder.addVertex(to), -1))
+		}
+	}
+	return builder.graph
+}
@@ -0,0 +1,118 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"testing"
+)
+
+func setOperationPoint(x, y float64) Vertex {
+	return VertexFromSpatial(gomath.Point{Values: []float64{x, y}})
+}
+
+func TestUnion(t *testing.T) {
+	left := NewSimpleGraph()
+	right := NewSimpleGraph()
+	a, b, c := setOperationPoint(0, 0), setOperationPoint(1, 0), setOperationPoint(2, 0)
+	left.AddEdge(NewSimpleEdge(a, b, -1, &map[string]float64{COST_TYPE_DISTANCE: 4.0}))
+	right.AddEdge(NewSimpleEdge(a, b, -1, &map[string]float64{COST_TYPE_DISTANCE: 2.0, "toll": 1.0}))
+	right.AddEdge(NewSimpleEdge(b, c, -1))
+
+	union := Union(left, right)
+	if union.Size() != 3 || len(union.GetEdges()) != 4 {
+		t.Errorf("Expected 3 vertices and 4 edges, got %d and %d", union.Size(), len(union.GetEdges()))
+	}
+	if !union.Validate().IsValid() {
+		t.Error("Union should have consistent adjacency")
+	}
+	kept := union.GetEdge(EdgeHashOrId(NewSimpleEdge(a, b, -1)))
+	if (*kept.Cost())[COST_TYPE_DISTANCE] != 4.0 {
+		t.Errorf("Expected the left cost by default, got %v", *kept.Cost())
+	}
+	if ToVertex(kept.From()) == a {
+		t.Error("Union should not share vertices with its inputs")
+	}
+
+	merged := Union(left, right, MergeEdgeConflicts(MinCostResolver, nil))
+	mergedEdge := merged.GetEdge(EdgeHashOrId(NewSimpleEdge(a, b, -1)))
+	if cost := *mergedEdge.Cost(); cost[COST_TYPE_DISTANCE] != 2.0 || cost["toll"] != 1.0 {
+		t.Errorf("Expected the merged costs, got %v", cost)
+	}
+	if (*left.GetEdge(EdgeHashOrId(NewSimpleEdge(a, b, -1))).Cost())[COST_TYPE_DISTANCE] != 4.0 {
+		t.Error("Merging should not modify the inputs")
+	}
+}
+
+func TestUnion_MergeProperties(t *testing.T) {
+	left := NewDirectedSimpleGraph()
+	right := NewDirectedSimpleGraph()
+	a, b := setOperationPoint(0, 0), setOperationPoint(1, 0)
+	leftEdge := NewSimpleEdge(a, b, 7)
+	leftEdge.Properties().SetString("highway", "primary")
+	rightEdge := NewSimpleEdge(a, b, 7)
+	rightEdge.Properties().SetString("highway", "closed")
+	rightEdge.Properties().SetInt("lanes", 2)
+	left.AddEdge(leftEdge)
+	right.AddEdge(rightEdge)
+
+	properties := GetProperties(Union(left, right, MergeEdgeConflicts(nil, KeepRightProperty)).GetEdge(7))
+	if highway, _ := properties.GetString("highway"); highway != "closed" {
+		t.Errorf("Expected the right property to win, got %q", highway)
+	}
+	if lanes, _ := properties.GetInt("lanes"); lanes != 2 {
+		t.Error("Expected properties only on the right to be kept")
+	}
+	if highway, _ := GetProperties(Union(left, right, KeepRightEdge).GetEdge(7)).GetString("highway"); highway != "closed" {
+		t.Error("KeepRightEdge should keep the right edge")
+	}
+	unsupported := func(_ string, _ any, _ any) any {
+		return struct{}{}
+	}
+	properties = GetProperties(Union(left, right, MergeEdgeConflicts(nil, unsupported)).GetEdge(7))
+	if highway, _ := properties.GetString("highway"); highway != "primary" {
+		t.Errorf("Expected an unsupported resolved value to keep the left property, got %q", highway)
+	}
+}
+
+func TestIntersectionAndDifference(t *testing.T) {
+	left := NewDirectedSimpleGraph()
+	right := NewDirectedSimpleGraph()
+	a, b, c := setOperationPoint(0, 0), setOperationPoint(1, 0), setOperationPoint(2, 0)
+	left.AddEdge(NewSimpleEdge(a, b, -1))
+	left.AddEdge(NewSimpleEdge(b, c, -1))
+	right.AddEdge(NewSimpleEdge(a, b, -1))
+	right.AddEdge(NewSimpleEdge(c, b, -1))
+
+	intersection := Intersection(left, right)
+	if intersection.Size() != 3 || len(intersection.GetEdges()) != 1 {
+		t.Errorf("Expected 3 vertices and 1 edge, got %d and %d", intersection.Size(), len(intersection.GetEdges()))
+	}
+	difference := Difference(left, right)
+	if difference.Size() != 3 || len(difference.GetEdges()) != 1 || !difference.ContainsEdge(NewSimpleEdge(b, c, -1)) {
+		t.Errorf("Expected only b->c, got %d edges", len(difference.GetEdges()))
+	}
+	if !intersection.Validate().IsValid() || !difference.Validate().IsValid() {
+		t.Error("Results should have consistent adjacency")
+	}
+}
+
+func TestComplement(t *testing.T) {
+	graph := NewSimpleGraph()
+	a, b, c, d := setOperationPoint(0, 0), setOperationPoint(1, 0), setOperationPoint(2, 0), setOperationPoint(3, 0)
+	graph.AddEdge(NewSimpleEdge(a, b, -1))
+	graph.AddEdge(NewSimpleEdge(b, c, -1))
+	graph.AddEdge(NewSimpleEdge(c, d, -1))
+	complement := Complement(graph)
+	if complement.Size() != 4 || len(complement.GetEdges()) != 6 {
+		t.Errorf("Expected 3 undirected edges in the complement, got %d directed ones", len(complement.GetEdges()))
+	}
+	if complement.ContainsEdge(NewSimpleEdge(a, b, -1)) || !complement.ContainsEdge(NewSimpleEdge(d, a, -1)) {
+		t.Error("Unexpected edges in the complement")
+	}
+
+	directed := NewDirectedSimpleGraph()
+	directed.AddEdge(NewSimpleEdge(a, b, -1))
+	directedComplement := Complement(directed)
+	if len(directedComplement.GetEdges()) != 1 || !directedComplement.ContainsEdge(NewSimpleEdge(b, a, -1)) {
+		t.Errorf("Expected only b->a, got %d edges", len(directedComplement.GetEdges()))
+	}
+}
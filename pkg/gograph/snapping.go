@@ -0,0 +1,276 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"math"
+)
+
+// EdgeProjection is the closest point of an edge to a query point. Fraction is the position of
+// Point along the edge in [0, 1], the inverse of Edge.Scale.
+type EdgeProjection struct {
+	Edge     Edge
+	Point    gomath.Point
+	Fraction float64
+	Distance float64
+	segment  int
+}
+
+func edgePoints(edge Edge) []gomath.Spatial {
+	if polyEdge, ok := edge.(PolyEdge); ok {
+		return polyEdge.Points
+	}
+	return []gomath.Spatial{edge.From(), edge.To()}
+}
+
+// ProjectOntoEdge projects point onto every segment of edge and keeps the closest projection under
+// pDistanceFunction, which defaults to Euclidean distance. Segments are treated as straight lines
+// in coordinate space, like Edge.Scale does.
+func ProjectOntoEdge(edge Edge, point gomath.Spatial, pDistanceFunction ...gomath.DistanceFunction) EdgeProjection {
+	distanceFunction := gomath.EuclideanDistance
+	if len(pDistanceFunction) > 0 {
+		distanceFunction = pDistanceFunction[0]
+	}
+	points := edgePoints(edge)
+	lengths := make([]float64, len(points)-1)
+	totalLength := 0.0
+	for i := range lengths {
+		lengths[i] = distanceFunction(points[i], points[i+1])
+		totalLength += lengths[i]
+	}
+	best := EdgeProjection{Edge: edge, Distance: math.MaxFloat64}
+	accumulated := 0.0
+	for i := range lengths {
+		t := projectOntoSegment(points[i].GetValues(), points[i+1].GetValues(), point.GetValues())
+		projected := interpolate(points[i].GetValues(), points[i+1].GetValues(), t)
+		if distance := distanceFunction(point, projected); distance < best.Distance {
+			best.Point = projected
+			best.Distance = distance
+			best.segment = i
+			if totalLength > 0 {
+				best.Fraction = (accumulated + t*lengths[i]) / totalLength
+			}
+		}
+		accumulated += lengths[i]
+	}
+	if len(lengths) == 1 {
+		// SimpleEdge.Scale interpolates linearly whatever the distance function.
+		best.Fraction = projectOntoSegment(points[0].GetValues(), points[1].GetValues(), point.GetValues())
+	}
+	return best
+}
+
+// projectOntoSegment returns the parameter in [0, 1] of the closest point to point on the segment.
+func projectOntoSegment(from, to, point []float64) float64 {
+	dot, squaredLength := 0.0, 0.0
+	for i := 0; i < len(from) && i < len(to); i++ {
+		delta := to[i] - from[i]
+		value := 0.0
+		if i < len(point) {
+			value = point[i]
+		}
+		dot += (value - from[i]) * delta
+		squaredLength += delta * delta
+	}
+	if squaredLength == 0 {
+		return 0
+	}
+	return min(max(dot/squaredLength, 0), 1)
+}
+
+func interpolate(from, to []float64, t float64) gomath.Point {
+	values := make([]float64, min(len(from), len(to)))
+	for i := range values {
+		values[i] = from[i] + (to[i]-from[i])*t
+	}
+	return gomath.Point{Values: values}
+}
+
+// SnapToGraph projects point onto the closest edge of graph. It reports false for a graph
+// without edges.
+func SnapToGraph(graph Graph, point gomath.Spatial, pDistanceFunction ...gomath.DistanceFunction) (EdgeProjection, bool) {
+	best := EdgeProjection{Distance: math.MaxFloat64}
+	found := false
+	for _, edge := range graph.GetEdges() {
+		projection := ProjectOntoEdge(edge, point, pDistanceFunction...)
+		if projection.Distance < best.Distance {
+			best = projection
+			found = true
+		}
+	}
+	return best, found
+}
+
+// EdgeSplit records a temporary vertex inserted into a graph by SplitEdge, so that Restore can
+// undo it.
+type EdgeSplit struct {
+	Vertex     Vertex
+	graph      Graph
+	created    bool
+	removed    []Edge
+	added      []Edge
+	adjacency  map[*SimpleVertex][]Edge
+	nextEdgeId int64
+}
+
+// SplitEdge inserts a vertex at the projection and replaces the projected edge by two edges
+// through it. Their cost maps are divided in proportion to the fraction of the edge each covers,
+// and both keep the properties of the edge. In a directed graph, an edge in the opposite
+// direction along the same geometry is split as well. When the projection falls on an endpoint,
+// that endpoint is returned and the graph is left unchanged. When it falls on another vertex of
+// the graph, the edges are split at that vertex, which Restore leaves in the graph.
+func SplitEdge(graph Graph, projection EdgeProjection) *EdgeSplit {
+	edge := storedInDirection(graph, projection.Edge)
+	from, to := ToVertex(edge.From()), ToVertex(edge.To())
+	split := &EdgeSplit{graph: graph, adjacency: make(map[*SimpleVertex][]Edge)}
+	if projection.Fraction <= 0 {
+		split.Vertex = from
+		return split
+	}
+	if projection.Fraction >= 1 {
+		split.Vertex = to
+		return split
+	}
+	for _, vertex := range []Vertex{from, to} {
+		if simpleVertex, ok := vertex.(*SimpleVertex); ok {
+			split.adjacency[simpleVertex] = append([]Edge{}, simpleVertex.Edges...)
+		}
+	}
+	if simpleGraph, ok := graph.(*SimpleGraph); ok {
+		split.nextEdgeId = simpleGraph.nextEdgeId
+	}
+	vertex := VertexFromSpatial(projection.Point)
+	if graph.ContainsVertex(vertex) {
+		vertex = graph.GetVertex(VertexHashOrId(vertex))
+	} else {
+		split.created = true
+	}
+	split.Vertex = vertex
+	edges := []Edge{edge}
+	if graph.IsDirected() {
+		for _, reverse := range GetEdges(to, from) {
+			if reverseGeometry(edge, reverse) {
+				edges = append(edges, reverse)
+				break
+			}
+		}
+	}
+	for i, current := range edges {
+		fraction := projection.Fraction
+		segment := projection.segment
+		if i > 0 {
+			fraction = 1 - fraction
+			segment = len(edgePoints(current)) - 2 - segment
+		}
+		removed := current
+		if stored := graph.GetEdge(EdgeHashOrId(current)); stored != nil && !graph.IsDirected() {
+			// Restore puts back the direction the graph stores, not the one that was projected.
+			removed = stored
+		}
+		graph.RemoveEdge(current)
+		split.removed = append(split.removed, removed)
+		first, second := splitEdgeAt(current, vertex, segment, fraction)
+		split.add(first)
+		split.add(second)
+	}
+	return split
+}
+
+// add adds edge to the graph and records the edge the graph stored for it, which has the id a
+// multigraph gave it. An edge the graph already had is not recorded.
+func (s *EdgeSplit) add(edge Edge) {
+	key := VertexHashOrId(ToVertex(edge.From()))
+	before := 0
+	if s.graph.ContainsVertex(ToVertex(edge.From())) {
+		before = len(s.graph.GetVertex(key).GetEdges())
+	}
+	s.graph.AddEdge(edge)
+	if edges := s.graph.GetVertex(key).GetEdges(); len(edges) > before {
+		s.added = append(s.added, edges[len(edges)-1])
+	}
+}
+
+// storedInDirection returns the graph's copy of edge that starts at the same vertex, since the
+// projection fraction is measured in that direction. An undirected edge with an id is stored once
+// under that id, so the lookup goes through the adjacency of its from vertex.
+func storedInDirection(graph Graph, edge Edge) Edge {
+	from := ToVertex(edge.From())
+	if !graph.ContainsVertex(from) {
+		return edge
+	}
+	key := EdgeHashOrId(edge)
+	for _, stored := range graph.GetVertex(VertexHashOrId(from)).GetEdges() {
+		if EdgeHashOrId(stored) == key {
+			return stored
+		}
+	}
+	return edge
+}
+
+func reverseGeometry(edge Edge, reverse Edge) bool {
+	points, reversePoints := edgePoints(edge), edgePoints(reverse)
+	if len(points) != len(reversePoints) {
+		return false
+	}
+	for i, point := range points {
+		if VertexHashOrId(ToVertex(point)) != VertexHashOrId(ToVertex(reversePoints[len(points)-1-i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func splitEdgeAt(edge Edge, vertex Vertex, segment int, fraction float64) (Edge, Edge) {
+	var firstCost, secondCost *map[string]float64
+	if edge.Cost() != nil {
+		first := make(map[string]float64, len(*edge.Cost()))
+		second := make(map[string]float64, len(*edge.Cost()))
+		for key, value := range *edge.Cost() {
+			first[key] = value * fraction
+			second[key] = value * (1 - fraction)
+		}
+		firstCost, secondCost = &first, &second
+	}
+	properties := GetProperties(edge)
+	points := edgePoints(edge)
+	firstPoints := append(append([]gomath.Spatial{}, points[:segment+1]...), vertex)
+	secondPoints := append([]gomath.Spatial{vertex}, points[segment+1:]...)
+	return splitPart(firstPoints, firstCost, properties.Clone()), splitPart(secondPoints, secondCost, properties.Clone())
+}
+
+func splitPart(points []gomath.Spatial, cost *map[string]float64, properties *Properties) Edge {
+	if len(points) == 2 {
+		edge := NewSimpleEdge(points[0], points[1], -1, cost)
+		edge.properties = properties
+		return edge
+	}
+	edge := NewPolyEdge(points, -1)
+	edge.cost = cost
+	edge.properties = properties
+	return edge
+}
+
+// Restore removes the edges SplitEdge added, and the vertex when SplitEdge created it, and puts
+// back the split edges. The adjacency of their endpoints is left in its original order when they
+// are SimpleVertices, and the edge ids a SimpleGraph gives out next are reset as well.
+func (s *EdgeSplit) Restore() {
+	if len(s.removed) == 0 {
+		return
+	}
+	for _, edge := range s.added {
+		s.graph.RemoveEdge(edge)
+	}
+	if s.created {
+		s.graph.RemoveVertex(s.Vertex)
+	}
+	for _, edge := range s.removed {
+		s.graph.AddEdge(edge)
+	}
+	for vertex, edges := range s.adjacency {
+		vertex.Edges = edges
+	}
+	if simpleGraph, ok := s.graph.(*SimpleGraph); ok {
+		simpleGraph.nextEdgeId = s.nextEdgeId
+	}
+	s.removed = nil
+	s.added = nil
+}
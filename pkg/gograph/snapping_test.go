@@ -0,0 +1,150 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"maps"
+	"math"
+	"testing"
+)
+
+func TestProjectOntoEdge(t *testing.T) {
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{4.0, 0.0}})
+	projection := ProjectOntoEdge(NewSimpleEdge(&a, &b, -1), gomath.Point{Values: []float64{1.0, 2.0}})
+	if projection.Fraction != 0.25 || projection.Point.X() != 1.0 || projection.Point.Y() != 0.0 {
+		t.Errorf("Expected (1, 0) at 0.25, got %v at %f", projection.Point.Values, projection.Fraction)
+	}
+
+	polyEdge := NewPolyEdge([]gomath.Spatial{&a, gomath.Point{Values: []float64{2.0, 0.0}}, gomath.Point{Values: []float64{2.0, 2.0}}, &b}, -1)
+	for _, fraction := range []float64{0.1, 0.3, 0.6, 0.8} {
+		scaled := polyEdge.Scale(fraction, gomath.ManhattanDistance)
+		inverse := ProjectOntoEdge(polyEdge, scaled, gomath.ManhattanDistance)
+		if math.Abs(inverse.Fraction-fraction) > 1e-9 || inverse.Distance > 1e-9 {
+			t.Errorf("Expected fraction %f, got %f", fraction, inverse.Fraction)
+		}
+	}
+}
+
+func TestSplitEdge_Restore(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{4.0, 0.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{4.0, 4.0}})
+	edge := NewSimpleEdge(&a, &b, -1, &map[string]float64{COST_TYPE_TIME: 8.0})
+	edge.Properties().SetString("name", "Main Street")
+	graph.AddEdge(edge)
+	graph.AddEdge(NewPolyEdge([]gomath.Spatial{&b, gomath.Point{Values: []float64{6.0, 2.0}}, &c}, -1))
+	edges := edgeKeys(graph)
+	adjacency := append([]Edge{}, b.Edges...)
+
+	projection, ok := SnapToGraph(graph, gomath.Point{Values: []float64{3.0, -1.0}})
+	if !ok {
+		t.Fatal("Expected to snap onto an edge")
+	}
+	if projection.Point.X() != 3.0 || projection.Point.Y() != 0.0 {
+		t.Fatalf("Expected to snap at (3, 0), got %v", projection.Point.Values)
+	}
+	split := SplitEdge(graph, projection)
+	if graph.Size() != 4 || len(graph.GetEdges()) != 6 || !graph.Validate().IsValid() {
+		t.Errorf("Expected 4 vertices and 6 edges, got %d and %d", graph.Size(), len(graph.GetEdges()))
+	}
+	first := GetEdge(&a, split.Vertex)
+	second := GetEdge(split.Vertex, &b)
+	if (*first.Cost())[COST_TYPE_TIME] != 6.0 || (*second.Cost())[COST_TYPE_TIME] != 2.0 {
+		t.Errorf("Expected costs 6 and 2, got %v and %v", *first.Cost(), *second.Cost())
+	}
+	if name, _ := GetProperties(second).GetString("name"); name != "Main Street" {
+		t.Error("Expected the split edges to keep their properties")
+	}
+
+	split.Restore()
+	if !maps.Equal(edgeKeys(graph), edges) || graph.Size() != 3 || len(graph.GetEdges()) != 4 {
+		t.Error("Expected the graph to be restored")
+	}
+	if len(b.Edges) != len(adjacency) {
+		t.Fatal("Expected the adjacency of b to be restored")
+	}
+	for i := range adjacency {
+		if EdgeHashOrId(b.Edges[i]) != EdgeHashOrId(adjacency[i]) {
+			t.Error("Expected the adjacency of b to keep its order")
+		}
+	}
+}
+
+func TestSplitEdge_UndirectedId(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{4.0, 0.0}})
+	edge := NewSimpleEdge(&a, &b, 7, &map[string]float64{COST_TYPE_TIME: 8.0})
+	graph.AddEdge(edge)
+
+	for _, projected := range []Edge{edge, edge.Reverse()} {
+		projection := ProjectOntoEdge(projected, gomath.Point{Values: []float64{3.0, 1.0}})
+		split := SplitEdge(graph, projection)
+		if split.Vertex.X() != 3.0 || split.Vertex.Y() != 0.0 {
+			t.Errorf("Expected the split at (3, 0), got %v", split.Vertex.GetValues())
+		}
+		first := GetEdge(&a, split.Vertex)
+		second := GetEdge(split.Vertex, &b)
+		if first.Cost() == nil || second.Cost() == nil || (*first.Cost())[COST_TYPE_TIME] != 6.0 || (*second.Cost())[COST_TYPE_TIME] != 2.0 {
+			t.Errorf("Expected costs 6 and 2 when splitting from %v", projected.From().GetValues())
+		}
+		split.Restore()
+		if restored := graph.GetEdge(7); len(graph.GetEdges()) != 1 || restored == nil || VertexHashOrId(ToVertex(restored.From())) != VertexHashOrId(&a) {
+			t.Error("Expected the graph to be restored")
+		}
+	}
+}
+
+func TestSplitEdge_ExistingVertex(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{2.0, 0.0}})
+	middle := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	graph.AddVertex(&middle)
+
+	projection, _ := SnapToGraph(graph, gomath.Point{Values: []float64{1.0, 1.0}})
+	split := SplitEdge(graph, projection)
+	if split.Vertex != Vertex(&middle) || len(graph.GetEdges()) != 4 || graph.Size() != 3 {
+		t.Errorf("Expected the edge to be split at the existing vertex, got %d edges", len(graph.GetEdges()))
+	}
+	split.Restore()
+	if graph.Size() != 3 || !graph.ContainsVertex(&middle) || len(middle.Edges) != 0 || len(graph.GetEdges()) != 2 {
+		t.Errorf("Expected the existing vertex to be kept without edges, got %d vertices", graph.Size())
+	}
+}
+
+func TestSplitEdge_PolyEdgeDirected(t *testing.T) {
+	graph := NewDirectedSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{4.0, 4.0}})
+	corner := gomath.Point{Values: []float64{4.0, 0.0}}
+	forward := NewPolyEdge([]gomath.Spatial{&a, corner, &b}, -1)
+	graph.AddEdge(forward)
+	graph.AddEdge(forward.Reverse())
+
+	split := SplitEdge(graph, ProjectOntoEdge(forward, gomath.Point{Values: []float64{5.0, 2.0}}))
+	if split.Vertex.X() != 4.0 || split.Vertex.Y() != 2.0 {
+		t.Errorf("Expected the split at (4, 2), got %v", split.Vertex.GetValues())
+	}
+	if len(graph.GetEdges()) != 4 || graph.InDegree(split.Vertex) != 2 || graph.OutDegree(split.Vertex) != 2 {
+		t.Errorf("Expected both directions to be split, got %d edges", len(graph.GetEdges()))
+	}
+	firstHalf, ok := GetEdge(&a, split.Vertex).(PolyEdge)
+	if !ok || len(firstHalf.Points) != 3 {
+		t.Error("Expected the first half to keep the corner")
+	}
+	split.Restore()
+	if len(graph.GetEdges()) != 2 || graph.Size() != 2 || !graph.ContainsEdge(forward) {
+		t.Error("Expected the graph to be restored")
+	}
+}
+
+func edgeKeys(graph Graph) map[int64]bool {
+	keys := make(map[int64]bool)
+	for _, edge := range graph.GetEdges() {
+		keys[EdgeHashOrId(edge)] = true
+	}
+	return keys
+}
@@ -0,0 +1,412 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// SPATIAL_INDEX_BALANCE is the largest share of a subtree that one of its children may hold
+// before Insert rebuilds the subtree.
+const SPATIAL_INDEX_BALANCE = 2.0 / 3.0
+
+// SpatialIndex is a k-d tree over the vertices of a graph for nearest neighbour, radius and
+// bounding box queries. Insert and Remove update it incrementally. Insert rebuilds the subtree
+// that has grown lopsided, as in a scapegoat tree, so that the tree stays balanced for any order
+// of insertion. Removed vertices are dropped lazily and the tree is rebuilt once they outnumber
+// the vertices left.
+//
+// Queries accept any gomath.DistanceFunction that never decreases when a coordinate moves away
+// from the query point, such as Euclidean or Manhattan distance, and gomath.HaversineDistance
+// over [longitude, latitude] points. Subtrees are pruned with the distance to the closest point
+// of their bounding box.
+type SpatialIndex struct {
+	root       *kdNode
+	dimensions int
+	nodes      map[int64]*kdNode
+	removed    int
+}
+
+type kdNode struct {
+	vertex  Vertex
+	point   []float64
+	axis    int
+	left    *kdNode
+	right   *kdNode
+	min     []float64
+	max     []float64
+	removed bool
+}
+
+type spatialMatch struct {
+	vertex   Vertex
+	distance float64
+}
+
+// NewSpatialIndex builds a balanced index of the vertices of graph.
+func NewSpatialIndex(graph Graph) *SpatialIndex {
+	return NewSpatialIndexFromVertices(graph.GetVertices())
+}
+
+func NewSpatialIndexFromVertices(vertices []Vertex) *SpatialIndex {
+	index := &SpatialIndex{nodes: make(map[int64]*kdNode, len(vertices))}
+	nodes := make([]*kdNode, 0, len(vertices))
+	for _, vertex := range vertices {
+		key := VertexHashOrId(vertex)
+		if _, ok := index.nodes[key]; ok {
+			continue
+		}
+		node := index.newNode(vertex)
+		index.nodes[key] = node
+		nodes = append(nodes, node)
+	}
+	index.root = index.build(nodes, 0)
+	return index
+}
+
+func (s *SpatialIndex) newNode(vertex Vertex) *kdNode {
+	point := append([]float64{}, vertex.GetValues()...)
+	if s.dimensions == 0 {
+		s.dimensions = max(len(point), 1)
+	}
+	for len(point) < s.dimensions {
+		point = append(point, 0)
+	}
+	return &kdNode{
+		vertex: vertex,
+		point:  point,
+		min:    append([]float64{}, point[:s.dimensions]...),
+		max:    append([]float64{}, point[:s.dimensions]...),
+	}
+}
+
+func (s *SpatialIndex) build(nodes []*kdNode, depth int) *kdNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	axis := depth % s.dimensions
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].point[axis] < nodes[j].point[axis]
+	})
+	median := len(nodes) / 2
+	node := nodes[median]
+	node.axis = axis
+	node.left = s.build(nodes[:median], depth+1)
+	node.right = s.build(nodes[median+1:], depth+1)
+	node.min = append(node.min[:0], node.point[:s.dimensions]...)
+	node.max = append(node.max[:0], node.point[:s.dimensions]...)
+	for _, child := range []*kdNode{node.left, node.right} {
+		if child != nil {
+			node.expand(child.min)
+			node.expand(child.max)
+		}
+	}
+	return node
+}
+
+func (n *kdNode) expand(point []float64) {
+	for i := range n.min {
+		n.min[i] = min(n.min[i], point[i])
+		n.max[i] = max(n.max[i], point[i])
+	}
+}
+
+func (s *SpatialIndex) Len() int {
+	return len(s.nodes)
+}
+
+// Insert adds vertex to the index, replacing a vertex with the same VertexHashOrId.
+func (s *SpatialIndex) Insert(vertex Vertex) {
+	key := VertexHashOrId(vertex)
+	if _, ok := s.nodes[key]; ok {
+		s.Remove(vertex)
+	}
+	node := s.newNode(vertex)
+	s.nodes[key] = node
+	if s.root == nil {
+		s.root = node
+		return
+	}
+	path := []*kdNode{}
+	curr := s.root
+	for {
+		path = append(path, curr)
+		curr.expand(node.point[:s.dimensions])
+		next := &curr.right
+		if node.point[curr.axis] < curr.point[curr.axis] {
+			next = &curr.left
+		}
+		if *next == nil {
+			node.axis = (curr.axis + 1) % s.dimensions
+			*next = node
+			break
+		}
+		curr = *next
+	}
+	if float64(len(path)) > math.Log(float64(len(s.nodes)+s.removed))/math.Log(1/SPATIAL_INDEX_BALANCE) {
+		s.rebalance(append(path, node))
+	}
+}
+
+// rebalance rebuilds the subtree of the deepest node on path, which leads from the root to a new
+// node, that holds a child too large for SPATIAL_INDEX_BALANCE.
+func (s *SpatialIndex) rebalance(path []*kdNode) {
+	size := 1
+	for depth := len(path) - 2; depth >= 0; depth-- {
+		parent, child := path[depth], path[depth+1]
+		sibling := parent.left
+		if sibling == child {
+			sibling = parent.right
+		}
+		childSize := size
+		size = childSize + countNodes(sibling) + 1
+		if float64(childSize) <= SPATIAL_INDEX_BALANCE*float64(size) {
+			continue
+		}
+		nodes := make([]*kdNode, 0, size)
+		nodes = collectNodes(parent, nodes)
+		live := nodes[:0]
+		for _, node := range nodes {
+			if node.removed {
+				s.removed--
+				continue
+			}
+			live = append(live, node)
+		}
+		// Axes follow the depth, so the rebuilt subtree splits on the same axes as before.
+		rebuilt := s.build(live, depth)
+		switch {
+		case depth == 0:
+			s.root = rebuilt
+		case path[depth-1].left == parent:
+			path[depth-1].left = rebuilt
+		default:
+			path[depth-1].right = rebuilt
+		}
+		return
+	}
+}
+
+func countNodes(node *kdNode) int {
+	if node == nil {
+		return 0
+	}
+	return countNodes(node.left) + countNodes(node.right) + 1
+}
+
+func collectNodes(node *kdNode, nodes []*kdNode) []*kdNode {
+	if node == nil {
+		return nodes
+	}
+	nodes = collectNodes(node.left, nodes)
+	nodes = append(nodes, node)
+	return collectNodes(node.right, nodes)
+}
+
+// Remove drops vertex from the index and reports whether it was present.
+func (s *SpatialIndex) Remove(vertex Vertex) bool {
+	key := VertexHashOrId(vertex)
+	node, ok := s.nodes[key]
+	if !ok {
+		return false
+	}
+	node.removed = true
+	delete(s.nodes, key)
+	s.removed++
+	if s.removed > len(s.nodes) {
+		s.rebuild()
+	}
+	return true
+}
+
+func (s *SpatialIndex) rebuild() {
+	nodes := make([]*kdNode, 0, len(s.nodes))
+	for _, node := range s.nodes {
+		node.left, node.right = nil, nil
+		nodes = append(nodes, node)
+	}
+	s.removed = 0
+	s.root = s.build(nodes, 0)
+}
+
+// lowerBound is the distance from point to the closest point of the bounding box of node.
+func (s *SpatialIndex) lowerBound(point gomath.Point, node *kdNode, distanceFunction gomath.DistanceFunction) float64 {
+	if s.dimensions >= 2 && isHaversineDistance(distanceFunction) {
+		return haversineLowerBound(point.X(), point.Y(), node)
+	}
+	closest := append([]float64{}, point.Values...)
+	for i := 0; i < s.dimensions && i < len(closest); i++ {
+		closest[i] = min(max(closest[i], node.min[i]), node.max[i])
+	}
+	return distanceFunction(point, gomath.Point{Values: closest})
+}
+
+func isHaversineDistance(distanceFunction gomath.DistanceFunction) bool {
+	return reflect.ValueOf(distanceFunction).Pointer() == reflect.ValueOf(gomath.HaversineDistance).Pointer()
+}
+
+// haversineLowerBound is the great circle distance from [lon, lat] to the closest point of the box
+// of node. Clamping the point into the box is no lower bound on a sphere, since the closest point
+// may lie across the antimeridian and great circles bend toward the poles. Differences of
+// longitude go through their haversine, which measures them the short way round. Beside the box,
+// the closest point lies on its nearer meridian, at the latitude where the great circle is
+// closest to it or else at a corner.
+func haversineLowerBound(lon, lat float64, node *kdNode) float64 {
+	const earthRadius = 6371000.0
+	const toRadians = math.Pi / 180.0
+	haversine := func(theta float64) float64 {
+		sin := math.Sin(theta * toRadians / 2)
+		return sin * sin
+	}
+	distance := func(h float64) float64 {
+		return 2 * earthRadius * math.Asin(math.Sqrt(min(max(h, 0), 1)))
+	}
+	minLon, minLat, maxLon, maxLat := node.min[0], node.min[1], node.max[0], node.max[1]
+	if lon >= minLon && lon <= maxLon {
+		switch {
+		case lat < minLat:
+			return distance(haversine(minLat - lat))
+		case lat > maxLat:
+			return distance(haversine(lat - maxLat))
+		}
+		return 0
+	}
+	deltaLon := min(haversine(lon-minLon), haversine(lon-maxLon))
+	cosLat := math.Cos(lat * toRadians)
+	toLatitude := func(other float64) float64 {
+		return distance(haversine(lat-other) + cosLat*math.Cos(other*toRadians)*deltaLon)
+	}
+	extremum := math.Copysign(90, lat)
+	if cosDeltaLon := 1 - 2*deltaLon; cosDeltaLon > 0 {
+		extremum = math.Atan(math.Tan(lat*toRadians)/cosDeltaLon) / toRadians
+	}
+	if extremum > minLat && extremum < maxLat {
+		return toLatitude(extremum)
+	}
+	return min(toLatitude(minLat), toLatitude(maxLat))
+}
+
+func (s *SpatialIndex) queryPoint(spatial gomath.Spatial) gomath.Point {
+	values := append([]float64{}, spatial.GetValues()...)
+	for len(values) < s.dimensions {
+		values = append(values, 0)
+	}
+	return gomath.Point{Values: values}
+}
+
+// Nearest returns the vertex closest to spatial, or nil when the index is empty.
+func (s *SpatialIndex) Nearest(spatial gomath.Spatial, pDistanceFunction ...gomath.DistanceFunction) Vertex {
+	nearest := s.KNearest(spatial, 1, pDistanceFunction...)
+	if len(nearest) == 0 {
+		return nil
+	}
+	return nearest[0]
+}
+
+// KNearest returns up to k vertices ordered by their distance to spatial.
+func (s *SpatialIndex) KNearest(spatial gomath.Spatial, k int, pDistanceFunction ...gomath.DistanceFunction) []Vertex {
+	distanceFunction := gomath.EuclideanDistance
+	if len(pDistanceFunction) > 0 {
+		distanceFunction = pDistanceFunction[0]
+	}
+	if k <= 0 || s.root == nil {
+		return []Vertex{}
+	}
+	point := s.queryPoint(spatial)
+	matches := make([]spatialMatch, 0, k+1)
+	var search func(node *kdNode)
+	search = func(node *kdNode) {
+		if node == nil {
+			return
+		}
+		if len(matches) == k && s.lowerBound(point, node, distanceFunction) > matches[k-1].distance {
+			return
+		}
+		if !node.removed {
+			distance := distanceFunction(point, gomath.Point{Values: node.point})
+			if len(matches) < k || distance < matches[len(matches)-1].distance {
+				position := sort.Search(len(matches), func(i int) bool {
+					return matches[i].distance > distance
+				})
+				matches = slices.Insert(matches, position, spatialMatch{node.vertex, distance})
+				if len(matches) > k {
+					matches = matches[:k]
+				}
+			}
+		}
+		first, second := node.left, node.right
+		if point.Values[node.axis] >= node.point[node.axis] {
+			first, second = second, first
+		}
+		search(first)
+		search(second)
+	}
+	search(s.root)
+	return verticesOfMatches(matches)
+}
+
+// WithinRadius returns every vertex within radius of spatial, ordered by distance.
+func (s *SpatialIndex) WithinRadius(spatial gomath.Spatial, radius float64, pDistanceFunction ...gomath.DistanceFunction) []Vertex {
+	distanceFunction := gomath.EuclideanDistance
+	if len(pDistanceFunction) > 0 {
+		distanceFunction = pDistanceFunction[0]
+	}
+	point := s.queryPoint(spatial)
+	matches := make([]spatialMatch, 0)
+	var search func(node *kdNode)
+	search = func(node *kdNode) {
+		if node == nil || s.lowerBound(point, node, distanceFunction) > radius {
+			return
+		}
+		if !node.removed {
+			if distance := distanceFunction(point, gomath.Point{Values: node.point}); distance <= radius {
+				matches = append(matches, spatialMatch{node.vertex, distance})
+			}
+		}
+		search(node.left)
+		search(node.right)
+	}
+	search(s.root)
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+	return verticesOfMatches(matches)
+}
+
+// WithinBoundingBox returns every vertex whose X and Y lie inside box.
+func (s *SpatialIndex) WithinBoundingBox(box gomath.BoundingBox) []Vertex {
+	retArray := make([]Vertex, 0)
+	if s.dimensions == 0 {
+		return retArray
+	}
+	var search func(node *kdNode)
+	search = func(node *kdNode) {
+		if node == nil || node.min[0] > box.MaxX || node.max[0] < box.MinX {
+			return
+		}
+		if s.dimensions > 1 && (node.min[1] > box.MaxY || node.max[1] < box.MinY) {
+			return
+		}
+		inside := node.point[0] >= box.MinX && node.point[0] <= box.MaxX
+		if s.dimensions > 1 {
+			inside = inside && node.point[1] >= box.MinY && node.point[1] <= box.MaxY
+		}
+		if !node.removed && inside {
+			retArray = append(retArray, node.vertex)
+		}
+		search(node.left)
+		search(node.right)
+	}
+	search(s.root)
+	return retArray
+}
+
+func verticesOfMatches(matches []spatialMatch) []Vertex {
+	retArray := make([]Vertex, len(matches))
+	for i, match := range matches {
+		retArray[i] = match.vertex
+	}
+	return retArray
+}
@@ -0,0 +1,178 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func linearNearest(vertices []Vertex, point gomath.Spatial, k int, distanceFunction gomath.DistanceFunction) []Vertex {
+	sorted := make([]Vertex, len(vertices))
+	copy(sorted, vertices)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return distanceFunction(sorted[i], point) < distanceFunction(sorted[j], point)
+	})
+	return sorted[:min(k, len(sorted))]
+}
+
+func TestSpatialIndex_KNearest(t *testing.T) {
+	random := rand.New(rand.NewSource(7))
+	graph := NewSimpleGraph()
+	for i := 0; i < 500; i++ {
+		vertex := NewSimpleVertex(gomath.Point{Values: []float64{random.Float64() * 100, random.Float64() * 100}})
+		graph.AddVertex(&vertex)
+	}
+	index := NewSpatialIndex(graph)
+	if index.Len() != 500 {
+		t.Errorf("Expected 500 vertices, got %d", index.Len())
+	}
+	for _, distanceFunction := range []gomath.DistanceFunction{gomath.EuclideanDistance, gomath.ManhattanDistance} {
+		for i := 0; i < 20; i++ {
+			point := gomath.Point{Values: []float64{random.Float64() * 100, random.Float64() * 100}}
+			expected := linearNearest(graph.GetVertices(), point, 5, distanceFunction)
+			actual := index.KNearest(point, 5, distanceFunction)
+			if len(actual) != 5 {
+				t.Fatalf("Expected 5 vertices, got %d", len(actual))
+			}
+			for j := range expected {
+				if distanceFunction(expected[j], point) != distanceFunction(actual[j], point) {
+					t.Errorf("Neighbour %d differs from a linear scan", j)
+				}
+			}
+		}
+	}
+}
+
+func TestSpatialIndex_RadiusAndBoundingBox(t *testing.T) {
+	vertices := make([]Vertex, 0)
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			vertices = append(vertices, VertexFromSpatial(gomath.Point{Values: []float64{float64(x), float64(y)}}))
+		}
+	}
+	index := NewSpatialIndexFromVertices(vertices)
+	center := gomath.Point{Values: []float64{5.0, 5.0}}
+	within := index.WithinRadius(center, 1.0, gomath.ManhattanDistance)
+	if len(within) != 5 || VertexHashOrId(within[0]) != VertexHashOrId(VertexFromSpatial(center)) {
+		t.Errorf("Expected the center and its 4 neighbours, got %d vertices", len(within))
+	}
+	inside := index.WithinBoundingBox(gomath.BoundingBox{MinX: 1.5, MinY: 2.0, MaxX: 4.0, MaxY: 3.0})
+	if len(inside) != 6 {
+		t.Errorf("Expected 6 vertices in the box, got %d", len(inside))
+	}
+}
+
+func TestSpatialIndex_Incremental(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{10.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	index := graph.SpatialIndex()
+	query := gomath.Point{Values: []float64{4.0, 1.0}}
+	if VertexHashOrId(index.Nearest(query)) != VertexHashOrId(&a) {
+		t.Error("Expected a to be nearest")
+	}
+	c := NewSimpleVertex(gomath.Point{Values: []float64{4.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(&b, &c, -1))
+	if index.Len() != 3 || VertexHashOrId(index.Nearest(query)) != VertexHashOrId(&c) {
+		t.Error("Expected the index to pick up c")
+	}
+	graph.RemoveVertex(&c)
+	if index.Len() != 2 || VertexHashOrId(index.Nearest(query)) != VertexHashOrId(&a) {
+		t.Error("Expected the index to drop c")
+	}
+	graph.RemoveVertex(&a)
+	graph.RemoveVertex(&b)
+	if index.Len() != 0 || index.Nearest(query) != nil {
+		t.Error("Expected an empty index")
+	}
+	graph.AddVertex(&c)
+	if VertexHashOrId(index.Nearest(query)) != VertexHashOrId(&c) {
+		t.Error("Expected the index to be usable after emptying it")
+	}
+}
+
+func TestSpatialIndex_Haversine(t *testing.T) {
+	random := rand.New(rand.NewSource(11))
+	vertices := make([]Vertex, 0)
+	for i := 0; i < 400; i++ {
+		lon, lat := random.Float64()*360-180, random.Float64()*180-90
+		if i%2 == 0 {
+			lon, lat = 170+random.Float64()*10, 60+random.Float64()*29
+		}
+		vertices = append(vertices, VertexFromSpatial(gomath.Point{Values: []float64{lon, lat}}))
+	}
+	index := NewSpatialIndexFromVertices(vertices)
+	for _, point := range []gomath.Point{
+		{Values: []float64{-179.5, 70.0}},
+		{Values: []float64{-175.0, 85.0}},
+		{Values: []float64{100.0, 88.0}},
+		{Values: []float64{179.9, -10.0}},
+		{Values: []float64{0.0, 0.0}},
+	} {
+		expected := linearNearest(vertices, point, 3, gomath.HaversineDistance)
+		actual := index.KNearest(point, 3, gomath.HaversineDistance)
+		for j := range expected {
+			if gomath.HaversineDistance(expected[j], point) != gomath.HaversineDistance(actual[j], point) {
+				t.Errorf("Neighbour %d of %v differs from a linear scan", j, point.Values)
+			}
+		}
+		radius := gomath.HaversineDistance(expected[2], point)
+		if within := index.WithinRadius(point, radius, gomath.HaversineDistance); len(within) < 3 {
+			t.Errorf("Expected at least 3 vertices within %f of %v, got %d", radius, point.Values, len(within))
+		}
+	}
+}
+
+func kdHeight(node *kdNode) int {
+	if node == nil {
+		return 0
+	}
+	return max(kdHeight(node.left), kdHeight(node.right)) + 1
+}
+
+func TestSpatialIndex_SortedInserts(t *testing.T) {
+	index := NewSpatialIndexFromVertices(nil)
+	vertices := make([]Vertex, 0)
+	for i := 0; i < 4000; i++ {
+		vertex := VertexFromSpatial(gomath.Point{Values: []float64{float64(i), float64(i % 7)}})
+		vertices = append(vertices, vertex)
+		index.Insert(vertex)
+	}
+	if height := kdHeight(index.root); height > 40 {
+		t.Errorf("Expected sorted inserts to keep the tree balanced, got a height of %d", height)
+	}
+	for _, x := range []float64{-5, 0, 1234.4, 3999.6, 5000} {
+		point := gomath.Point{Values: []float64{x, 3.0}}
+		expected := linearNearest(vertices, point, 2, gomath.EuclideanDistance)
+		actual := index.KNearest(point, 2, gomath.EuclideanDistance)
+		for j := range expected {
+			if gomath.EuclideanDistance(expected[j], point) != gomath.EuclideanDistance(actual[j], point) {
+				t.Errorf("Neighbour %d of %v differs from a linear scan", j, point.Values)
+			}
+		}
+	}
+	for _, vertex := range vertices[:3000] {
+		index.Remove(vertex)
+	}
+	for _, vertex := range vertices[:1000] {
+		index.Insert(vertex)
+	}
+	if index.Len() != 2000 || len(index.WithinBoundingBox(gomath.BoundingBox{MinX: -1, MinY: -1, MaxX: 5000, MaxY: 10})) != 2000 {
+		t.Errorf("Expected 2000 vertices after removing and inserting, got %d", index.Len())
+	}
+}
+
+func TestSpatialIndex_Copy(t *testing.T) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{10.0, 0.0}})
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	graph.SpatialIndex()
+	copied := copySimpleGraph(graph)
+	nearest := copied.index.Nearest(gomath.Point{Values: []float64{1.0, 1.0}})
+	if nearest == nil || nearest == Vertex(&a) || nearest != copied.GetVertex(VertexHashOrId(&a)) {
+		t.Error("Expected the copy to index its own vertices")
+	}
+}
@@ -0,0 +1,373 @@
+package gograph
+
+import (
+	"errors"
+	"slices"
+	"sort"
+)
+
+// GraphHistory groups the changes of an ObservableGraph into transactions that can be rolled back,
+// and keeps committed transactions on undo and redo stacks. Changes made to the graph outside a
+// transaction cannot be undone, so they clear both stacks.
+//
+// Undo and redo leave the adjacency of every vertex in the order it had before, as long as the
+// changes of a transaction are made through the GraphTransaction.
+type GraphHistory struct {
+	graph       ObservableGraph
+	transaction *GraphTransaction
+	undo        []*graphBatch
+	redo        []*graphBatch
+	applying    bool
+	// updating is the graph of the ConcurrentGraph.Update that Transaction runs in, if any.
+	updating *SimpleGraph
+}
+
+// ErrTransactionOpen is returned when a GraphHistory is asked to begin a transaction, undo or redo
+// while a transaction is open.
+var ErrTransactionOpen = errors.New("a transaction is already open")
+
+// graphBatch is the changes of one transaction, along with the order of the outgoing edge keys of
+// each vertex it touched, as they were before the transaction.
+type graphBatch struct {
+	changes []GraphChange
+	order   map[int64][]int64
+}
+
+func NewGraphHistory(graph ObservableGraph) *GraphHistory {
+	history := &GraphHistory{graph: graph, undo: make([]*graphBatch, 0), redo: make([]*graphBatch, 0)}
+	graph.AddUpdateListener(history)
+	return history
+}
+
+// Close stops recording the changes of the graph.
+func (h *GraphHistory) Close() {
+	h.graph.RemoveUpdateListener(h)
+}
+
+func (h *GraphHistory) Update(change GraphChange) {
+	if h.applying {
+		return
+	}
+	if h.transaction == nil {
+		h.undo = h.undo[:0]
+		h.redo = h.redo[:0]
+		return
+	}
+	h.transaction.batch.changes = append(h.transaction.batch.changes, change)
+}
+
+// Begin opens a transaction. Only one transaction can be open at a time. The changes of a
+// transaction begun on a ConcurrentGraph are visible to its readers one by one; use Transaction to
+// apply them in a single step.
+func (h *GraphHistory) Begin() (*GraphTransaction, error) {
+	return h.begin(h.graph)
+}
+
+func (h *GraphHistory) begin(graph Graph) (*GraphTransaction, error) {
+	if h.transaction != nil {
+		return nil, ErrTransactionOpen
+	}
+	h.transaction = &GraphTransaction{
+		Graph:   graph,
+		history: h,
+		batch:   &graphBatch{changes: make([]GraphChange, 0), order: make(map[int64][]int64)},
+	}
+	return h.transaction, nil
+}
+
+// Transaction runs fn in a new transaction, which is committed when fn returns nil and rolled
+// back when it returns an error or panics. On a ConcurrentGraph the whole transaction, rollback
+// included, runs under the write lock, so readers see either none or all of it. fn must then read
+// and write through the transaction only, since calling the ConcurrentGraph would deadlock.
+func (h *GraphHistory) Transaction(fn func(transaction *GraphTransaction) error) error {
+	concurrentGraph, ok := h.graph.(*ConcurrentGraph)
+	if !ok {
+		return h.run(h.graph, fn)
+	}
+	if h.transaction != nil {
+		return ErrTransactionOpen
+	}
+	var err error
+	concurrentGraph.Update(func(graph *SimpleGraph) {
+		h.updating = graph
+		defer func() {
+			h.updating = nil
+		}()
+		err = h.run(graph, fn)
+	})
+	return err
+}
+
+func (h *GraphHistory) run(graph Graph, fn func(transaction *GraphTransaction) error) error {
+	transaction, err := h.begin(graph)
+	if err != nil {
+		return err
+	}
+	done := false
+	defer func() {
+		if !done {
+			_ = transaction.Rollback()
+		}
+	}()
+	if err := fn(transaction); err != nil {
+		done = true
+		return errors.Join(err, transaction.Rollback())
+	}
+	done = true
+	transaction.Commit()
+	return nil
+}
+
+func (h *GraphHistory) CanUndo() bool {
+	return len(h.undo) > 0
+}
+
+func (h *GraphHistory) CanRedo() bool {
+	return len(h.redo) > 0
+}
+
+// Undo reverts the last committed transaction and reports whether there was one. The transaction
+// moves to the redo stack even when one of its changes cannot be reverted.
+func (h *GraphHistory) Undo() (bool, error) {
+	if h.transaction != nil {
+		return false, ErrTransactionOpen
+	}
+	if len(h.undo) == 0 {
+		return false, nil
+	}
+	batch := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	err := h.revert(batch)
+	h.redo = append(h.redo, batch)
+	return true, err
+}
+
+// Redo applies the last undone transaction again and reports whether there was one. The
+// transaction moves to the undo stack even when one of its changes cannot be applied.
+func (h *GraphHistory) Redo() (bool, error) {
+	if h.transaction != nil {
+		return false, ErrTransactionOpen
+	}
+	if len(h.redo) == 0 {
+		return false, nil
+	}
+	batch := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	err := h.edit(func(graph Graph) error {
+		for _, change := range batch.changes {
+			if err := applyGraphChange(graph, change, h.copies()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	h.undo = append(h.undo, batch)
+	return true, err
+}
+
+// revert applies the inverse of the changes of batch in reverse order, stopping at the first one
+// that fails.
+func (h *GraphHistory) revert(batch *graphBatch) error {
+	return h.edit(func(graph Graph) error {
+		for i := len(batch.changes) - 1; i >= 0; i-- {
+			inverse, err := InverseGraphChange(batch.changes[i])
+			if err != nil {
+				return err
+			}
+			if err := applyGraphChange(graph, inverse, h.copies()); err != nil {
+				return err
+			}
+		}
+		for key, order := range batch.order {
+			if vertex, ok := graph.GetVertex(key).(*SimpleVertex); ok {
+				sortAdjacency(vertex, order)
+			}
+		}
+		return nil
+	})
+}
+
+// copies reports whether undo and redo must add copies of the recorded vertices and edges. A
+// ConcurrentGraph may have handed the recorded ones out in a snapshot.
+func (h *GraphHistory) copies() bool {
+	_, ok := h.graph.(*ConcurrentGraph)
+	return ok
+}
+
+// edit runs fn without recording its changes, in a single step for a ConcurrentGraph. Within
+// Transaction it runs on the graph of the ConcurrentGraph.Update that is already under way.
+func (h *GraphHistory) edit(fn func(graph Graph) error) error {
+	h.applying = true
+	defer func() {
+		h.applying = false
+	}()
+	if h.updating != nil {
+		return fn(h.updating)
+	}
+	if concurrentGraph, ok := h.graph.(*ConcurrentGraph); ok {
+		var err error
+		concurrentGraph.Update(func(graph *SimpleGraph) {
+			err = fn(graph)
+		})
+		return err
+	}
+	return fn(h.graph)
+}
+
+// sortAdjacency orders the edges of vertex by the position of their key in order. Edges missing
+// from order keep their relative order at the end.
+func sortAdjacency(vertex *SimpleVertex, order []int64) {
+	positions := make(map[int64]int, len(order))
+	for i, key := range order {
+		positions[key] = i
+	}
+	position := func(edge Edge) int {
+		if i, ok := positions[EdgeHashOrId(edge)]; ok {
+			return i
+		}
+		return len(order)
+	}
+	sort.SliceStable(vertex.Edges, func(i, j int) bool {
+		return position(vertex.Edges[i]) < position(vertex.Edges[j])
+	})
+}
+
+// GraphTransaction is an open transaction of a GraphHistory. It reads from and writes to the
+// graph directly, and remembers the adjacency order of the vertices it changes so that a rollback
+// or undo can restore it.
+type GraphTransaction struct {
+	Graph
+	history *GraphHistory
+	batch   *graphBatch
+	closed  bool
+}
+
+// adjacencyReader is implemented by graphs that can copy the adjacency of a vertex without
+// handing out their vertices.
+type adjacencyReader interface {
+	adjacency(key int64) ([]Edge, []Edge, bool)
+}
+
+func (t *GraphTransaction) adjacency(key int64) ([]Edge, []Edge, bool) {
+	if reader, ok := t.Graph.(adjacencyReader); ok {
+		return reader.adjacency(key)
+	}
+	vertex := t.Graph.GetVertex(key)
+	if vertex == nil {
+		return nil, nil, false
+	}
+	return append([]Edge{}, vertex.GetEdges()...), t.Graph.GetIncomingEdges(vertex), true
+}
+
+// remember records the adjacency order of the vertices with keys the first time they are changed.
+func (t *GraphTransaction) remember(keys ...int64) {
+	t.checkOpen()
+	for _, key := range keys {
+		if _, ok := t.batch.order[key]; ok {
+			continue
+		}
+		outgoing, _, ok := t.adjacency(key)
+		if !ok {
+			continue
+		}
+		order := make([]int64, len(outgoing))
+		for i, edge := range outgoing {
+			order[i] = EdgeHashOrId(edge)
+		}
+		t.batch.order[key] = order
+	}
+}
+
+func (t *GraphTransaction) rememberEdge(e Edge) {
+	t.remember(VertexHashOrId(ToVertex(e.From())), VertexHashOrId(ToVertex(e.To())))
+}
+
+func (t *GraphTransaction) AddEdge(e Edge) {
+	t.rememberEdge(e)
+	t.Graph.AddEdge(e)
+}
+
+func (t *GraphTransaction) AddVertex(v Vertex) {
+	t.checkOpen()
+	t.Graph.AddVertex(v)
+}
+
+func (t *GraphTransaction) RemoveEdge(e Edge) {
+	t.rememberEdge(e)
+	t.Graph.RemoveEdge(e)
+}
+
+// RemoveVertex removes v and its edges, remembering the adjacency order of its neighbours.
+func (t *GraphTransaction) RemoveVertex(v Vertex) {
+	key := VertexHashOrId(v)
+	t.remember(key)
+	outgoing, incoming, _ := t.adjacency(key)
+	for _, edge := range outgoing {
+		t.remember(VertexHashOrId(ToVertex(edge.To())))
+	}
+	for _, edge := range incoming {
+		t.remember(VertexHashOrId(ToVertex(edge.From())))
+	}
+	t.Graph.RemoveVertex(v)
+}
+
+func (t *GraphTransaction) Clear() {
+	for _, vertex := range t.Graph.GetVertices() {
+		t.remember(VertexHashOrId(vertex))
+	}
+	t.Graph.Clear()
+}
+
+// SetEdgeCost sets the cost of e for key. The graph must support cost changes, like SimpleGraph
+// and ConcurrentGraph do.
+func (t *GraphTransaction) SetEdgeCost(e Edge, key string, value float64) {
+	t.checkOpen()
+	t.costEditor().SetEdgeCost(e, key, value)
+}
+
+func (t *GraphTransaction) RemoveEdgeCost(e Edge, key string) {
+	t.checkOpen()
+	t.costEditor().RemoveEdgeCost(e, key)
+}
+
+func (t *GraphTransaction) costEditor() costEditor {
+	editor, ok := t.Graph.(costEditor)
+	if !ok {
+		panic("The graph does not support cost changes")
+	}
+	return editor
+}
+
+// Commit closes the transaction and pushes its changes onto the undo stack, clearing the redo
+// stack. A transaction without changes is dropped.
+func (t *GraphTransaction) Commit() {
+	t.close()
+	if len(t.batch.changes) == 0 {
+		return
+	}
+	t.history.undo = append(t.history.undo, t.batch)
+	t.history.redo = t.history.redo[:0]
+}
+
+// Rollback closes the transaction and reverts its changes.
+func (t *GraphTransaction) Rollback() error {
+	t.close()
+	return t.history.revert(t.batch)
+}
+
+func (t *GraphTransaction) checkOpen() {
+	if t.closed {
+		panic("GraphTransaction is closed")
+	}
+}
+
+func (t *GraphTransaction) close() {
+	t.checkOpen()
+	t.closed = true
+	t.history.transaction = nil
+}
+
+// Changes returns the changes made so far in the transaction.
+func (t *GraphTransaction) Changes() []GraphChange {
+	return slices.Clone(t.batch.changes)
+}
@@ -0,0 +1,219 @@
+package gograph
+
+import (
+	"errors"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"testing"
+	"time"
+)
+
+func adjacencyKeys(vertex Vertex) []int64 {
+	retArray := make([]int64, 0, len(vertex.GetEdges()))
+	for _, edge := range vertex.GetEdges() {
+		retArray = append(retArray, EdgeHashOrId(edge))
+	}
+	return retArray
+}
+
+func equalKeys(left []int64, right []int64) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for i := range left {
+		if left[i] != right[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func buildTransactionTestGraph() (*SimpleGraph, []Vertex) {
+	graph := NewSimpleGraph()
+	vertices := []Vertex{
+		VertexFromSpatial(gomath.Point{Values: []float64{0.0, 0.0}}),
+		VertexFromSpatial(gomath.Point{Values: []float64{1.0, 0.0}}),
+		VertexFromSpatial(gomath.Point{Values: []float64{2.0, 0.0}}),
+		VertexFromSpatial(gomath.Point{Values: []float64{1.0, 1.0}}),
+	}
+	graph.AddEdge(NewSimpleEdge(vertices[0], vertices[1], -1))
+	graph.AddEdge(NewSimpleEdge(vertices[1], vertices[2], -1))
+	graph.AddEdge(NewSimpleEdge(vertices[1], vertices[3], -1))
+	graph.AddEdge(NewSimpleEdge(vertices[0], vertices[3], -1))
+	return graph, vertices
+}
+
+func TestGraphHistory_UndoRedo(t *testing.T) {
+	graph, vertices := buildTransactionTestGraph()
+	history := NewGraphHistory(graph)
+	hash := graph.Hash()
+	before := adjacencyKeys(vertices[1])
+
+	transaction, err := history.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := history.Begin(); !errors.Is(err, ErrTransactionOpen) {
+		t.Errorf("Expected a second transaction to fail, got %v", err)
+	}
+	if _, err := history.Undo(); !errors.Is(err, ErrTransactionOpen) {
+		t.Errorf("Expected undo to fail while a transaction is open, got %v", err)
+	}
+	if _, err := history.Redo(); !errors.Is(err, ErrTransactionOpen) {
+		t.Errorf("Expected redo to fail while a transaction is open, got %v", err)
+	}
+	transaction.RemoveEdge(NewSimpleEdge(vertices[0], vertices[1], -1))
+	detour := VertexFromSpatial(gomath.Point{Values: []float64{0.5, -1.0}})
+	transaction.AddEdge(NewSimpleEdge(vertices[0], detour, -1))
+	transaction.AddEdge(NewSimpleEdge(detour, vertices[1], -1))
+	transaction.SetEdgeCost(NewSimpleEdge(vertices[1], vertices[2], -1), COST_TYPE_TIME, 4.0)
+	transaction.RemoveVertex(vertices[3])
+	transaction.Commit()
+	committed := graph.Hash()
+	after := adjacencyKeys(vertices[1])
+
+	if undone, err := history.Undo(); !undone || err != nil || history.CanUndo() || !history.CanRedo() {
+		t.Fatal("Expected to undo the transaction")
+	}
+	if graph.Hash() != hash || graph.Size() != 4 || !graph.Validate().IsValid() {
+		t.Error("Expected the graph to be restored")
+	}
+	if !equalKeys(adjacencyKeys(vertices[1]), before) {
+		t.Errorf("Expected the adjacency order %v, got %v", before, adjacencyKeys(vertices[1]))
+	}
+	if cost := GetEdge(vertices[1], vertices[2]).Cost(); cost != nil && len(*cost) > 0 {
+		t.Error("Expected the cost change to be undone")
+	}
+
+	if redone, err := history.Redo(); !redone || err != nil || history.CanRedo() {
+		t.Fatal("Expected to redo the transaction")
+	}
+	if graph.Hash() != committed || !graph.Validate().IsValid() || !equalKeys(adjacencyKeys(vertices[1]), after) {
+		t.Error("Expected the graph to match the committed transaction")
+	}
+	if cost := GetEdge(vertices[1], vertices[2]).Cost(); cost == nil || (*cost)[COST_TYPE_TIME] != 4.0 {
+		t.Error("Expected the cost change to be redone")
+	}
+
+	graph.AddVertex(VertexFromSpatial(gomath.Point{Values: []float64{5.0, 5.0}}))
+	if history.CanUndo() {
+		t.Error("Expected a change outside a transaction to clear the history")
+	}
+}
+
+func TestGraphHistory_Rollback(t *testing.T) {
+	graph, vertices := buildTransactionTestGraph()
+	history := NewGraphHistory(graph)
+	hash := graph.Hash()
+	before := adjacencyKeys(vertices[0])
+
+	closed := errors.New("road closed")
+	err := history.Transaction(func(transaction *GraphTransaction) error {
+		transaction.RemoveVertex(vertices[1])
+		transaction.AddEdge(NewSimpleEdge(vertices[0], vertices[2], -1))
+		return closed
+	})
+	if !errors.Is(err, closed) {
+		t.Errorf("Expected the error of the transaction, got %v", err)
+	}
+	if graph.Hash() != hash || !graph.Validate().IsValid() || !equalKeys(adjacencyKeys(vertices[0]), before) {
+		t.Error("Expected the transaction to be rolled back")
+	}
+	if history.CanUndo() {
+		t.Error("Expected a rolled back transaction not to be undoable")
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected the panic to propagate")
+			}
+		}()
+		_ = history.Transaction(func(transaction *GraphTransaction) error {
+			transaction.RemoveEdge(NewSimpleEdge(vertices[1], vertices[2], -1))
+			panic("failed")
+		})
+	}()
+	if graph.Hash() != hash || len(graph.GetEdges()) != 8 {
+		t.Error("Expected a panicking transaction to be rolled back")
+	}
+}
+
+func TestGraphHistory_ConcurrentGraph(t *testing.T) {
+	simpleGraph, vertices := buildTransactionTestGraph()
+	graph := NewConcurrentGraphFrom(simpleGraph)
+	history := NewGraphHistory(graph)
+	hash := graph.Hash()
+	snapshot := graph.Snapshot()
+
+	err := history.Transaction(func(transaction *GraphTransaction) error {
+		transaction.RemoveVertex(vertices[1])
+		transaction.SetEdgeCost(NewSimpleEdge(vertices[0], vertices[3], -1), COST_TYPE_TIME, 2.0)
+		return nil
+	})
+	if err != nil || graph.Size() != 3 {
+		t.Fatal("Expected the transaction to be committed")
+	}
+	if _, err := history.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if graph.Hash() != hash || graph.Size() != 4 || len(graph.GetEdges()) != 8 {
+		t.Error("Expected the concurrent graph to be restored")
+	}
+	if snapshot.Size() != 4 || GetEdge(snapshot.GetVertex(VertexHashOrId(vertices[0])), vertices[3]).Cost() != nil {
+		t.Error("Expected the snapshot to be unchanged")
+	}
+	if _, err := history.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if graph.Size() != 3 {
+		t.Error("Expected the transaction to be redone")
+	}
+}
+
+func TestGraphHistory_ConcurrentTransactionIsAtomic(t *testing.T) {
+	simpleGraph, vertices := buildTransactionTestGraph()
+	graph := NewConcurrentGraphFrom(simpleGraph)
+	history := NewGraphHistory(graph)
+	hash := graph.Hash()
+
+	sizes := make(chan int, 1)
+	closed := errors.New("road closed")
+	err := history.Transaction(func(transaction *GraphTransaction) error {
+		transaction.RemoveVertex(vertices[1])
+		go func() {
+			sizes <- graph.Size()
+		}()
+		select {
+		case size := <-sizes:
+			t.Errorf("Expected readers to wait for the transaction, got size %d", size)
+		case <-time.After(20 * time.Millisecond):
+		}
+		transaction.RemoveVertex(vertices[3])
+		return closed
+	})
+	if !errors.Is(err, closed) {
+		t.Errorf("Expected the error of the transaction, got %v", err)
+	}
+	select {
+	case size := <-sizes:
+		if size != 4 {
+			t.Errorf("Expected the reader to see the rolled back graph, got size %d", size)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the reader to finish after the transaction")
+	}
+	if graph.Hash() != hash || len(graph.GetEdges()) != 8 || history.CanUndo() {
+		t.Error("Expected the transaction to be rolled back")
+	}
+
+	err = history.Transaction(func(transaction *GraphTransaction) error {
+		transaction.RemoveVertex(vertices[1])
+		return nil
+	})
+	if err != nil || graph.Size() != 3 || !history.CanUndo() {
+		t.Fatal("Expected the transaction to be committed")
+	}
+	if undone, err := history.Undo(); !undone || err != nil || graph.Hash() != hash {
+		t.Error("Expected the transaction to be undone")
+	}
+}
@@ -5,13 +5,13 @@ import (
 	"github.com/mtresnik/goutils/pkg/goutils"
 	"math"
 	"math/rand"
-	"time"
 )
 
 type TSPRequest struct {
 	Graph            Graph
 	DistanceFunction *gomath.DistanceFunction
 	MaxIterations    int
+	Random           *rand.Rand
 }
 
 type TSPResponse struct {
@@ -38,19 +38,19 @@ var GreedyTSP TSP = func(request TSPRequest) TSPResponse {
 		distanceFunction = *request.DistanceFunction
 	}
 
-	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	random := randomOrDefault(request.Random)
 	startIndex := random.Intn(numVertices)
 	startVertex := vertices[startIndex]
 	currentVertex := startVertex
 
 	visited = append(visited, currentVertex)
-	visitedSet[currentVertex.Hash()] = true
+	visitedSet[VertexHashOrId(currentVertex)] = true
 
 	for len(visited) < numVertices {
 		currentEdges := currentVertex.GetEdges()
 
 		nextEdges := goutils.Filter(currentEdges, func(edge Edge) bool {
-			return !goutils.SetContains(visitedSet, ToVertex(edge.To()).Hash())
+			return !goutils.SetContains(visitedSet, VertexHashOrId(ToVertex(edge.To())))
 		})
 
 		if len(nextEdges) == 0 {
@@ -58,7 +58,7 @@ var GreedyTSP TSP = func(request TSPRequest) TSPResponse {
 			minDistance := math.MaxFloat64
 
 			for _, vertex := range vertices {
-				if !visitedSet[vertex.Hash()] {
+				if !visitedSet[VertexHashOrId(vertex)] {
 					distance := distanceFunction(currentVertex, vertex)
 					if distance < minDistance {
 						minDistance = distance
@@ -71,14 +71,14 @@ var GreedyTSP TSP = func(request TSPRequest) TSPResponse {
 			visitedEdges = append(visitedEdges, newEdge)
 			currentVertex = closestVertex
 			visited = append(visited, currentVertex)
-			visitedSet[currentVertex.Hash()] = true
+			visitedSet[VertexHashOrId(currentVertex)] = true
 		} else {
 			closestEdge := goutils.MinBy(nextEdges, func(edge Edge) float64 {
 				return edge.DistanceCached(distanceFunction)
 			})
 			currentVertex = ToVertex(closestEdge.To())
 			visited = append(visited, currentVertex)
-			visitedSet[currentVertex.Hash()] = true
+			visitedSet[VertexHashOrId(currentVertex)] = true
 			visitedEdges = append(visitedEdges, closestEdge)
 		}
 	}
@@ -112,7 +112,7 @@ var RandomTSP TSP = func(request TSPRequest) TSPResponse {
 		return TSPResponse{Path: NewSimplePath([]Edge{})}
 	}
 
-	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	random := randomOrDefault(request.Random)
 	startIndex := random.Intn(numVertices)
 	startVertex := vertices[startIndex]
 	currentVertex := startVertex
@@ -121,19 +121,19 @@ var RandomTSP TSP = func(request TSPRequest) TSPResponse {
 	visitedEdges := make([]Edge, 0)
 	visitedOrder := make([]Vertex, 0)
 
-	visitedSet[currentVertex.Hash()] = true
+	visitedSet[VertexHashOrId(currentVertex)] = true
 	visitedOrder = append(visitedOrder, currentVertex)
 
 	for len(visitedOrder) < numVertices {
 		currentEdges := currentVertex.GetEdges()
 
 		availableEdges := goutils.Filter(currentEdges, func(edge Edge) bool {
-			return !goutils.SetContains(visitedSet, ToVertex(edge.To()).Hash())
+			return !goutils.SetContains(visitedSet, VertexHashOrId(ToVertex(edge.To())))
 		})
 
 		if len(availableEdges) == 0 {
 			unvisitedVertex := vertices[random.Intn(numVertices)]
-			for visitedSet[unvisitedVertex.Hash()] {
+			for visitedSet[VertexHashOrId(unvisitedVertex)] {
 				unvisitedVertex = vertices[random.Intn(numVertices)]
 			}
 			newEdge := NewEdge(currentVertex, unvisitedVertex)
@@ -145,7 +145,7 @@ var RandomTSP TSP = func(request TSPRequest) TSPResponse {
 			visitedEdges = append(visitedEdges, randomEdge)
 		}
 		visitedOrder = append(visitedOrder, currentVertex)
-		visitedSet[currentVertex.Hash()] = true
+		visitedSet[VertexHashOrId(currentVertex)] = true
 	}
 	path := NewSimplePath(visitedEdges).Wrap()
 	return TSPResponse{Path: path}
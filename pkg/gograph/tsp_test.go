@@ -90,3 +90,25 @@ func TestRepeatTSP_Eval(t *testing.T) {
 	defer file.Close()
 	png.Encode(file, img)
 }
+
+func TestTSP_Seeded(t *testing.T) {
+	graph := BoundedRandomGraphProvider{
+		BoundingBox:    gomath.BoundingBox{MinX: 10, MinY: 10, MaxX: 20, MaxY: 20},
+		NumPoints:      60,
+		NumConnections: 4,
+		Random:         NewSeededRandom(1),
+	}.Build()
+	for name, tsp := range map[string]TSP{"GreedyTSP": GreedyTSP, "RandomTSP": RandomTSP} {
+		first := tsp(TSPRequest{Graph: graph, Random: NewSeededRandom(7)}).Path.GetEdges()
+		second := tsp(TSPRequest{Graph: graph, Random: NewSeededRandom(7)}).Path.GetEdges()
+		if len(first) != len(second) {
+			t.Fatalf("%s: expected tours of the same length", name)
+		}
+		for i := range first {
+			if EdgeHashOrId(first[i]) != EdgeHashOrId(second[i]) {
+				t.Errorf("%s: expected the same seed to produce the same tour", name)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,487 @@
+package gograph
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// TSPLIB edge weight types supported by ReadTSPLIB.
+const (
+	TSPLIB_EUC_2D   = "EUC_2D"
+	TSPLIB_GEO      = "GEO"
+	TSPLIB_ATT      = "ATT"
+	TSPLIB_EXPLICIT = "EXPLICIT"
+)
+
+var ErrTSPLIBUnsupported = errors.New("unsupported TSPLIB file")
+
+// TSPLIBInstance is a TSPLIB problem. Graph is the complete graph of its nodes, with vertex ids
+// equal to the node numbers, and DistanceFunction measures the TSPLIB distance between two of its
+// vertices, rounded like TSPLIB does, so that tours can be compared with published optima.
+type TSPLIBInstance struct {
+	Name             string
+	Comment          string
+	Type             string
+	EdgeWeightType   string
+	Dimension        int
+	Graph            *SimpleGraph
+	DistanceFunction gomath.DistanceFunction
+}
+
+// Request returns a TSPRequest for the instance using its distance function.
+func (i *TSPLIBInstance) Request() TSPRequest {
+	return TSPRequest{Graph: i.Graph, DistanceFunction: &i.DistanceFunction}
+}
+
+// TourLength is the length of a tour under the distance function of the instance.
+func (i *TSPLIBInstance) TourLength(tour Path) float64 {
+	return GetPathDistance(tour, i.DistanceFunction)
+}
+
+// TourGap reports how much longer tour is than optimal as a fraction of the optimal length, so a
+// tour 5% longer than the optimum has a gap of 0.05. Lengths use the distance function of the
+// instance. When the optimal length is zero, the gap is zero for a tour of length zero and
+// infinite otherwise.
+func (i *TSPLIBInstance) TourGap(tour Path, optimal Path) float64 {
+	length, optimalLength := i.TourLength(tour), i.TourLength(optimal)
+	if optimalLength == 0 {
+		if length == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return (length - optimalLength) / optimalLength
+}
+
+// tsplibLines reads the non-empty lines of a TSPLIB file, numbering them from 1.
+type tsplibLines struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newTSPLIBLines(reader io.Reader) *tsplibLines {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	return &tsplibLines{scanner: scanner}
+}
+
+// next returns the fields of the next non-empty line, or false at the end of the file.
+func (l *tsplibLines) next() ([]string, bool) {
+	for l.scanner.Scan() {
+		l.line++
+		if fields := strings.Fields(l.scanner.Text()); len(fields) > 0 {
+			return fields, true
+		}
+	}
+	return nil, false
+}
+
+func (l *tsplibLines) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", l.line, fmt.Sprintf(format, args...))
+}
+
+// tsplibKeyword splits a specification line such as "NAME : att48" into its keyword and value.
+func tsplibKeyword(text string) (string, string) {
+	keyword, value, found := strings.Cut(text, ":")
+	if !found {
+		fields := strings.Fields(text)
+		return fields[0], strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), fields[0]))
+	}
+	return strings.TrimSpace(keyword), strings.TrimSpace(value)
+}
+
+// ReadTSPLIB reads a TSPLIB .tsp file of type TSP, or ATSP with a full matrix, whose edge weight
+// type is EUC_2D, GEO, ATT or EXPLICIT. The nodes of an EXPLICIT instance are placed at their
+// DISPLAY_DATA_SECTION coordinates when it has them, and at [node, 0] otherwise. Every edge of
+// the complete graph has its TSPLIB distance as its distance cost.
+func ReadTSPLIB(reader io.Reader) (*TSPLIBInstance, error) {
+	instance := &TSPLIBInstance{Type: "TSP"}
+	lines := newTSPLIBLines(reader)
+	format := "FULL_MATRIX"
+	var coordinates [][]float64
+	var weights []float64
+	for {
+		fields, ok := lines.next()
+		if !ok {
+			break
+		}
+		keyword, value := tsplibKeyword(strings.Join(fields, " "))
+		switch keyword {
+		case "NAME":
+			instance.Name = value
+		case "COMMENT":
+			instance.Comment = strings.TrimSpace(strings.Join([]string{instance.Comment, value}, " "))
+		case "TYPE":
+			instance.Type = value
+		case "DIMENSION":
+			dimension, err := strconv.Atoi(value)
+			if err != nil || dimension < 1 {
+				return nil, lines.errorf("invalid DIMENSION %q", value)
+			}
+			instance.Dimension = dimension
+		case "EDGE_WEIGHT_TYPE":
+			instance.EdgeWeightType = value
+		case "EDGE_WEIGHT_FORMAT":
+			format = value
+		case "NODE_COORD_TYPE", "DISPLAY_DATA_TYPE", "CAPACITY":
+		case "NODE_COORD_SECTION", "DISPLAY_DATA_SECTION":
+			if instance.Dimension == 0 {
+				return nil, lines.errorf("%s before DIMENSION", keyword)
+			}
+			section, err := readTSPLIBCoordinates(lines, instance.Dimension)
+			if err != nil {
+				return nil, err
+			}
+			if keyword == "NODE_COORD_SECTION" || coordinates == nil {
+				coordinates = section
+			}
+		case "EDGE_WEIGHT_SECTION":
+			if instance.Dimension == 0 {
+				return nil, lines.errorf("EDGE_WEIGHT_SECTION before DIMENSION")
+			}
+			count, err := tsplibWeightCount(format, instance.Dimension)
+			if err != nil {
+				return nil, lines.errorf("%s", err)
+			}
+			weights = make([]float64, 0, count)
+			for len(weights) < count {
+				fields, ok := lines.next()
+				if !ok {
+					return nil, lines.errorf("expected %d edge weights, found %d", count, len(weights))
+				}
+				for _, field := range fields {
+					weight, err := strconv.ParseFloat(field, 64)
+					if err != nil {
+						return nil, lines.errorf("invalid edge weight %q", field)
+					}
+					weights = append(weights, weight)
+				}
+			}
+		case "FIXED_EDGES_SECTION":
+			for {
+				fields, ok := lines.next()
+				if !ok || fields[0] == "-1" {
+					break
+				}
+			}
+		case "EOF":
+		default:
+			return nil, fmt.Errorf("%w: line %d: keyword %s", ErrTSPLIBUnsupported, lines.line, keyword)
+		}
+	}
+	if err := lines.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if instance.Type != "TSP" && !(instance.Type == "ATSP" && format == "FULL_MATRIX") {
+		return nil, fmt.Errorf("%w: TYPE %s", ErrTSPLIBUnsupported, instance.Type)
+	}
+	if instance.Dimension == 0 {
+		return nil, errors.New("TSPLIB file without DIMENSION")
+	}
+
+	switch instance.EdgeWeightType {
+	case TSPLIB_EUC_2D:
+		instance.DistanceFunction = TSPLIBEuclideanDistance
+	case TSPLIB_ATT:
+		instance.DistanceFunction = TSPLIBPseudoEuclideanDistance
+	case TSPLIB_GEO:
+		instance.DistanceFunction = TSPLIBGeographicalDistance
+	case TSPLIB_EXPLICIT:
+		if weights == nil {
+			return nil, errors.New("EXPLICIT TSPLIB file without EDGE_WEIGHT_SECTION")
+		}
+		if coordinates == nil {
+			coordinates = make([][]float64, instance.Dimension)
+			for i := range coordinates {
+				coordinates[i] = []float64{float64(i + 1), 0}
+			}
+		}
+		instance.DistanceFunction = tsplibMatrixDistance(tsplibMatrix(format, instance.Dimension, weights), coordinates)
+	default:
+		return nil, fmt.Errorf("%w: EDGE_WEIGHT_TYPE %s", ErrTSPLIBUnsupported, instance.EdgeWeightType)
+	}
+	if coordinates == nil {
+		return nil, errors.New("TSPLIB file without NODE_COORD_SECTION")
+	}
+
+	instance.Graph = newTSPLIBGraph(instance.Type == "ATSP", coordinates, instance.DistanceFunction)
+	return instance, nil
+}
+
+// newTSPLIBGraph builds the complete graph of the nodes. The two directions of an undirected edge
+// share their cost map, as they do after SetEdgeCost.
+func newTSPLIBGraph(directed bool, coordinates [][]float64, distanceFunction gomath.DistanceFunction) *SimpleGraph {
+	graph := newSimpleGraph(directed)
+	vertices := make([]*SimpleVertex, len(coordinates))
+	for i := range vertices {
+		vertices[i] = VertexRecord{Id: int64(i + 1), Values: coordinates[i]}.Vertex()
+		vertices[i].Edges = make([]Edge, 0, len(coordinates)-1)
+		graph.AddVertex(vertices[i])
+	}
+	for i, from := range vertices {
+		for j, to := range vertices {
+			if i != j && (directed || i < j) {
+				graph.appendEdge(NewSimpleEdge(from, to, -1, &map[string]float64{COST_TYPE_DISTANCE: distanceFunction(from, to)}))
+			}
+		}
+	}
+	return graph
+}
+
+// readTSPLIBCoordinates reads the "node x y" lines of a coordinate section.
+func readTSPLIBCoordinates(lines *tsplibLines, dimension int) ([][]float64, error) {
+	coordinates := make([][]float64, dimension)
+	for i := 0; i < dimension; i++ {
+		fields, ok := lines.next()
+		if !ok {
+			return nil, lines.errorf("expected %d nodes, found %d", dimension, i)
+		}
+		node, err := strconv.Atoi(fields[0])
+		if err != nil || node < 1 || node > dimension || coordinates[node-1] != nil || len(fields) < 3 {
+			return nil, lines.errorf("invalid node %q", strings.Join(fields, " "))
+		}
+		values := make([]float64, len(fields)-1)
+		for j, field := range fields[1:] {
+			if values[j], err = strconv.ParseFloat(field, 64); err != nil {
+				return nil, lines.errorf("invalid coordinate %q", field)
+			}
+		}
+		coordinates[node-1] = values
+	}
+	return coordinates, nil
+}
+
+func tsplibWeightCount(format string, dimension int) (int, error) {
+	switch format {
+	case "FULL_MATRIX":
+		return dimension * dimension, nil
+	case "UPPER_ROW", "LOWER_ROW", "UPPER_COL", "LOWER_COL":
+		return dimension * (dimension - 1) / 2, nil
+	case "UPPER_DIAG_ROW", "LOWER_DIAG_ROW", "UPPER_DIAG_COL", "LOWER_DIAG_COL":
+		return dimension * (dimension + 1) / 2, nil
+	}
+	return 0, fmt.Errorf("%w: EDGE_WEIGHT_FORMAT %s", ErrTSPLIBUnsupported, format)
+}
+
+// tsplibMatrix expands the weights of an EDGE_WEIGHT_SECTION into a full matrix. A column format
+// lists the same weights as the row format of the opposite triangle.
+func tsplibMatrix(format string, dimension int, weights []float64) [][]float64 {
+	matrix := make([][]float64, dimension)
+	for i := range matrix {
+		matrix[i] = make([]float64, dimension)
+	}
+	set := func(i, j int, weight float64) {
+		matrix[i][j], matrix[j][i] = weight, weight
+	}
+	next := 0
+	switch format {
+	case "FULL_MATRIX":
+		for i := 0; i < dimension; i++ {
+			for j := 0; j < dimension; j++ {
+				matrix[i][j] = weights[next]
+				next++
+			}
+		}
+	case "UPPER_ROW", "LOWER_COL", "UPPER_DIAG_ROW", "LOWER_DIAG_COL":
+		offset := 1
+		if strings.Contains(format, "DIAG") {
+			offset = 0
+		}
+		for i := 0; i < dimension; i++ {
+			for j := i + offset; j < dimension; j++ {
+				set(i, j, weights[next])
+				next++
+			}
+		}
+	default:
+		offset := 0
+		if strings.Contains(format, "DIAG") {
+			offset = 1
+		}
+		for i := 0; i < dimension; i++ {
+			for j := 0; j < i+offset; j++ {
+				set(i, j, weights[next])
+				next++
+			}
+		}
+	}
+	return matrix
+}
+
+// tsplibMatrixDistance looks up the matrix by vertex id, or by position for other spatials. The
+// distance to a spatial that is not a node of the instance is infinite.
+func tsplibMatrixDistance(matrix [][]float64, coordinates [][]float64) gomath.DistanceFunction {
+	positions := make(map[int64]int, len(coordinates))
+	for i, values := range coordinates {
+		positions[gomath.HashSpatial(gomath.Point{Values: values})] = i
+	}
+	index := func(spatial gomath.Spatial) (int, bool) {
+		if vertex, ok := spatial.(Vertex); ok && vertex.Id() > 0 && vertex.Id() <= int64(len(matrix)) {
+			return int(vertex.Id() - 1), true
+		}
+		position, ok := positions[gomath.HashSpatial(gomath.Point{Values: spatial.GetValues()})]
+		return position, ok
+	}
+	return func(one, other gomath.Spatial) float64 {
+		from, ok := index(one)
+		if !ok {
+			return math.Inf(1)
+		}
+		to, ok := index(other)
+		if !ok {
+			return math.Inf(1)
+		}
+		return matrix[from][to]
+	}
+}
+
+func tsplibRound(value float64) float64 {
+	return math.Floor(value + 0.5)
+}
+
+// TSPLIBEuclideanDistance is the EUC_2D distance, the Euclidean distance rounded to the nearest
+// integer.
+var TSPLIBEuclideanDistance gomath.DistanceFunction = func(one, other gomath.Spatial) float64 {
+	dx, dy := one.X()-other.X(), one.Y()-other.Y()
+	return tsplibRound(math.Sqrt(dx*dx + dy*dy))
+}
+
+// TSPLIBPseudoEuclideanDistance is the ATT distance of the att48 and att532 instances.
+var TSPLIBPseudoEuclideanDistance gomath.DistanceFunction = func(one, other gomath.Spatial) float64 {
+	dx, dy := one.X()-other.X(), one.Y()-other.Y()
+	distance := math.Sqrt((dx*dx + dy*dy) / 10.0)
+	rounded := tsplibRound(distance)
+	if rounded < distance {
+		return rounded + 1
+	}
+	return rounded
+}
+
+// TSPLIBGeographicalDistance is the GEO distance in kilometers between coordinates given as
+// DDD.MM latitude and longitude, on the idealized sphere TSPLIB uses.
+var TSPLIBGeographicalDistance gomath.DistanceFunction = func(one, other gomath.Spatial) float64 {
+	toRadians := func(value float64) float64 {
+		degrees := math.Trunc(value)
+		return 3.141592 * (degrees + 5.0*(value-degrees)/3.0) / 180.0
+	}
+	latitude1, longitude1 := toRadians(one.X()), toRadians(one.Y())
+	latitude2, longitude2 := toRadians(other.X()), toRadians(other.Y())
+	q1 := math.Cos(longitude1 - longitude2)
+	q2 := math.Cos(latitude1 - latitude2)
+	q3 := math.Cos(latitude1 + latitude2)
+	return math.Trunc(6378.388*math.Acos(0.5*((1.0+q1)*q2-(1.0-q1)*q3)) + 1.0)
+}
+
+// WriteTour writes path as a TSPLIB .tour file listing the ids of its vertices in order. A closing
+// edge back to the first vertex is implied by the format and not listed twice. Every vertex must
+// have an id, such as the node number of a TSPLIBInstance vertex.
+func WriteTour(writer io.Writer, path Path, name string) error {
+	ids, err := tourIds(path)
+	if err != nil {
+		return err
+	}
+	output := bufio.NewWriter(writer)
+	_, _ = fmt.Fprintf(output, "NAME : %s\nTYPE : TOUR\nDIMENSION : %d\nTOUR_SECTION\n", name, len(ids))
+	for _, id := range ids {
+		_, _ = fmt.Fprintln(output, id)
+	}
+	_, _ = fmt.Fprint(output, "-1\nEOF\n")
+	return output.Flush()
+}
+
+// tourIds lists the ids of the vertices of path in order, leaving out the return to the first.
+func tourIds(path Path) ([]int64, error) {
+	edges := path.GetEdges()
+	if len(edges) == 0 {
+		return []int64{}, nil
+	}
+	vertices := make([]Vertex, 0, len(edges)+1)
+	for _, edge := range edges {
+		vertices = append(vertices, ToVertex(edge.From()))
+	}
+	if last := ToVertex(edges[len(edges)-1].To()); VertexHashOrId(last) != VertexHashOrId(vertices[0]) {
+		vertices = append(vertices, last)
+	}
+	ids := make([]int64, len(vertices))
+	seen := make(map[int64]bool, len(vertices))
+	for i, vertex := range vertices {
+		if vertex.Id() <= 0 {
+			return nil, fmt.Errorf("tour vertex %v has no id", vertex.GetValues())
+		}
+		if seen[vertex.Id()] {
+			return nil, fmt.Errorf("tour visits vertex %d twice", vertex.Id())
+		}
+		seen[vertex.Id()] = true
+		ids[i] = vertex.Id()
+	}
+	return ids, nil
+}
+
+// ReadTour reads a TSPLIB .tour file into a closed path through the vertices of graph with the
+// listed ids, using the edges of graph between them where they exist.
+func ReadTour(reader io.Reader, graph Graph) (*SimplePath, error) {
+	lines := newTSPLIBLines(reader)
+	ids := make([]int64, 0)
+	for {
+		fields, ok := lines.next()
+		if !ok {
+			break
+		}
+		keyword, value := tsplibKeyword(strings.Join(fields, " "))
+		switch keyword {
+		case "NAME", "COMMENT", "DIMENSION", "EOF":
+		case "TYPE":
+			if value != "TOUR" {
+				return nil, fmt.Errorf("%w: TYPE %s", ErrTSPLIBUnsupported, value)
+			}
+		case "TOUR_SECTION":
+			for done := false; !done; {
+				fields, ok := lines.next()
+				if !ok {
+					return nil, lines.errorf("TOUR_SECTION without -1")
+				}
+				for _, field := range fields {
+					id, err := strconv.ParseInt(field, 10, 64)
+					if err != nil {
+						return nil, lines.errorf("invalid node %q", field)
+					}
+					if id == -1 {
+						done = true
+						break
+					}
+					ids = append(ids, id)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%w: line %d: keyword %s", ErrTSPLIBUnsupported, lines.line, keyword)
+		}
+	}
+	if err := lines.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) < 2 {
+		return nil, errors.New("tour with fewer than two nodes")
+	}
+	vertices := make([]Vertex, len(ids))
+	for i, id := range ids {
+		if vertices[i] = graph.GetVertex(id); vertices[i] == nil {
+			return nil, fmt.Errorf("tour node %d is not in the graph", id)
+		}
+	}
+	edges := make([]Edge, len(vertices))
+	for i, from := range vertices {
+		to := vertices[(i+1)%len(vertices)]
+		if parallel := GetEdges(from, to); len(parallel) > 0 {
+			edges[i] = parallel[0]
+		} else {
+			edges[i] = NewEdge(from, to)
+		}
+	}
+	return NewSimplePath(edges), nil
+}
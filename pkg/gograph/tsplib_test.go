@@ -0,0 +1,170 @@
+package gograph
+
+import (
+	"bytes"
+	"errors"
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"math"
+	"strings"
+	"testing"
+)
+
+const tsplibSquare = `NAME : square4
+COMMENT : A 3 by 4 rectangle
+TYPE : TSP
+DIMENSION : 4
+EDGE_WEIGHT_TYPE : EUC_2D
+NODE_COORD_SECTION
+1 0 0
+2 3 0
+3 3 4
+4 0 4
+EOF
+`
+
+func TestReadTSPLIB_Euclidean(t *testing.T) {
+	instance, err := ReadTSPLIB(strings.NewReader(tsplibSquare))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.Name != "square4" || instance.Comment != "A 3 by 4 rectangle" || instance.Dimension != 4 || instance.EdgeWeightType != TSPLIB_EUC_2D {
+		t.Errorf("Unexpected specification %+v", instance)
+	}
+	graph := instance.Graph
+	if graph.IsDirected() || graph.Size() != 4 || len(graph.GetEdges()) != 12 || !graph.Validate().IsValid() {
+		t.Fatalf("Expected the complete graph of 4 vertices, got %d edges", len(graph.GetEdges()))
+	}
+	one, two, three, four := graph.GetVertex(1), graph.GetVertex(2), graph.GetVertex(3), graph.GetVertex(4)
+	if (*GetEdge(one, three).Cost())[COST_TYPE_DISTANCE] != 5.0 || (*GetEdge(three, one).Cost())[COST_TYPE_DISTANCE] != 5.0 {
+		t.Error("Expected the diagonal to cost 5 both ways")
+	}
+	if len(three.GetEdges()) != 3 || graph.InDegree(three) != 3 {
+		t.Error("Expected every vertex to have 3 neighbours")
+	}
+
+	response := GreedyTSP(instance.Request())
+	if length := instance.TourLength(response.Path); length != 14.0 {
+		t.Errorf("Expected the perimeter tour of length 14, got %v", length)
+	}
+	crossed := NewSimplePath([]Edge{GetEdge(one, three), GetEdge(three, two), GetEdge(two, four), GetEdge(four, one)})
+	if gap := instance.TourGap(crossed, response.Path); math.Abs(gap-4.0/14.0) > 1e-12 {
+		t.Errorf("Expected a gap of 4/14, got %v", gap)
+	}
+	empty := NewSimplePath([]Edge{})
+	if instance.TourGap(empty, empty) != 0 || !math.IsInf(instance.TourGap(crossed, empty), 1) {
+		t.Error("Expected the gap to an optimal tour of length zero not to divide by zero")
+	}
+}
+
+func TestTSPLIBDistances(t *testing.T) {
+	point := func(x, y float64) gomath.Point {
+		return gomath.Point{Values: []float64{x, y}}
+	}
+	if distance := TSPLIBEuclideanDistance(point(0, 0), point(1, 1)); distance != 1.0 {
+		t.Errorf("Expected EUC_2D to round to 1, got %v", distance)
+	}
+	if distance := TSPLIBPseudoEuclideanDistance(point(0, 0), point(10, 0)); distance != 4.0 {
+		t.Errorf("Expected ATT to round sqrt(10) up to 4, got %v", distance)
+	}
+	if distance := TSPLIBPseudoEuclideanDistance(point(0, 0), point(0, 20)); distance != 7.0 {
+		t.Errorf("Expected ATT to round sqrt(40) up to 7, got %v", distance)
+	}
+	if distance := TSPLIBGeographicalDistance(point(0, 0), point(1, 0)); distance != 112.0 {
+		t.Errorf("Expected one degree of latitude to be 112 km, got %v", distance)
+	}
+	if distance := TSPLIBGeographicalDistance(point(0, 0), point(0.30, 0)); distance != 56.0 {
+		t.Errorf("Expected 30 minutes of latitude to be 56 km, got %v", distance)
+	}
+}
+
+func TestReadTSPLIB_Explicit(t *testing.T) {
+	header := "NAME : triangle\nTYPE : TSP\nDIMENSION : 3\nEDGE_WEIGHT_TYPE : EXPLICIT\n"
+	for format, section := range map[string]string{
+		"UPPER_ROW":      "1 2\n3",
+		"LOWER_DIAG_ROW": "0\n1 0\n2 3 0",
+		"FULL_MATRIX":    "0 1 2\n1 0 3\n2 3 0",
+		"LOWER_COL":      "1 2 3",
+	} {
+		instance, err := ReadTSPLIB(strings.NewReader(header + "EDGE_WEIGHT_FORMAT : " + format + "\nEDGE_WEIGHT_SECTION\n" + section + "\nEOF\n"))
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		one, two, three := instance.Graph.GetVertex(1), instance.Graph.GetVertex(2), instance.Graph.GetVertex(3)
+		if instance.DistanceFunction(one, two) != 1 || instance.DistanceFunction(one, three) != 2 || instance.DistanceFunction(three, two) != 3 {
+			t.Errorf("%s: unexpected distances", format)
+		}
+		if one.X() != 1 || three.X() != 3 {
+			t.Errorf("%s: expected nodes placed at their numbers", format)
+		}
+	}
+
+	asymmetric := "NAME : asym\nTYPE : ATSP\nDIMENSION : 3\nEDGE_WEIGHT_TYPE : EXPLICIT\nEDGE_WEIGHT_FORMAT : FULL_MATRIX\n" +
+		"DISPLAY_DATA_SECTION\n1 0 0\n2 1 0\n3 0 1\nEDGE_WEIGHT_SECTION\n0 1 2\n5 0 3\n4 6 0\nEOF\n"
+	instance, err := ReadTSPLIB(strings.NewReader(asymmetric))
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := instance.Graph
+	one, two := graph.GetVertex(1), graph.GetVertex(2)
+	if !graph.IsDirected() || len(graph.GetEdges()) != 6 || two.X() != 1 {
+		t.Fatal("Expected a complete directed graph at the display coordinates")
+	}
+	if (*GetEdge(one, two).Cost())[COST_TYPE_DISTANCE] != 1 || (*GetEdge(two, one).Cost())[COST_TYPE_DISTANCE] != 5 {
+		t.Error("Expected asymmetric costs")
+	}
+	if distance := instance.DistanceFunction(gomath.Point{Values: []float64{0, 1}}, one); distance != 4 {
+		t.Errorf("Expected spatials to be found by position, got %v", distance)
+	}
+	if distance := instance.DistanceFunction(gomath.Point{Values: []float64{5, 5}}, one); !math.IsInf(distance, 1) {
+		t.Errorf("Expected an infinite distance to a spatial outside the instance, got %v", distance)
+	}
+}
+
+func TestReadTSPLIB_Errors(t *testing.T) {
+	for name, text := range map[string]string{
+		"type":        "TYPE : CVRP\nDIMENSION : 1\nEDGE_WEIGHT_TYPE : EUC_2D\nNODE_COORD_SECTION\n1 0 0\n",
+		"weight type": "DIMENSION : 1\nEDGE_WEIGHT_TYPE : MAN_3D\nNODE_COORD_SECTION\n1 0 0\n",
+		"keyword":     "DIMENSION : 1\nDEPOT_SECTION\n1\n-1\n",
+	} {
+		if _, err := ReadTSPLIB(strings.NewReader(text)); !errors.Is(err, ErrTSPLIBUnsupported) {
+			t.Errorf("%s: expected an unsupported error, got %v", name, err)
+		}
+	}
+	_, err := ReadTSPLIB(strings.NewReader("DIMENSION : 2\nEDGE_WEIGHT_TYPE : EUC_2D\nNODE_COORD_SECTION\n1 0 0\n2 0 north\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 5") {
+		t.Errorf("Expected an error on line 5, got %v", err)
+	}
+	if _, err := ReadTSPLIB(strings.NewReader("DIMENSION : 2\nEDGE_WEIGHT_TYPE : EUC_2D\n")); err == nil {
+		t.Error("Expected an error without coordinates")
+	}
+}
+
+func TestTour_RoundTrip(t *testing.T) {
+	instance, err := ReadTSPLIB(strings.NewReader(tsplibSquare))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tour := GreedyTSP(instance.Request()).Path
+	var buffer bytes.Buffer
+	if err := WriteTour(&buffer, tour, "square4.tour"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), "DIMENSION : 4\nTOUR_SECTION\n") || !strings.HasSuffix(buffer.String(), "-1\nEOF\n") {
+		t.Errorf("Unexpected tour file %q", buffer.String())
+	}
+	decoded, err := ReadTour(&buffer, instance.Graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Length() != 4 || instance.TourLength(decoded) != instance.TourLength(tour) || instance.TourGap(decoded, tour) != 0 {
+		t.Error("Expected the decoded tour to equal the tour")
+	}
+
+	if _, err := ReadTour(strings.NewReader("TYPE : TOUR\nTOUR_SECTION\n1 2 9\n-1\n"), instance.Graph); err == nil {
+		t.Error("Expected an error for a node outside the graph")
+	}
+	stranger := NewSimpleVertex(gomath.Point{Values: []float64{0, 0}})
+	if err := WriteTour(&buffer, NewSimplePath([]Edge{NewSimpleEdge(&stranger, instance.Graph.GetVertex(1), -1)}), "x"); err == nil {
+		t.Error("Expected an error for a vertex without an id")
+	}
+}
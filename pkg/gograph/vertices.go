@@ -24,7 +24,7 @@ type Vertex interface {
 
 func GetEdge(from Vertex, to Vertex) Edge {
 	for _, edge := range from.GetEdges() {
-		if ToVertex(edge.To()).Hash() == to.Hash() {
+		if VertexHashOrId(ToVertex(edge.To())) == VertexHashOrId(to) {
 			return edge
 		}
 	}
@@ -36,6 +36,27 @@ func GetEdge(from Vertex, to Vertex) Edge {
 	}
 }
 
+// GetEdges returns every edge from `from` to `to`, including parallel edges of a multigraph.
+func GetEdges(from Vertex, to Vertex) []Edge {
+	retArray := make([]Edge, 0)
+	for _, edge := range from.GetEdges() {
+		if VertexHashOrId(ToVertex(edge.To())) == VertexHashOrId(to) {
+			retArray = append(retArray, edge)
+		}
+	}
+	return retArray
+}
+
+func vertexContainsEdge(vertex Vertex, edge Edge) bool {
+	key := EdgeHashOrId(edge)
+	for _, e := range vertex.GetEdges() {
+		if EdgeHashOrId(e) == key {
+			return true
+		}
+	}
+	return false
+}
+
 func VertexFromSpatial(spatial gomath.Spatial) Vertex {
 	return &SimpleVertex{Spatial: spatial, Edges: []Edge{}, id: -1, hash: -1}
 }
@@ -54,7 +75,7 @@ func VertexHashOrId(vertex Vertex) int64 {
 	if vertex.Id() > 0 {
 		return vertex.Id()
 	}
-	return HashVertex(vertex)
+	return vertex.Hash()
 }
 
 func HashVertex(vertex Vertex) int64 {
@@ -88,16 +109,26 @@ func HashVertex(vertex Vertex) int64 {
 }
 
 type SimpleVertex struct {
-	Spatial gomath.Spatial
-	Edges   []Edge
-	id      int64
-	hash    int64
+	Spatial    gomath.Spatial
+	Edges      []Edge
+	id         int64
+	hash       int64
+	properties *Properties
+	// multi is set once a multigraph adds an edge from the vertex, so that routing looks for
+	// parallel edges only where there can be some.
+	multi bool
 }
 
 func NewSimpleVertex(spatial gomath.Spatial, edges ...Edge) SimpleVertex {
 	return SimpleVertex{Spatial: spatial, Edges: edges, id: -1, hash: -1}
 }
 
+// NewSimpleVertexWithId creates a vertex whose identity is id instead of its coordinates, so that
+// distinct vertices can share a location. Ids must be positive.
+func NewSimpleVertexWithId(spatial gomath.Spatial, id int64, edges ...Edge) SimpleVertex {
+	return SimpleVertex{Spatial: spatial, Edges: edges, id: id, hash: -1}
+}
+
 func (v *SimpleVertex) GetEdge(to Vertex) Edge {
 	return GetEdge(v, to)
 }
@@ -108,6 +139,7 @@ func (v *SimpleVertex) DistanceTo(other SimpleVertex, distanceFunction ...gomath
 
 func (v *SimpleVertex) SetValues(values []float64) {
 	v.Spatial = gomath.NewPoint(values...)
+	v.hash = -1
 }
 
 func (v *SimpleVertex) GetValues() []float64 {
@@ -138,12 +170,32 @@ func (v *SimpleVertex) Id() int64 {
 	return v.id
 }
 
+// Properties returns the properties of the vertex, creating an empty bag on first use.
+func (v *SimpleVertex) Properties() *Properties {
+	if v.properties == nil {
+		v.properties = NewProperties()
+	}
+	return v.properties
+}
+
+func (v *SimpleVertex) SetProperties(properties *Properties) {
+	v.properties = properties
+}
+
+// SetId changes the identity of the vertex. Set it before adding the vertex to a graph.
+func (v *SimpleVertex) SetId(id int64) {
+	v.id = id
+	v.hash = -1
+}
+
 func (v *SimpleVertex) GetEdges() []Edge {
 	return v.Edges
 }
 
+// Hash caches HashVertex. A hash of 0 counts as not computed yet, so that vertices built as
+// struct literals are hashed too.
 func (v *SimpleVertex) Hash() int64 {
-	if v.hash <= 0 {
+	if v.hash == -1 || v.hash == 0 {
 		v.hash = HashVertex(v)
 	}
 	return v.hash
@@ -154,20 +206,42 @@ func (v *SimpleVertex) AddEdge(edge Edge) {
 }
 
 func (v *SimpleVertex) RemoveEdge(edge Edge) {
+	key := EdgeHashOrId(edge)
 	for i, e := range v.Edges {
-		if e.Hash() == edge.Hash() {
+		if EdgeHashOrId(e) == key {
 			v.Edges = append(v.Edges[:i], v.Edges[i+1:]...)
 			return
 		}
 	}
 }
 
+// VertexWrapper is a vertex reached during routing. Via is the edge taken from Previous, which
+// tells parallel edges of a multigraph apart.
 type VertexWrapper struct {
 	Previous *VertexWrapper
 	Inner    Vertex
 	Next     *VertexWrapper
 	Costs    map[string]CostEntry
 	Combined *CostEntry
+	Via      Edge
+}
+
+// ViaEdge wraps the target of edge so that cost functions evaluated towards it see that exact
+// edge through TraversedEdge.
+func ViaEdge(edge Edge) *VertexWrapper {
+	return &VertexWrapper{Inner: ToVertex(edge.To()), Via: edge}
+}
+
+// TraversedEdge returns the edge taken from `from` to `to`: the Via edge when `to` is a
+// VertexWrapper that has one, otherwise the first edge from `from` to `to`.
+func TraversedEdge(from Vertex, to gomath.Spatial) Edge {
+	if wrapper, ok := to.(*VertexWrapper); ok && wrapper.Via != nil {
+		return wrapper.Via
+	}
+	if wrapper, ok := from.(*VertexWrapper); ok {
+		from = wrapper.Inner
+	}
+	return GetEdge(from, ToVertex(to))
 }
 
 func NewVertexWrapper(inner Vertex, costs map[string]CostEntry, pCostCombiner ...CostCombiner) *VertexWrapper {
@@ -239,3 +313,7 @@ func (v *VertexWrapper) AddEdge(edge Edge) {
 func (v *VertexWrapper) RemoveEdge(edge Edge) {
 	v.Inner.RemoveEdge(edge)
 }
+
+func (v *VertexWrapper) Properties() *Properties {
+	return GetProperties(v.Inner)
+}
@@ -0,0 +1,378 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"hash/fnv"
+	"slices"
+	"time"
+)
+
+type VertexPredicate func(vertex Vertex) bool
+
+type EdgePredicate func(edge Edge) bool
+
+// GraphView is a lazy, read-only view of another Graph. A vertex is part of the view when it
+// passes the vertex predicate, and an edge when it passes the edge predicate and both of its
+// endpoints are part of the view. Nothing is copied: vertices and edges of the view wrap those of
+// the underlying graph, and the adjacency of a view vertex is filtered on every GetEdges call, so
+// changes to the underlying graph show through. Routing on a view must start from a vertex of
+// the view, see Vertex.
+type GraphView struct {
+	id              int64
+	graph           Graph
+	vertexPredicate VertexPredicate
+	edgePredicate   EdgePredicate
+}
+
+// NewGraphView creates a view of graph. A nil predicate accepts everything.
+func NewGraphView(graph Graph, vertexPredicate VertexPredicate, edgePredicate EdgePredicate) *GraphView {
+	return &GraphView{
+		id:              time.Now().UnixNano(),
+		graph:           graph,
+		vertexPredicate: vertexPredicate,
+		edgePredicate:   edgePredicate,
+	}
+}
+
+// InducedSubgraph is the view of vertices and every edge of graph between two of them.
+func InducedSubgraph(graph Graph, vertices []Vertex) *GraphView {
+	keys := make(map[int64]bool, len(vertices))
+	for _, vertex := range vertices {
+		keys[VertexHashOrId(vertex)] = true
+	}
+	return NewGraphView(graph, func(vertex Vertex) bool {
+		return keys[VertexHashOrId(vertex)]
+	}, nil)
+}
+
+// EdgeSubgraph is the view of edges and their endpoints. For an undirected graph both
+// directions of each edge are kept.
+func EdgeSubgraph(graph Graph, edges []Edge) *GraphView {
+	edgeKeys := make(map[int64]bool, len(edges))
+	vertexKeys := make(map[int64]bool, 2*len(edges))
+	for _, edge := range edges {
+		edgeKeys[EdgeHashOrId(edge)] = true
+		if !graph.IsDirected() {
+			edgeKeys[EdgeHashOrId(edge.Reverse())] = true
+		}
+		vertexKeys[VertexHashOrId(ToVertex(edge.From()))] = true
+		vertexKeys[VertexHashOrId(ToVertex(edge.To()))] = true
+	}
+	return NewGraphView(graph, func(vertex Vertex) bool {
+		return vertexKeys[VertexHashOrId(vertex)]
+	}, func(edge Edge) bool {
+		return edgeKeys[EdgeHashOrId(edge)]
+	})
+}
+
+// VertexInBoundingBox accepts the vertices inside box, e.g. to restrict routing to a region.
+func VertexInBoundingBox(box gomath.BoundingBox) VertexPredicate {
+	return func(vertex Vertex) bool {
+		return vertex.X() >= box.MinX && vertex.X() <= box.MaxX && vertex.Y() >= box.MinY && vertex.Y() <= box.MaxY
+	}
+}
+
+// ExcludeEdges rejects edges, and for undirected graphs their reverses, e.g. closed roads.
+func ExcludeEdges(graph Graph, edges []Edge) EdgePredicate {
+	excluded := make(map[int64]bool, len(edges))
+	for _, edge := range edges {
+		excluded[EdgeHashOrId(edge)] = true
+		if !graph.IsDirected() {
+			excluded[EdgeHashOrId(edge.Reverse())] = true
+		}
+	}
+	return func(edge Edge) bool {
+		return !excluded[EdgeHashOrId(edge)]
+	}
+}
+
+// Graph returns the underlying graph of the view.
+func (g *GraphView) Graph() Graph {
+	return g.graph
+}
+
+// Vertex returns the view's counterpart of a vertex of the underlying graph, or nil when the
+// vertex is not part of the view.
+func (g *GraphView) Vertex(v Vertex) Vertex {
+	inner := g.graph.GetVertex(VertexHashOrId(g.unwrapVertex(v)))
+	if inner == nil || !g.includesVertex(inner) {
+		return nil
+	}
+	return g.wrapVertex(inner)
+}
+
+func (g *GraphView) unwrapVertex(v Vertex) Vertex {
+	if viewVertex, ok := v.(*ViewVertex); ok && viewVertex.view == g {
+		return viewVertex.inner
+	}
+	return v
+}
+
+func (g *GraphView) unwrapEdge(e Edge) Edge {
+	if viewEdge, ok := e.(ViewEdge); ok && viewEdge.view == g {
+		return viewEdge.Edge
+	}
+	return e
+}
+
+func (g *GraphView) wrapVertex(v Vertex) *ViewVertex {
+	return &ViewVertex{view: g, inner: v}
+}
+
+func (g *GraphView) wrapEdge(e Edge) ViewEdge {
+	return ViewEdge{Edge: e, view: g}
+}
+
+func (g *GraphView) includesVertex(v Vertex) bool {
+	return g.vertexPredicate == nil || g.vertexPredicate(v)
+}
+
+func (g *GraphView) includesEdge(e Edge) bool {
+	if g.edgePredicate != nil && !g.edgePredicate(e) {
+		return false
+	}
+	return g.includesVertex(g.unwrapVertex(ToVertex(e.From()))) && g.includesVertex(g.unwrapVertex(ToVertex(e.To())))
+}
+
+func (g *GraphView) Id() int64 {
+	return g.id
+}
+
+func (g *GraphView) GetEdge(id int64) Edge {
+	edge := g.graph.GetEdge(id)
+	if edge == nil || !g.includesEdge(edge) {
+		return nil
+	}
+	return g.wrapEdge(edge)
+}
+
+func (g *GraphView) GetVertex(id int64) Vertex {
+	vertex := g.graph.GetVertex(id)
+	if vertex == nil || !g.includesVertex(vertex) {
+		return nil
+	}
+	return g.wrapVertex(vertex)
+}
+
+func (g *GraphView) AddEdge(_ Edge) {
+	panic("GraphView is read-only")
+}
+
+func (g *GraphView) ContainsEdge(e Edge) bool {
+	inner := g.unwrapEdge(e)
+	if !g.graph.ContainsEdge(inner) {
+		return false
+	}
+	if stored := g.graph.GetEdge(EdgeHashOrId(inner)); stored != nil {
+		inner = stored
+	}
+	return g.includesEdge(inner)
+}
+
+func (g *GraphView) ContainsVertex(v Vertex) bool {
+	return g.Vertex(v) != nil
+}
+
+func (g *GraphView) AddVertex(_ Vertex) {
+	panic("GraphView is read-only")
+}
+
+func (g *GraphView) GetVertices() []Vertex {
+	retArray := make([]Vertex, 0)
+	for _, vertex := range g.graph.GetVertices() {
+		if g.includesVertex(vertex) {
+			retArray = append(retArray, g.wrapVertex(vertex))
+		}
+	}
+	return retArray
+}
+
+func (g *GraphView) GetEdges() []Edge {
+	retArray := make([]Edge, 0)
+	for _, edge := range g.graph.GetEdges() {
+		if g.includesEdge(edge) {
+			retArray = append(retArray, g.wrapEdge(edge))
+		}
+	}
+	return retArray
+}
+
+func (g *GraphView) RemoveEdge(_ Edge) {
+	panic("GraphView is read-only")
+}
+
+func (g *GraphView) RemoveVertex(_ Vertex) {
+	panic("GraphView is read-only")
+}
+
+func (g *GraphView) Size() int {
+	size := 0
+	for _, vertex := range g.graph.GetVertices() {
+		if g.includesVertex(vertex) {
+			size++
+		}
+	}
+	return size
+}
+
+func (g *GraphView) Clear() {
+	panic("GraphView is read-only")
+}
+
+// Hash is computed from the keys of the vertices and edges currently in the view.
+func (g *GraphView) Hash() int64 {
+	allKeys := make([]int64, 0)
+	for _, vertex := range g.GetVertices() {
+		allKeys = append(allKeys, VertexHashOrId(vertex))
+	}
+	vertexCount := len(allKeys)
+	for _, edge := range g.GetEdges() {
+		allKeys = append(allKeys, EdgeHashOrId(edge))
+	}
+	slices.Sort(allKeys[:vertexCount])
+	slices.Sort(allKeys[vertexCount:])
+	hasher := fnv.New64a()
+	for _, key := range allKeys {
+		var buf [8]byte
+		for i := 0; i < 8; i++ {
+			buf[i] = byte(key >> (i * 8))
+		}
+		_, _ = hasher.Write(buf[:])
+	}
+	return int64(hasher.Sum64())
+}
+
+func (g *GraphView) IsDirected() bool {
+	return g.graph.IsDirected()
+}
+
+func (g *GraphView) InDegree(v Vertex) int {
+	return len(g.GetIncomingEdges(v))
+}
+
+func (g *GraphView) OutDegree(v Vertex) int {
+	vertex := g.Vertex(v)
+	if vertex == nil {
+		return 0
+	}
+	return len(vertex.GetEdges())
+}
+
+func (g *GraphView) GetIncomingEdges(v Vertex) []Edge {
+	retArray := make([]Edge, 0)
+	if g.Vertex(v) == nil {
+		return retArray
+	}
+	for _, edge := range g.graph.GetIncomingEdges(g.unwrapVertex(v)) {
+		if g.includesEdge(edge) {
+			retArray = append(retArray, g.wrapEdge(edge))
+		}
+	}
+	return retArray
+}
+
+// ViewVertex <editor-fold>
+
+// ViewVertex is a vertex of the underlying graph as seen through a GraphView. It keeps the id and
+// hash of the vertex it wraps.
+type ViewVertex struct {
+	view  *GraphView
+	inner Vertex
+}
+
+// Inner returns the vertex of the underlying graph.
+func (v *ViewVertex) Inner() Vertex {
+	return v.inner
+}
+
+func (v *ViewVertex) GetValues() []float64 {
+	return v.inner.GetValues()
+}
+
+func (v *ViewVertex) Size() int {
+	return v.inner.Size()
+}
+
+func (v *ViewVertex) X() float64 {
+	return v.inner.X()
+}
+
+func (v *ViewVertex) Y() float64 {
+	return v.inner.Y()
+}
+
+func (v *ViewVertex) Z() float64 {
+	return v.inner.Z()
+}
+
+func (v *ViewVertex) W() float64 {
+	return v.inner.W()
+}
+
+func (v *ViewVertex) Id() int64 {
+	return v.inner.Id()
+}
+
+func (v *ViewVertex) GetEdges() []Edge {
+	retArray := make([]Edge, 0)
+	for _, edge := range v.inner.GetEdges() {
+		if v.view.includesEdge(edge) {
+			retArray = append(retArray, v.view.wrapEdge(edge))
+		}
+	}
+	return retArray
+}
+
+func (v *ViewVertex) Hash() int64 {
+	return VertexHashOrId(v.inner)
+}
+
+func (v *ViewVertex) GetEdge(to Vertex) Edge {
+	return GetEdge(v, to)
+}
+
+func (v *ViewVertex) Properties() *Properties {
+	return GetProperties(v.inner)
+}
+
+func (v *ViewVertex) AddEdge(_ Edge) {
+	panic("GraphView is read-only")
+}
+
+func (v *ViewVertex) RemoveEdge(_ Edge) {
+	panic("GraphView is read-only")
+}
+
+// </editor-fold>
+
+// ViewEdge <editor-fold>
+
+// ViewEdge is an edge of the underlying graph whose endpoints are vertices of the view. It keeps
+// the id, hash and cost of the edge it wraps.
+type ViewEdge struct {
+	Edge
+	view *GraphView
+}
+
+// Inner returns the edge of the underlying graph.
+func (e ViewEdge) Inner() Edge {
+	return e.Edge
+}
+
+func (e ViewEdge) From() gomath.Spatial {
+	return e.view.wrapVertex(ToVertex(e.Edge.From()))
+}
+
+func (e ViewEdge) To() gomath.Spatial {
+	return e.view.wrapVertex(ToVertex(e.Edge.To()))
+}
+
+func (e ViewEdge) Reverse() Edge {
+	return e.view.wrapEdge(e.Edge.Reverse())
+}
+
+func (e ViewEdge) Properties() *Properties {
+	return GetProperties(e.Edge)
+}
+
+// </editor-fold>
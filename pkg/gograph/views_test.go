@@ -0,0 +1,88 @@
+package gograph
+
+import (
+	"github.com/mtresnik/gomath/pkg/gomath"
+	"testing"
+)
+
+func buildViewTestGraph() (*SimpleGraph, []Vertex, Edge) {
+	graph := NewSimpleGraph()
+	a := NewSimpleVertex(gomath.Point{Values: []float64{0.0, 0.0}})
+	b := NewSimpleVertex(gomath.Point{Values: []float64{1.0, 1.0}})
+	c := NewSimpleVertex(gomath.Point{Values: []float64{2.0, 1.0}})
+	d := NewSimpleVertex(gomath.Point{Values: []float64{3.0, 0.0}})
+	shortcut := NewSimpleEdge(&a, &d, -1)
+	graph.AddEdge(NewSimpleEdge(&a, &b, -1))
+	graph.AddEdge(NewSimpleEdge(&b, &c, -1))
+	graph.AddEdge(NewSimpleEdge(&c, &d, -1))
+	graph.AddEdge(shortcut)
+	return graph, []Vertex{&a, &b, &c, &d}, shortcut
+}
+
+func TestGraphView_ExcludeEdges(t *testing.T) {
+	graph, vertices, shortcut := buildViewTestGraph()
+	view := NewGraphView(graph, nil, ExcludeEdges(graph, []Edge{shortcut}))
+	if len(view.GetEdges()) != 6 || len(graph.GetEdges()) != 8 {
+		t.Errorf("Expected 6 edges in the view and 8 in the graph, got %d and %d", len(view.GetEdges()), len(graph.GetEdges()))
+	}
+	if view.ContainsEdge(shortcut) || view.ContainsEdge(shortcut.Reverse()) || !graph.ContainsEdge(shortcut) {
+		t.Error("The view should hide the shortcut in both directions without removing it from the graph")
+	}
+	start := view.Vertex(vertices[0])
+	if len(start.GetEdges()) != 1 || view.OutDegree(vertices[0]) != 1 || view.InDegree(vertices[3]) != 1 {
+		t.Errorf("Unexpected adjacency in the view: %d", len(start.GetEdges()))
+	}
+	response := AStar(RoutingAlgorithmRequest{
+		Start:       start,
+		Destination: view.Vertex(vertices[3]),
+	})
+	if !response.Completed || len(response.Path.GetEdges()) != 3 {
+		t.Errorf("Expected a detour of 3 edges, got %d", len(response.Path.GetEdges()))
+	}
+	direct := AStar(RoutingAlgorithmRequest{
+		Start:       graph.GetVertex(VertexHashOrId(vertices[0])),
+		Destination: vertices[3],
+	})
+	if len(direct.Path.GetEdges()) != 1 {
+		t.Errorf("Expected the underlying graph to keep the shortcut, got %d edges", len(direct.Path.GetEdges()))
+	}
+}
+
+func TestGraphView_InducedSubgraph(t *testing.T) {
+	graph, vertices, _ := buildViewTestGraph()
+	view := InducedSubgraph(graph, vertices[:3])
+	if view.Size() != 3 || len(view.GetEdges()) != 4 {
+		t.Errorf("Expected 3 vertices and 4 edges, got %d and %d", view.Size(), len(view.GetEdges()))
+	}
+	if view.ContainsVertex(vertices[3]) || view.Vertex(vertices[3]) != nil {
+		t.Error("The induced subgraph should not contain d")
+	}
+	for _, edge := range view.GetEdges() {
+		if !view.ContainsVertex(ToVertex(edge.To())) || !view.ContainsEdge(edge) {
+			t.Error("Edges of the view should end in the view")
+		}
+	}
+	region := NewGraphView(graph, VertexInBoundingBox(gomath.BoundingBox{MinX: 0.5, MinY: 0.5, MaxX: 3.0, MaxY: 2.0}), nil)
+	if region.Size() != 2 || len(region.GetEdges()) != 2 {
+		t.Errorf("Expected b, c and the edge between them, got %d and %d", region.Size(), len(region.GetEdges()))
+	}
+	graph.RemoveEdge(NewSimpleEdge(vertices[1], vertices[2], -1))
+	if len(region.GetEdges()) != 0 {
+		t.Error("Changes to the underlying graph should show through the view")
+	}
+}
+
+func TestGraphView_EdgeSubgraph(t *testing.T) {
+	graph, vertices, shortcut := buildViewTestGraph()
+	view := EdgeSubgraph(graph, []Edge{shortcut})
+	if view.Size() != 2 || len(view.GetEdges()) != 2 {
+		t.Errorf("Expected the shortcut and its endpoints, got %d and %d", view.Size(), len(view.GetEdges()))
+	}
+	if view.Vertex(vertices[1]) != nil {
+		t.Error("b is not an endpoint of the shortcut")
+	}
+	mst := KruskalMST(MSTRequest{Graph: view})
+	if mst.Error != nil || len(mst.Graph.GetVertices()) != 2 {
+		t.Errorf("Expected a spanning tree of the shortcut, got %v", mst.Error)
+	}
+}